@@ -1,20 +1,42 @@
 package config
 
-import "os"
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type Config struct {
 	Port          string
 	AdminUsername string
 	AdminPassword string
 	DatabaseURL   string
+	JobWorkers    int
+	// MaxUploadSize is the largest upload accepted, in bytes.
+	MaxUploadSize int64
+	// TrustedProxies are the CIDR ranges of the proxies whose X-Forwarded-For
+	// header is believed; without any the client IP is the connection's.
+	TrustedProxies []string
+	JWTSecret      string
+	TokenTTL       time.Duration
+	// MigrateOnStart applies pending database migrations before serving.
+	MigrateOnStart bool
 }
 
 func LoadConfig() *Config {
 	return &Config{
-		Port:          getEnv("PORT", ""),
-		AdminUsername: getEnv("ADMIN_USERNAME", ""),
-		AdminPassword: getEnv("ADMIN_PASSWORD", ""),
-		DatabaseURL:   getEnv("DATABASE_URL", ""),
+		Port:           getEnv("PORT", ""),
+		AdminUsername:  getEnv("ADMIN_USERNAME", ""),
+		AdminPassword:  getEnv("ADMIN_PASSWORD", ""),
+		DatabaseURL:    getEnv("DATABASE_URL", ""),
+		JobWorkers:     getEnvInt("JOB_WORKERS", runtime.NumCPU()),
+		MaxUploadSize:  int64(getEnvInt("MAX_UPLOAD_BYTES", 32<<20)),
+		TrustedProxies: getEnvList("TRUSTED_PROXIES"),
+		JWTSecret:      getEnv("JWT_SECRET", ""),
+		TokenTTL:       getEnvDuration("JWT_TTL", time.Hour),
+		MigrateOnStart: getEnvBool("MIGRATE_ON_START", false),
 	}
 
 }
@@ -26,3 +48,35 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+func getEnvList(key string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
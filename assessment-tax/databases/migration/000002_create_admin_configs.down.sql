@@ -1,5 +1,3 @@
 DROP TABLE IF EXISTS admin_configs;
 
-DROP TABLE IF EXISTS tax_calculations;
-
-DROP TABLE IF EXISTS schema_migrations;
\ No newline at end of file
+DROP TABLE IF EXISTS tax_calculations;
\ No newline at end of file
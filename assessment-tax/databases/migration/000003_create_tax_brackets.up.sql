@@ -0,0 +1,24 @@
+BEGIN;
+
+CREATE TABLE
+    tax_brackets (
+        id SERIAL PRIMARY KEY,
+        threshold DECIMAL(14, 2) NOT NULL UNIQUE CHECK (threshold >= 0),
+        rate DECIMAL(5, 4) NOT NULL CHECK (
+            rate >= 0
+            AND rate <= 1
+        ),
+        created_at TIMESTAMP NOT NULL DEFAULT NOW ()
+    );
+
+-- Thai personal income tax schedule
+INSERT INTO
+    tax_brackets (threshold, rate)
+VALUES
+    (0.00, 0.0000),
+    (150000.00, 0.1000),
+    (500000.00, 0.1500),
+    (1000000.00, 0.2000),
+    (2000000.00, 0.3500);
+
+COMMIT;
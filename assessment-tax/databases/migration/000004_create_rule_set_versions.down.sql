@@ -0,0 +1,31 @@
+BEGIN;
+
+DELETE FROM tax_brackets
+WHERE
+    admin_config_id <> (
+        SELECT
+            id
+        FROM
+            admin_configs
+        ORDER BY
+            tax_year DESC,
+            version DESC
+        LIMIT
+            1
+    );
+
+ALTER TABLE tax_brackets
+DROP CONSTRAINT tax_brackets_admin_config_id_threshold_key,
+DROP COLUMN admin_config_id,
+ADD CONSTRAINT tax_brackets_threshold_key UNIQUE (threshold);
+
+ALTER TABLE admin_configs
+DROP CONSTRAINT admin_configs_effective_range_check,
+DROP CONSTRAINT admin_configs_tax_year_version_key,
+DROP COLUMN effective_to,
+DROP COLUMN effective_from,
+DROP COLUMN donation_cap,
+DROP COLUMN version,
+DROP COLUMN tax_year;
+
+COMMIT;
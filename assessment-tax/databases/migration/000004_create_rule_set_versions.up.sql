@@ -0,0 +1,67 @@
+BEGIN;
+
+-- admin_configs becomes an append-only list of rule set versions keyed by tax year
+ALTER TABLE admin_configs
+ADD COLUMN tax_year INTEGER,
+ADD COLUMN version INTEGER NOT NULL DEFAULT 1 CHECK (version > 0),
+ADD COLUMN donation_cap DECIMAL(10, 2) NOT NULL DEFAULT 100000.00 CHECK (donation_cap >= 0),
+ADD COLUMN effective_from DATE,
+ADD COLUMN effective_to DATE;
+
+UPDATE admin_configs
+SET
+    tax_year = EXTRACT(YEAR FROM created_at)::INTEGER,
+    effective_from = MAKE_DATE (EXTRACT(YEAR FROM created_at)::INTEGER, 1, 1);
+
+UPDATE admin_configs c
+SET
+    version = v.version
+FROM
+    (
+        SELECT
+            id,
+            ROW_NUMBER() OVER (
+                PARTITION BY tax_year
+                ORDER BY id
+            ) AS version
+        FROM
+            admin_configs
+    ) v
+WHERE
+    c.id = v.id;
+
+ALTER TABLE admin_configs
+ALTER COLUMN tax_year SET NOT NULL,
+ALTER COLUMN effective_from SET NOT NULL,
+ADD CONSTRAINT admin_configs_tax_year_version_key UNIQUE (tax_year, version),
+ADD CONSTRAINT admin_configs_effective_range_check CHECK (
+    effective_to IS NULL
+    OR effective_to >= effective_from
+);
+
+-- every rule set version carries its own bracket schedule
+ALTER TABLE tax_brackets
+ADD COLUMN admin_config_id INTEGER REFERENCES admin_configs (id) ON DELETE CASCADE;
+
+INSERT INTO
+    tax_brackets (admin_config_id, threshold, rate)
+SELECT
+    c.id,
+    b.threshold,
+    b.rate
+FROM
+    admin_configs c
+    CROSS JOIN tax_brackets b
+WHERE
+    b.admin_config_id IS NULL;
+
+DELETE FROM tax_brackets
+WHERE
+    admin_config_id IS NULL;
+
+ALTER TABLE tax_brackets
+DROP CONSTRAINT tax_brackets_threshold_key,
+ALTER COLUMN admin_config_id SET NOT NULL,
+ADD CONSTRAINT tax_brackets_admin_config_id_threshold_key UNIQUE (admin_config_id, threshold);
+
+COMMIT;
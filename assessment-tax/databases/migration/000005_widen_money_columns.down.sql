@@ -0,0 +1,14 @@
+BEGIN;
+
+ALTER TABLE tax_brackets
+ALTER COLUMN threshold TYPE DECIMAL(14, 2);
+
+ALTER TABLE tax_calculations
+ALTER COLUMN totalIncome TYPE DECIMAL(10, 2),
+ALTER COLUMN wht TYPE DECIMAL(10, 2),
+ALTER COLUMN personal_allowance TYPE DECIMAL(10, 2),
+ALTER COLUMN donation TYPE DECIMAL(10, 2),
+ALTER COLUMN k_receipt TYPE DECIMAL(10, 2),
+ALTER COLUMN tax TYPE DECIMAL(10, 2);
+
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+
+-- amounts are exact satang values; keep them in NUMERIC with two decimals and
+-- enough headroom for large incomes
+ALTER TABLE tax_calculations
+ALTER COLUMN totalIncome TYPE NUMERIC(15, 2),
+ALTER COLUMN wht TYPE NUMERIC(15, 2),
+ALTER COLUMN personal_allowance TYPE NUMERIC(15, 2),
+ALTER COLUMN donation TYPE NUMERIC(15, 2),
+ALTER COLUMN k_receipt TYPE NUMERIC(15, 2),
+ALTER COLUMN tax TYPE NUMERIC(15, 2);
+
+ALTER TABLE tax_brackets
+ALTER COLUMN threshold TYPE NUMERIC(15, 2);
+
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS tax_calculations_immutable ON tax_calculations;
+
+DROP FUNCTION IF EXISTS reject_tax_calculation_update ();
+
+ALTER TABLE tax_calculations
+DROP COLUMN request,
+DROP COLUMN tax_level,
+DROP COLUMN allowances,
+DROP COLUMN tax_refund,
+DROP COLUMN tax_payable,
+DROP COLUMN taxable_income,
+DROP COLUMN admin_config_id,
+DROP COLUMN tax_year;
+
+COMMIT;
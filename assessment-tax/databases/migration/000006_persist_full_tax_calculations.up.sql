@@ -0,0 +1,24 @@
+BEGIN;
+
+ALTER TABLE tax_calculations
+ADD COLUMN tax_year INTEGER,
+ADD COLUMN admin_config_id INTEGER REFERENCES admin_configs (id),
+ADD COLUMN taxable_income NUMERIC(15, 2) NOT NULL DEFAULT '0.00',
+ADD COLUMN tax_payable NUMERIC(15, 2) NOT NULL DEFAULT '0.00',
+ADD COLUMN tax_refund NUMERIC(15, 2) NOT NULL DEFAULT '0.00',
+ADD COLUMN allowances JSONB NOT NULL DEFAULT '[]',
+ADD COLUMN tax_level JSONB NOT NULL DEFAULT '[]',
+ADD COLUMN request JSONB NOT NULL DEFAULT '{}';
+
+-- a calculation record is evidence of what was computed; never rewrite it
+CREATE FUNCTION reject_tax_calculation_update () RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'tax_calculations rows are immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER tax_calculations_immutable BEFORE
+UPDATE ON tax_calculations FOR EACH ROW
+EXECUTE FUNCTION reject_tax_calculation_update ();
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_tax_calculations_upload_id;
+
+ALTER TABLE tax_calculations
+DROP COLUMN upload_id;
+
+DROP TABLE IF EXISTS tax_uploads;
+
+COMMIT;
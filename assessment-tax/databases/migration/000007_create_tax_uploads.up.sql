@@ -0,0 +1,18 @@
+BEGIN;
+
+CREATE TABLE
+    tax_uploads (
+        id SERIAL PRIMARY KEY,
+        filename TEXT NOT NULL DEFAULT '',
+        row_count INTEGER NOT NULL DEFAULT 0,
+        error_count INTEGER NOT NULL DEFAULT 0,
+        created_at TIMESTAMP NOT NULL DEFAULT NOW ()
+    );
+
+-- calculations stored from a CSV upload point back to it
+ALTER TABLE tax_calculations
+ADD COLUMN upload_id INTEGER REFERENCES tax_uploads (id);
+
+CREATE INDEX idx_tax_calculations_upload_id ON tax_calculations (upload_id);
+
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+
+ALTER TABLE tax_calculations
+DROP COLUMN upload_row;
+
+DROP INDEX IF EXISTS idx_tax_uploads_pending;
+
+ALTER TABLE tax_uploads
+DROP COLUMN finished_at,
+DROP COLUMN updated_at,
+DROP COLUMN content,
+DROP COLUMN error,
+DROP COLUMN errors,
+DROP COLUMN processed_count,
+DROP COLUMN status;
+
+COMMIT;
@@ -0,0 +1,32 @@
+BEGIN;
+
+-- an upload is processed in the background; its row holds the job state so
+-- unfinished jobs are picked up again after a restart
+ALTER TABLE tax_uploads
+ADD COLUMN status TEXT NOT NULL DEFAULT 'completed' CHECK (
+    status IN ('queued', 'running', 'completed', 'failed')
+),
+ADD COLUMN processed_count INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN errors JSONB NOT NULL DEFAULT '[]',
+ADD COLUMN error TEXT NOT NULL DEFAULT '',
+ADD COLUMN content BYTEA,
+ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT NOW (),
+ADD COLUMN finished_at TIMESTAMP;
+
+UPDATE tax_uploads
+SET
+    processed_count = row_count,
+    finished_at = created_at;
+
+ALTER TABLE tax_uploads
+ALTER COLUMN status SET DEFAULT 'queued';
+
+CREATE INDEX idx_tax_uploads_pending ON tax_uploads (id)
+WHERE
+    status IN ('queued', 'running');
+
+-- line of the uploaded file a calculation was computed from
+ALTER TABLE tax_calculations
+ADD COLUMN upload_row INTEGER;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE IF EXISTS revoked_tokens;
+
+DROP TABLE IF EXISTS admin_users;
+
+COMMIT;
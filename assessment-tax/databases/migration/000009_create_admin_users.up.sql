@@ -0,0 +1,21 @@
+BEGIN;
+
+CREATE TABLE
+    admin_users (
+        id SERIAL PRIMARY KEY,
+        username TEXT NOT NULL UNIQUE,
+        password_hash TEXT NOT NULL,
+        role TEXT NOT NULL CHECK (role IN ('viewer', 'tax-admin', 'super-admin')),
+        created_at TIMESTAMP NOT NULL DEFAULT NOW (),
+        updated_at TIMESTAMP NOT NULL DEFAULT NOW ()
+    );
+
+-- tokens logged out before they expire; rows past expires_at can be deleted
+CREATE TABLE
+    revoked_tokens (
+        jti TEXT PRIMARY KEY,
+        expires_at TIMESTAMP NOT NULL,
+        revoked_at TIMESTAMP NOT NULL DEFAULT NOW ()
+    );
+
+COMMIT;
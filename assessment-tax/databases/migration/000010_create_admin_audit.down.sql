@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS admin_audit_append_only ON admin_audit;
+
+DROP FUNCTION IF EXISTS reject_admin_audit_change ();
+
+DROP TABLE IF EXISTS admin_audit;
+
+COMMIT;
@@ -0,0 +1,32 @@
+BEGIN;
+
+CREATE TABLE
+    admin_audit (
+        id BIGSERIAL PRIMARY KEY,
+        actor TEXT NOT NULL,
+        action TEXT NOT NULL,
+        resource TEXT NOT NULL,
+        resource_id TEXT NOT NULL DEFAULT '',
+        old_value JSONB,
+        new_value JSONB,
+        client_ip TEXT NOT NULL DEFAULT '',
+        request_id TEXT NOT NULL DEFAULT '',
+        created_at TIMESTAMP NOT NULL DEFAULT NOW ()
+    );
+
+CREATE INDEX idx_admin_audit_actor ON admin_audit (actor, id);
+
+CREATE INDEX idx_admin_audit_created_at ON admin_audit (created_at);
+
+-- the audit trail is append-only
+CREATE FUNCTION reject_admin_audit_change () RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'admin_audit rows are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER admin_audit_append_only BEFORE
+UPDATE OR DELETE ON admin_audit FOR EACH ROW
+EXECUTE FUNCTION reject_admin_audit_change ();
+
+COMMIT;
@@ -0,0 +1,25 @@
+BEGIN;
+
+-- allowance types the calculator deducts; a claim of any other type is rejected
+CREATE TABLE
+    allowance_types (
+        key TEXT PRIMARY KEY CHECK (key ~ '^[a-z0-9]+(-[a-z0-9]+)*$'),
+        display_name TEXT NOT NULL,
+        cap_amount NUMERIC(15, 2) CHECK (cap_amount >= 0),
+        cap_rate DECIMAL(5, 4) CHECK (
+            cap_rate >= 0
+            AND cap_rate <= 1
+        ),
+        stage TEXT NOT NULL DEFAULT 'before' CHECK (stage IN ('before', 'after')),
+        created_at TIMESTAMP NOT NULL DEFAULT NOW (),
+        updated_at TIMESTAMP NOT NULL DEFAULT NOW ()
+    );
+
+-- the caps of these two stay on the rule set (donationCap, kReceipt)
+INSERT INTO
+    allowance_types (key, display_name, stage)
+VALUES
+    ('k-receipt', 'ช้อปลดภาษี (k-receipt)', 'before'),
+    ('donation', 'เงินบริจาค', 'after');
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+DELETE FROM allowance_types
+WHERE
+    key = 'education-donation';
+
+UPDATE allowance_types
+SET
+    cap_rate = NULL,
+    updated_at = NOW ()
+WHERE
+    key = 'donation';
+
+COMMIT;
@@ -0,0 +1,23 @@
+BEGIN;
+
+-- donations are capped at 10% of the income left after every other allowance
+UPDATE allowance_types
+SET
+    cap_rate = 0.10,
+    stage = 'after',
+    updated_at = NOW ()
+WHERE
+    key = 'donation';
+
+-- education, sports and hospital donations are deducted twice over, under the same 10% cap
+INSERT INTO
+    allowance_types (key, display_name, cap_rate, stage)
+VALUES
+    (
+        'education-donation',
+        'เงินบริจาคเพื่อการศึกษา การกีฬา และโรงพยาบาลรัฐ (หักได้ 2 เท่า)',
+        0.10,
+        'after'
+    ) ON CONFLICT (key) DO NOTHING;
+
+COMMIT;
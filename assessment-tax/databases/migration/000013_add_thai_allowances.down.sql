@@ -0,0 +1,24 @@
+BEGIN;
+
+DELETE FROM allowance_types
+WHERE
+    key IN (
+        'spouse',
+        'child',
+        'parent',
+        'life-insurance',
+        'health-insurance',
+        'parent-health-insurance',
+        'provident-fund',
+        'rmf',
+        'ssf'
+    );
+
+ALTER TABLE allowance_types
+DROP COLUMN IF EXISTS group_key,
+DROP COLUMN IF EXISTS max_units,
+DROP COLUMN IF EXISTS unit_amount;
+
+DROP TABLE IF EXISTS allowance_groups;
+
+COMMIT;
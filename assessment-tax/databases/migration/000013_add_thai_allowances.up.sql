@@ -0,0 +1,56 @@
+BEGIN;
+
+-- allowance types in a group share the group's cap
+CREATE TABLE
+    allowance_groups (
+        key TEXT PRIMARY KEY CHECK (key ~ '^[a-z0-9]+(-[a-z0-9]+)*$'),
+        display_name TEXT NOT NULL,
+        cap_amount NUMERIC(15, 2) NOT NULL CHECK (cap_amount >= 0),
+        created_at TIMESTAMP NOT NULL DEFAULT NOW (),
+        updated_at TIMESTAMP NOT NULL DEFAULT NOW ()
+    );
+
+-- unit_amount is deducted per person claimed, for at most max_units people
+ALTER TABLE allowance_types
+ADD COLUMN unit_amount NUMERIC(15, 2) CHECK (unit_amount >= 0),
+ADD COLUMN max_units INTEGER CHECK (max_units >= 1),
+ADD COLUMN group_key TEXT REFERENCES allowance_groups (key);
+
+INSERT INTO
+    allowance_groups (key, display_name, cap_amount)
+VALUES
+    (
+        'insurance',
+        'เบี้ยประกันชีวิตและประกันสุขภาพรวมกัน',
+        100000
+    ),
+    (
+        'retirement',
+        'กองทุนเพื่อการเกษียณรวมกัน',
+        500000
+    );
+
+INSERT INTO
+    allowance_types (
+        key,
+        display_name,
+        cap_amount,
+        cap_rate,
+        unit_amount,
+        max_units,
+        group_key,
+        stage
+    )
+VALUES
+    ('spouse', 'คู่สมรสที่ไม่มีเงินได้', NULL, NULL, 60000, 1, NULL, 'before'),
+    ('child', 'บุตร', NULL, NULL, 30000, NULL, NULL, 'before'),
+    ('parent', 'บิดามารดาอายุ 60 ปีขึ้นไป', NULL, NULL, 30000, 4, NULL, 'before'),
+    ('life-insurance', 'เบี้ยประกันชีวิต', 100000, NULL, NULL, NULL, 'insurance', 'before'),
+    ('health-insurance', 'เบี้ยประกันสุขภาพ', 25000, NULL, NULL, NULL, 'insurance', 'before'),
+    ('parent-health-insurance', 'เบี้ยประกันสุขภาพบิดามารดา', 15000, NULL, NULL, NULL, NULL, 'before'),
+    ('provident-fund', 'กองทุนสำรองเลี้ยงชีพ', 500000, 0.15, NULL, NULL, 'retirement', 'before'),
+    ('rmf', 'กองทุนรวมเพื่อการเลี้ยงชีพ (RMF)', 500000, 0.30, NULL, NULL, 'retirement', 'before'),
+    ('ssf', 'กองทุนรวมเพื่อการออม (SSF)', 200000, 0.30, NULL, NULL, 'retirement', 'before')
+ON CONFLICT (key) DO NOTHING;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE tax_calculations
+DROP COLUMN expenses;
+
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+-- standard expenses of the income categories of the request, section 40
+ALTER TABLE tax_calculations
+ADD COLUMN expenses NUMERIC(15, 2) NOT NULL DEFAULT '0.00';
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+-- the seeded rule set is kept: calculations may already refer to it, and it
+-- cannot be told apart from one stored through the admin API
+
+COMMIT;
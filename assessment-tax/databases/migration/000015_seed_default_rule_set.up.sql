@@ -0,0 +1,50 @@
+BEGIN;
+
+-- a database without any rule set gets the statutory one, in effect from the
+-- start of the current year; the calculator falls back to the same rules
+WITH
+    seeded AS (
+        INSERT INTO
+            admin_configs (
+                tax_year,
+                version,
+                personal_deduction,
+                k_receipt,
+                donation_cap,
+                effective_from
+            )
+        SELECT
+            EXTRACT(YEAR FROM CURRENT_DATE)::INTEGER,
+            1,
+            60000.00,
+            50000.00,
+            100000.00,
+            DATE_TRUNC('year', CURRENT_DATE)::DATE
+        WHERE
+            NOT EXISTS (
+                SELECT
+                    1
+                FROM
+                    admin_configs
+            )
+        RETURNING
+            id
+    )
+INSERT INTO
+    tax_brackets (admin_config_id, threshold, rate)
+SELECT
+    s.id,
+    b.threshold,
+    b.rate
+FROM
+    seeded s
+    CROSS JOIN (
+        VALUES
+            (0.00, 0.0000),
+            (150000.00, 0.1000),
+            (500000.00, 0.1500),
+            (1000000.00, 0.2000),
+            (2000000.00, 0.3500)
+    ) AS b (threshold, rate);
+
+COMMIT;
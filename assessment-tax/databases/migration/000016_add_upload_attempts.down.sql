@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE tax_uploads
+DROP COLUMN IF EXISTS attempt;
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+-- every claim of an upload is a new attempt; a worker only writes to the
+-- upload while it still carries the attempt it claimed, so one whose job was
+-- requeued as stale cannot race the worker that took it over
+ALTER TABLE tax_uploads
+ADD COLUMN attempt INTEGER NOT NULL DEFAULT 0;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE admin_configs
+DROP COLUMN IF EXISTS allowance_types;
+
+COMMIT;
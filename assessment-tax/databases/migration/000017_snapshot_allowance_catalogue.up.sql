@@ -0,0 +1,54 @@
+BEGIN;
+
+-- every rule set version keeps the allowance catalogue it is calculated with,
+-- so editing the catalogue does not change earlier versions and tax years;
+-- the versions stored so far get the catalogue as it is now
+ALTER TABLE admin_configs
+ADD COLUMN allowance_types JSONB;
+
+UPDATE admin_configs
+SET
+    allowance_types = (
+        SELECT
+            COALESCE(
+                jsonb_agg(
+                    jsonb_strip_nulls(
+                        jsonb_build_object(
+                            'key',
+                            t.key,
+                            'displayName',
+                            t.display_name,
+                            'capAmount',
+                            t.cap_amount,
+                            'capRate',
+                            t.cap_rate,
+                            'unitAmount',
+                            t.unit_amount,
+                            'maxUnits',
+                            t.max_units,
+                            'group',
+                            t.group_key,
+                            'groupCap',
+                            g.cap_amount,
+                            'stage',
+                            t.stage,
+                            'createdAt',
+                            TO_CHAR(t.created_at, 'YYYY-MM-DD"T"HH24:MI:SS"Z"'),
+                            'updatedAt',
+                            TO_CHAR(t.updated_at, 'YYYY-MM-DD"T"HH24:MI:SS"Z"')
+                        )
+                    )
+                    ORDER BY
+                        t.key
+                ),
+                '[]'
+            )
+        FROM
+            allowance_types t
+            LEFT JOIN allowance_groups g ON g.key = t.group_key
+    );
+
+ALTER TABLE admin_configs
+ALTER COLUMN allowance_types SET NOT NULL;
+
+COMMIT;
@@ -0,0 +1,25 @@
+BEGIN;
+
+-- the amount deducted for each allowance type claimed, keyed by type; the
+-- calculations stored so far only kept the donation and k-receipt totals
+ALTER TABLE tax_calculations
+ADD COLUMN deductions JSONB NOT NULL DEFAULT '{}';
+
+-- the backfill only fills the new column, so the rows stay as they were
+-- computed; the trigger is back on before the transaction commits
+ALTER TABLE tax_calculations DISABLE TRIGGER tax_calculations_immutable;
+
+UPDATE tax_calculations
+SET
+    deductions = jsonb_strip_nulls(
+        jsonb_build_object(
+            'donation',
+            NULLIF(donation, 0),
+            'k-receipt',
+            NULLIF(k_receipt, 0)
+        )
+    );
+
+ALTER TABLE tax_calculations ENABLE TRIGGER tax_calculations_immutable;
+
+COMMIT;
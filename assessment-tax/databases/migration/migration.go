@@ -2,25 +2,116 @@
 package migration
 
 import (
-	"log"
+	"embed"
+	"errors"
+	"io/fs"
+	"time"
 
-	"github.com/LGROW101/assessment-tax/config"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 )
 
-func Migrate(cfg *config.Config) {
-	connectionString := cfg.DatabaseURL
+//go:embed *.sql
+var files embed.FS
 
-	m, err := migrate.New("file://databases/migrations", connectionString)
+// lockTimeout is how long a migration waits for the advisory lock held by
+// another instance migrating the same database.
+const lockTimeout = 5 * time.Minute
+
+// Status is the state of a database: the version of the last migration
+// applied, 0 when none was, whether it failed half way, and the versions of
+// the embedded migrations not applied yet.
+type Status struct {
+	Version uint   `json:"version"`
+	Dirty   bool   `json:"dirty"`
+	Pending []uint `json:"pending"`
+}
+
+// open returns a migrator of the embedded migrations for the database. The
+// postgres driver holds a session advisory lock while migrating, so instances
+// started together apply each migration once: the others wait for the lock
+// and then find nothing left to do.
+func open(databaseURL string) (*migrate.Migrate, error) {
+	source, err := iofs.New(files, ".")
+	if err != nil {
+		return nil, err
+	}
+	m, err := migrate.NewWithSourceInstance("iofs", source, databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	m.LockTimeout = lockTimeout
+	return m, nil
+}
+
+// Up applies every pending migration.
+func Up(databaseURL string) error {
+	m, err := open(databaseURL)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// Down reverts the last steps migrations applied.
+func Down(databaseURL string, steps int) error {
+	m, err := open(databaseURL)
 	if err != nil {
-		log.Fatal("Failed to initialize migration:", err)
+		return err
 	}
+	defer m.Close()
 
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		log.Fatal("Failed to run migration:", err)
+	return m.Steps(-steps)
+}
+
+// GetStatus returns the Status of the database.
+func GetStatus(databaseURL string) (*Status, error) {
+	m, err := open(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	defer m.Close()
+
+	status := &Status{Pending: []uint{}}
+	status.Version, status.Dirty, err = m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return nil, err
 	}
 
-	log.Println("Migration completed")
+	versions, err := Versions()
+	if err != nil {
+		return nil, err
+	}
+	for _, version := range versions {
+		if version > status.Version {
+			status.Pending = append(status.Pending, version)
+		}
+	}
+	return status, nil
+}
+
+// Versions returns the versions of the embedded migrations in order.
+func Versions() ([]uint, error) {
+	source, err := iofs.New(files, ".")
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+
+	version, err := source.First()
+	var versions []uint
+	for err == nil {
+		versions = append(versions, version)
+		version, err = source.Next(version)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return versions, nil
 }
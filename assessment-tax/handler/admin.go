@@ -2,10 +2,16 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/LGROW101/assessment-tax/model"
 	"github.com/LGROW101/assessment-tax/repository"
+	"github.com/LGROW101/assessment-tax/service"
 	"github.com/labstack/echo/v4"
 )
 
@@ -19,40 +25,93 @@ func NewAdminHandler(adminRepo repository.AdminRepository) *AdminHandler {
 	}
 }
 
+// GetConfig returns the rule set of the tax year, by default the one in
+// effect today. While none is, the statutory default the calculator falls
+// back to is returned, marked "default" and without an ETag.
 func (h *AdminHandler) GetConfig(c echo.Context) error {
-	config, err := h.adminRepo.GetConfig()
+	taxYear, err := taxYearParam(c)
+	if err != nil {
+		return err
+	}
+
+	config, err := h.adminRepo.GetConfig(taxYear)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	if config == nil && taxYear == 0 {
+		resp := newAdminResponse(model.DefaultRuleSet(time.Now().Year()))
+		resp.Default = true
+		return c.JSON(http.StatusOK, resp)
+	}
 	if config == nil {
 		return echo.NewHTTPError(http.StatusNotFound, "Admin config not found")
 	}
 
-	resp := &model.AdminResponse{
-		PersonalDeduction: config.PersonalDeduction,
-		KReceipt:          config.KReceipt,
-	}
-	return c.JSON(http.StatusOK, resp)
+	c.Response().Header().Set("ETag", configETag(config))
+	return c.JSON(http.StatusOK, newAdminResponse(config))
 }
 
+// UpdateConfig creates a new rule set version of the tax year with the
+// requested deductions changed. The first version of a tax year starts from
+// the rules currently in effect, or the statutory default when there are
+// none.
+//
+// A request with an If-Match header is only applied to the version with that
+// ETag, and either way two updates made from the same version cannot both be
+// stored: the later one gets 409 Conflict.
 func (h *AdminHandler) UpdateConfig(c echo.Context) error {
 	var req model.AdminRequest
 	if err := c.Bind(&req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	config, err := h.adminRepo.GetConfig()
+	taxYear := 0
+	if req.TaxYear != nil {
+		taxYear = *req.TaxYear
+	}
+
+	config, err := h.adminRepo.GetConfig(taxYear)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
+	if taxYear == 0 && config != nil {
+		// the version in effect may already have a successor that takes
+		// effect later, and the update follows the latest version
+		taxYear = config.TaxYear
+		config, err = h.adminRepo.GetConfig(taxYear)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+	}
+	if !ifMatch(c, config) {
+		return conflictError(c, config)
+	}
 
-	if config == nil {
-		config = &model.AdminConfig{}
-		err = h.adminRepo.InsertConfig(config)
+	audit := newAuditEntry(c, model.AuditDeductionsUpdate, "admin_config")
+	if err := audit.SetOldValue(config); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if config == nil && taxYear != 0 {
+		config, err = h.adminRepo.GetConfig(0)
 		if err != nil {
 			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 		}
+		if config != nil {
+			config.TaxYear = taxYear
+			config.EffectiveFrom = time.Date(taxYear, time.January, 1, 0, 0, 0, 0, time.UTC)
+			config.EffectiveTo = nil
+			config.Version = 0
+			config.AllowanceTypes = nil
+		}
+	}
+
+	if config == nil {
+		if taxYear == 0 {
+			taxYear = time.Now().Year()
+		}
+		config = model.DefaultRuleSet(taxYear)
 	}
 
 	if req.PersonalDeduction != nil {
@@ -61,23 +120,171 @@ func (h *AdminHandler) UpdateConfig(c echo.Context) error {
 	if req.KReceipt != nil {
 		config.KReceipt = *req.KReceipt
 	}
+	if req.DonationCap != nil {
+		config.DonationCap = *req.DonationCap
+	}
 
 	if err := config.Validate(); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
-	err = h.adminRepo.UpdateConfig(config)
+	err = h.adminRepo.InsertConfig(config, audit)
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return conflictError(c, nil)
+	}
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
+	c.Response().Header().Set("ETag", configETag(config))
 
-	resp := &model.AdminResponse{}
+	resp := &model.AdminResponse{
+		TaxYear: config.TaxYear,
+		Version: config.Version,
+	}
 	if req.PersonalDeduction != nil {
 		resp.PersonalDeduction = *req.PersonalDeduction
 	}
 	if req.KReceipt != nil {
 		resp.KReceipt = *req.KReceipt
 	}
+	if req.DonationCap != nil {
+		resp.DonationCap = *req.DonationCap
+	}
 
 	return c.JSON(http.StatusOK, resp)
 }
+
+func (h *AdminHandler) ListRuleSets(c echo.Context) error {
+	taxYear, err := taxYearParam(c)
+	if err != nil {
+		return err
+	}
+
+	configs, err := h.adminRepo.ListConfigs(taxYear)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	resp := make([]*model.AdminResponse, len(configs))
+	for i, config := range configs {
+		resp[i] = newAdminResponse(config)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// CreateRuleSet stores a complete rule set, brackets included, as the next
+// version of its tax year.
+func (h *AdminHandler) CreateRuleSet(c echo.Context) error {
+	var req model.RuleSetRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	config := &model.AdminConfig{
+		TaxYear:           req.TaxYear,
+		PersonalDeduction: req.PersonalDeduction,
+		KReceipt:          req.KReceipt,
+		DonationCap:       req.DonationCap,
+		EffectiveFrom:     req.EffectiveFrom,
+		EffectiveTo:       req.EffectiveTo,
+		Brackets:          req.Brackets,
+	}
+	if config.EffectiveFrom.IsZero() {
+		config.EffectiveFrom = time.Date(req.TaxYear, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	if err := config.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if _, err := service.NewTaxBracketSchedule(config.Brackets); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	previous, err := h.adminRepo.GetConfig(config.TaxYear)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	audit := newAuditEntry(c, model.AuditRuleSetCreate, "admin_config")
+	if err := audit.SetOldValue(previous); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if previous != nil {
+		config.Version = previous.Version
+		config.AllowanceTypes = previous.AllowanceTypes
+	}
+
+	err = h.adminRepo.InsertConfig(config, audit)
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return conflictError(c, nil)
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	c.Response().Header().Set("ETag", configETag(config))
+	return c.JSON(http.StatusCreated, newAdminResponse(config))
+}
+
+// configETag identifies a rule set version. Versions are never changed, so
+// the tax year and version number are enough.
+func configETag(config *model.AdminConfig) string {
+	return fmt.Sprintf(`"%d-%d"`, config.TaxYear, config.Version)
+}
+
+// ifMatch reports whether the If-Match header of the request, if any, lists
+// the ETag of config. "*" matches any existing config.
+func ifMatch(c echo.Context, config *model.AdminConfig) bool {
+	header := c.Request().Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+	if config == nil {
+		return false
+	}
+	etag := configETag(config)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// conflictError answers a write made from an outdated version, pointing the
+// client at the current one when it is known.
+func conflictError(c echo.Context, current *model.AdminConfig) error {
+	if current != nil {
+		c.Response().Header().Set("ETag", configETag(current))
+	}
+	return echo.NewHTTPError(http.StatusConflict, repository.ErrVersionConflict.Error())
+}
+
+func newAdminResponse(config *model.AdminConfig) *model.AdminResponse {
+	resp := &model.AdminResponse{
+		TaxYear:           config.TaxYear,
+		Version:           config.Version,
+		PersonalDeduction: config.PersonalDeduction,
+		KReceipt:          config.KReceipt,
+		DonationCap:       config.DonationCap,
+		EffectiveTo:       config.EffectiveTo,
+		Brackets:          config.Brackets,
+		AllowanceTypes:    config.AllowanceTypes,
+	}
+	if !config.EffectiveFrom.IsZero() {
+		resp.EffectiveFrom = &config.EffectiveFrom
+	}
+	return resp
+}
+
+func taxYearParam(c echo.Context) (int, error) {
+	value := c.QueryParam("taxYear")
+	if value == "" {
+		return 0, nil
+	}
+	taxYear, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "taxYear must be a number")
+	}
+	return taxYear, nil
+}
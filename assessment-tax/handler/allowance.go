@@ -0,0 +1,179 @@
+// allowance
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/repository"
+	"github.com/labstack/echo/v4"
+)
+
+type AllowanceHandler struct {
+	allowanceRepo repository.AllowanceRepository
+}
+
+func NewAllowanceHandler(allowanceRepo repository.AllowanceRepository) *AllowanceHandler {
+	return &AllowanceHandler{
+		allowanceRepo: allowanceRepo,
+	}
+}
+
+type AllowanceTypeRequest struct {
+	DisplayName string       `json:"displayName"`
+	CapAmount   *model.Money `json:"capAmount"`
+	CapRate     *model.Rate  `json:"capRate"`
+	UnitAmount  *model.Money `json:"unitAmount"`
+	MaxUnits    *int         `json:"maxUnits"`
+	Group       string       `json:"group"`
+	Stage       string       `json:"stage"`
+}
+
+type AllowanceGroupRequest struct {
+	DisplayName string      `json:"displayName"`
+	CapAmount   model.Money `json:"capAmount"`
+}
+
+func (h *AllowanceHandler) ListAllowanceTypes(c echo.Context) error {
+	allowanceTypes, err := h.allowanceRepo.ListAllowanceTypes()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if allowanceTypes == nil {
+		allowanceTypes = []*model.AllowanceType{}
+	}
+	return c.JSON(http.StatusOK, allowanceTypes)
+}
+
+// SaveAllowanceType creates the allowance type of the key in the path or
+// replaces it.
+func (h *AllowanceHandler) SaveAllowanceType(c echo.Context) error {
+	var req AllowanceTypeRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	allowanceType := &model.AllowanceType{
+		Key:         c.Param("key"),
+		DisplayName: req.DisplayName,
+		CapAmount:   req.CapAmount,
+		CapRate:     req.CapRate,
+		UnitAmount:  req.UnitAmount,
+		MaxUnits:    req.MaxUnits,
+		Group:       req.Group,
+		Stage:       req.Stage,
+	}
+	if err := allowanceType.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	previous, err := h.allowanceRepo.GetAllowanceType(allowanceType.Key)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	audit := newAuditEntry(c, model.AuditAllowanceUpdate, "allowance_type")
+	if err := audit.SetOldValue(previous); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := h.allowanceRepo.SaveAllowanceType(allowanceType, audit); err != nil {
+		if errors.Is(err, repository.ErrUnknownAllowanceGroup) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return catalogueError(err)
+	}
+
+	status := http.StatusOK
+	if previous == nil {
+		status = http.StatusCreated
+	}
+	return c.JSON(status, allowanceType)
+}
+
+// DeleteAllowanceType removes an allowance type from the catalogue. The
+// built-in types cannot be removed.
+func (h *AllowanceHandler) DeleteAllowanceType(c echo.Context) error {
+	key := c.Param("key")
+	if model.IsBuiltinAllowance(key) {
+		return echo.NewHTTPError(http.StatusBadRequest, "built-in allowance type cannot be deleted")
+	}
+
+	previous, err := h.allowanceRepo.GetAllowanceType(key)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if previous == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Allowance type not found")
+	}
+
+	audit := newAuditEntry(c, model.AuditAllowanceDelete, "allowance_type")
+	if err := audit.SetOldValue(previous); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := h.allowanceRepo.DeleteAllowanceType(key, audit); err != nil {
+		return catalogueError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *AllowanceHandler) ListAllowanceGroups(c echo.Context) error {
+	groups, err := h.allowanceRepo.ListAllowanceGroups()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if groups == nil {
+		groups = []*model.AllowanceGroup{}
+	}
+	return c.JSON(http.StatusOK, groups)
+}
+
+// SaveAllowanceGroup creates the allowance group of the key in the path or
+// replaces it, changing the cap its types share.
+func (h *AllowanceHandler) SaveAllowanceGroup(c echo.Context) error {
+	var req AllowanceGroupRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	group := &model.AllowanceGroup{
+		Key:         c.Param("key"),
+		DisplayName: req.DisplayName,
+		CapAmount:   req.CapAmount,
+	}
+	if err := group.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	previous, err := h.allowanceRepo.GetAllowanceGroup(group.Key)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	audit := newAuditEntry(c, model.AuditAllowanceGroupUpdate, "allowance_group")
+	if err := audit.SetOldValue(previous); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if err := h.allowanceRepo.SaveAllowanceGroup(group, audit); err != nil {
+		return catalogueError(err)
+	}
+
+	status := http.StatusOK
+	if previous == nil {
+		status = http.StatusCreated
+	}
+	return c.JSON(status, group)
+}
+
+// catalogueError answers 409 Conflict when a rule set version was stored while
+// the catalogue change was reissuing the open rule sets.
+func catalogueError(err error) error {
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+}
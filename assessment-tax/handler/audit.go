@@ -0,0 +1,128 @@
+// audit
+package handler
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/repository"
+	"github.com/LGROW101/assessment-tax/service"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+type AuditHandler struct {
+	auditRepo repository.AuditRepository
+}
+
+func NewAuditHandler(auditRepo repository.AuditRepository) *AuditHandler {
+	return &AuditHandler{
+		auditRepo: auditRepo,
+	}
+}
+
+// newAuditEntry starts the audit entry of a change made by the request: the
+// user of its token, its client IP as found by the IPExtractor of the server
+// and the request ID set by the RequestID middleware.
+func newAuditEntry(c echo.Context, action, resource string) *model.AuditEntry {
+	entry := &model.AuditEntry{
+		Action:    action,
+		Resource:  resource,
+		ClientIP:  c.RealIP(),
+		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+	}
+	if claims, ok := c.Get(AuthClaimsKey).(*service.AuthClaims); ok {
+		entry.Actor = claims.Subject
+	}
+	return entry
+}
+
+// RequestID gives every request an ID generated by the server, in the
+// X-Request-ID header of its response. An X-Request-ID sent by the client is
+// dropped, so it cannot choose the request ID of its audit entries.
+func RequestID() echo.MiddlewareFunc {
+	requestID := middleware.RequestID()
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		next = requestID(next)
+		return func(c echo.Context) error {
+			c.Request().Header.Del(echo.HeaderXRequestID)
+			return next(c)
+		}
+	}
+}
+
+// IPExtractor returns how the client IP of a request is found. Without
+// trusted proxies it is the address of the connection; otherwise it is taken
+// from X-Forwarded-For as far back as the hops stay within the trusted proxy
+// ranges, given in CIDR notation.
+func IPExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, cidr := range trustedProxies {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", cidr, err)
+		}
+		options = append(options, echo.TrustIPRange(ipNet))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
+// ListEntries returns one page of the audit trail, newest first, filtered by
+// actor, action, resource, requestId and a from/to date range.
+func (h *AuditHandler) ListEntries(c echo.Context) error {
+	filter := model.AuditFilter{
+		Actor:     c.QueryParam("actor"),
+		Action:    c.QueryParam("action"),
+		Resource:  c.QueryParam("resource"),
+		RequestID: c.QueryParam("requestId"),
+	}
+
+	var err error
+	if filter.From, err = dateParam(c, "from", false); err != nil {
+		return err
+	}
+	if filter.To, err = dateParam(c, "to", true); err != nil {
+		return err
+	}
+	if value := c.QueryParam("cursor"); value != "" {
+		cursor, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid cursor")
+		}
+		filter.Cursor = uint(cursor)
+	}
+	if value := c.QueryParam("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit <= 0 {
+			return echo.NewHTTPError(http.StatusBadRequest, "limit must be a positive number")
+		}
+	}
+	if err := filter.Normalize(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	entries, nextCursor, err := h.auditRepo.ListEntries(filter)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if nextCursor != "" {
+		next := *c.Request().URL
+		query := next.Query()
+		query.Set("cursor", nextCursor)
+		next.RawQuery = query.Encode()
+		c.Response().Header().Set("X-Next-Cursor", nextCursor)
+		c.Response().Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+	}
+
+	if entries == nil {
+		entries = []*model.AuditEntry{}
+	}
+	return c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,120 @@
+// auth
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/repository"
+	"github.com/LGROW101/assessment-tax/service"
+	"github.com/labstack/echo/v4"
+)
+
+// AuthClaimsKey is the context key RequireRole stores the *service.AuthClaims
+// of the request under.
+const AuthClaimsKey = "authClaims"
+
+type AuthHandler struct {
+	authService service.AuthService
+}
+
+func NewAuthHandler(authService service.AuthService) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+	}
+}
+
+// RequireRole returns middleware that lets a request through only with a
+// valid bearer token of the role or a higher one.
+func (h *AuthHandler) RequireRole(role string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			scheme, token, ok := strings.Cut(c.Request().Header.Get(echo.HeaderAuthorization), " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+				return echo.NewHTTPError(http.StatusUnauthorized, "Missing bearer token")
+			}
+
+			claims, err := h.authService.Authenticate(token)
+			if errors.Is(err, service.ErrInvalidToken) {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+				return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+			}
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+			}
+
+			if !model.RoleAllows(claims.Role, role) {
+				return echo.NewHTTPError(http.StatusForbidden, "Requires the "+role+" role")
+			}
+
+			c.Set(AuthClaimsKey, claims)
+			return next(c)
+		}
+	}
+}
+
+func (h *AuthHandler) Login(c echo.Context) error {
+	var req model.LoginRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := h.authService.Login(req)
+	if errors.Is(err, service.ErrInvalidCredentials) {
+		return echo.NewHTTPError(http.StatusUnauthorized, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// Logout revokes the token the request was made with.
+func (h *AuthHandler) Logout(c echo.Context) error {
+	claims, ok := c.Get(AuthClaimsKey).(*service.AuthClaims)
+	if !ok {
+		return echo.NewHTTPError(http.StatusUnauthorized, "Missing bearer token")
+	}
+
+	if err := h.authService.Logout(claims); err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *AuthHandler) ListUsers(c echo.Context) error {
+	users, err := h.authService.ListUsers()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if users == nil {
+		users = []*model.AdminUser{}
+	}
+	return c.JSON(http.StatusOK, users)
+}
+
+func (h *AuthHandler) CreateUser(c echo.Context) error {
+	var req model.AdminUserRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	user, err := h.authService.CreateUser(req, newAuditEntry(c, model.AuditUserCreate, "admin_user"))
+	if errors.Is(err, repository.ErrUsernameTaken) {
+		return echo.NewHTTPError(http.StatusConflict, err.Error())
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, user)
+}
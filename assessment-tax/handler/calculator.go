@@ -2,7 +2,12 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/LGROW101/assessment-tax/model"
 	"github.com/LGROW101/assessment-tax/service"
@@ -20,34 +25,73 @@ func NewCalculatorHandler(taxCalculatorService service.TaxCalculatorService) *Ca
 }
 
 type TaxResponse struct {
-	TaxRefund *float64        `json:"taxRefund,omitempty"`
-	Tax       *float64        `json:"tax,omitempty"`
-	TaxLevel  []model.TaxRate `json:"taxLevel,omitempty"`
+	TaxRefund   *model.Money            `json:"taxRefund,omitempty"`
+	Tax         *model.Money            `json:"tax,omitempty"`
+	WHT         *model.Money            `json:"wht,omitempty"`
+	TaxLevel    []model.TaxRate         `json:"taxLevel,omitempty"`
+	Explanation []model.CalculationStep `json:"explanation,omitempty"`
 }
 
 type CalculateTaxRequest struct {
-	TotalIncome     float64           `json:"totalIncome"`
-	WHT             float64           `json:"wht"`
+	TaxYear         int               `json:"taxYear"`
+	TotalIncome     model.Money       `json:"totalIncome"`
+	WHT             model.Money       `json:"wht"`
+	Incomes         []model.Income    `json:"incomes"`
 	Allowances      []model.Allowance `json:"allowances"`
 	IncludeTaxLevel bool              `json:"includeTaxLevel"`
 }
 
+// CalculateTax computes and stores the tax of the request. With explain=true
+// the response also lists the steps of the calculation: the income, each
+// expense and allowance deducted with its cap, the taxable income, the tax of
+// each bracket and the WHT credit.
 func (h *CalculatorHandler) CalculateTax(c echo.Context) error {
 	var req CalculateTaxRequest
 	if err := c.Bind(&req); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
+	explain := false
+	if value := c.QueryParam("explain"); value != "" {
+		var err error
+		if explain, err = strconv.ParseBool(value); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "explain must be true or false")
+		}
+	}
+
 	if req.TotalIncome < 0 || req.WHT < 0 || len(req.Allowances) == 0 {
 		return echo.NewHTTPError(http.StatusBadRequest, "Invalid request body")
 	}
+	if len(req.Incomes) > 0 {
+		for _, income := range req.Incomes {
+			if err := income.Validate(); err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+		}
+		if req.TotalIncome != 0 && req.TotalIncome != model.TotalIncome(req.Incomes) {
+			return echo.NewHTTPError(http.StatusBadRequest, "totalIncome must be the sum of incomes")
+		}
+		req.TotalIncome = model.TotalIncome(req.Incomes)
+	}
+	for _, allowance := range req.Allowances {
+		if err := allowance.Validate(); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+	}
 
-	taxCalculationResponse, err := h.taxCalculatorService.CalculateTax(req.TotalIncome, req.WHT, req.Allowances)
+	taxCalculationResponse, err := h.taxCalculatorService.CalculateTax(model.TaxCalculationRequest{
+		TaxYear:     req.TaxYear,
+		TotalIncome: req.TotalIncome,
+		WHT:         req.WHT,
+		Incomes:     req.Incomes,
+		Allowances:  req.Allowances,
+		Explain:     explain,
+	})
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return calculationError(err)
 	}
 
-	response := TaxResponse{}
+	response := TaxResponse{Explanation: taxCalculationResponse.Explanation}
 
 	if taxCalculationResponse.TaxRefund != nil && *taxCalculationResponse.TaxRefund > 0 {
 		response.TaxRefund = taxCalculationResponse.TaxRefund
@@ -57,15 +101,195 @@ func (h *CalculatorHandler) CalculateTax(c echo.Context) error {
 
 	if req.IncludeTaxLevel {
 		response.TaxLevel = taxCalculationResponse.TaxLevel
+		if taxCalculationResponse.WHT > 0 {
+			response.WHT = &taxCalculationResponse.WHT
+		}
 	}
 
 	return c.JSON(http.StatusOK, response)
 }
+
+// GrossUp returns the income needed to take home a net income, or to owe a
+// tax, under the same rules as CalculateTax.
+func (h *CalculatorHandler) GrossUp(c echo.Context) error {
+	var req model.GrossUpRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	result, err := h.taxCalculatorService.GrossUp(req)
+	if err != nil {
+		return calculationError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// CalculateHousehold compares two spouses filing separately with filing
+// jointly.
+func (h *CalculatorHandler) CalculateHousehold(c echo.Context) error {
+	var req model.HouseholdRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	response, err := h.taxCalculatorService.CalculateHousehold(req)
+	if err != nil {
+		return calculationError(err)
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// calculationError maps an error of a calculation to a 400 when the request
+// caused it and to a 500 otherwise.
+func calculationError(err error) error {
+	if errors.Is(err, service.ErrRuleSetNotFound) || errors.Is(err, service.ErrUnknownAllowanceType) ||
+		errors.Is(err, service.ErrInvalidAllowance) || errors.Is(err, service.ErrInvalidIncome) ||
+		errors.Is(err, service.ErrTargetUnreachable) {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if errors.Is(err, service.ErrRuleSetUnavailable) {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, err.Error())
+	}
+	return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+}
+
+// GetAllCalculations returns one page of calculations. When there are more,
+// the cursor of the next page is sent in the X-Next-Cursor and Link headers.
+// A request that accepts CSV or XLSX gets the page as a spreadsheet.
 func (h *CalculatorHandler) GetAllCalculations(c echo.Context) error {
-	taxCalculations, err := h.taxCalculatorService.GetAllCalculations()
+	filter, err := calculationFilterParams(c)
 	if err != nil {
+		return err
+	}
+
+	taxCalculations, nextCursor, err := h.taxCalculatorService.GetAllCalculations(filter)
+	if err != nil {
+		if errors.Is(err, model.ErrInvalidCursor) {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
+	if nextCursor != "" {
+		next := *c.Request().URL
+		query := next.Query()
+		query.Set("cursor", nextCursor)
+		next.RawQuery = query.Encode()
+		c.Response().Header().Set("X-Next-Cursor", nextCursor)
+		c.Response().Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI()))
+	}
+
+	if format := exportFormat(c, ""); format != "" {
+		return writeExport(c, format, "tax-calculations", func(w io.Writer) error {
+			return service.WriteCalculations(w, format, taxCalculations)
+		})
+	}
+
+	if taxCalculations == nil {
+		taxCalculations = []*model.TaxCalculation{}
+	}
 	return c.JSON(http.StatusOK, taxCalculations)
 }
+
+func calculationFilterParams(c echo.Context) (model.TaxCalculationFilter, error) {
+	filter := model.TaxCalculationFilter{
+		Type:   c.QueryParam("type"),
+		Sort:   c.QueryParam("sort"),
+		Order:  c.QueryParam("order"),
+		Cursor: c.QueryParam("cursor"),
+	}
+
+	var err error
+	if filter.TaxYear, err = taxYearParam(c); err != nil {
+		return filter, err
+	}
+	if value := c.QueryParam("uploadId"); value != "" {
+		uploadID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return filter, echo.NewHTTPError(http.StatusBadRequest, "uploadId must be a positive number")
+		}
+		filter.UploadID = uint(uploadID)
+	}
+	if value := c.QueryParam("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit <= 0 {
+			return filter, echo.NewHTTPError(http.StatusBadRequest, "limit must be a positive number")
+		}
+	}
+	if filter.CreatedFrom, err = dateParam(c, "from", false); err != nil {
+		return filter, err
+	}
+	if filter.CreatedTo, err = dateParam(c, "to", true); err != nil {
+		return filter, err
+	}
+	if filter.MinIncome, err = moneyParam(c, "minIncome"); err != nil {
+		return filter, err
+	}
+	if filter.MaxIncome, err = moneyParam(c, "maxIncome"); err != nil {
+		return filter, err
+	}
+
+	if err := filter.Normalize(); err != nil {
+		return filter, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return filter, nil
+}
+
+// dateParam reads a date (2006-01-02) or timestamp (RFC 3339). A date given as
+// the end of a range includes that whole day. A timestamp is converted to UTC,
+// the wall clock created_at is stored in.
+func dateParam(c echo.Context, name string, end bool) (*time.Time, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		if end {
+			date = date.AddDate(0, 0, 1)
+		}
+		return &date, nil
+	}
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, name+" must be a date (YYYY-MM-DD) or an RFC 3339 timestamp")
+	}
+	timestamp = timestamp.UTC()
+	return &timestamp, nil
+}
+
+func moneyParam(c echo.Context, name string) (*model.Money, error) {
+	value := c.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	money, err := model.ParseMoney(value)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, name+" must be an amount")
+	}
+	return &money, nil
+}
+
+func (h *CalculatorHandler) GetCalculation(c echo.Context) error {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "id must be a positive number")
+	}
+
+	taxCalculation, err := h.taxCalculatorService.GetCalculation(uint(id))
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if taxCalculation == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Tax calculation not found")
+	}
+
+	return c.JSON(http.StatusOK, taxCalculation)
+}
@@ -1,24 +1,61 @@
 package handler
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
 
+	"github.com/LGROW101/assessment-tax/model"
 	"github.com/LGROW101/assessment-tax/service"
 	"github.com/labstack/echo/v4"
 )
 
 type CSVHandler struct {
 	taxCSVService service.TaxCSVService
+	taxJobService service.TaxJobService
+	// maxUploadSize is the largest request body an upload may have, in bytes.
+	maxUploadSize int64
 }
 
-func NewCSVHandler(taxCSVService service.TaxCSVService) *CSVHandler {
+func NewCSVHandler(taxCSVService service.TaxCSVService, taxJobService service.TaxJobService, maxUploadSize int64) *CSVHandler {
 	return &CSVHandler{
 		taxCSVService: taxCSVService,
+		taxJobService: taxJobService,
+		maxUploadSize: maxUploadSize,
 	}
 }
 
+// UploadCSV queues the uploaded file as a job and answers 202 with the job,
+// whose progress is at GET /tax/jobs/:id.
+//
+// With dryRun=true the file is instead validated and calculated right away
+// and nothing is stored. Rejected rows are listed in "errors"; the request
+// fails with 422 only when no row could be used. A request that accepts CSV
+// or XLSX gets the result as a spreadsheet instead of JSON.
+//
+// A request larger than the upload limit fails with 413.
 func (h *CSVHandler) UploadCSV(c echo.Context) error {
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, h.maxUploadSize)
+
+	dryRun := false
+	if value := c.FormValue("dryRun"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, "dryRun must be true or false")
+		}
+	}
+
 	file, err := c.FormFile("taxFile")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("upload is larger than %d bytes", tooLarge.Limit))
+	}
 	if err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
@@ -29,10 +66,47 @@ func (h *CSVHandler) UploadCSV(c echo.Context) error {
 	}
 	defer src.Close()
 
-	taxes, err := h.taxCSVService.ImportCSV(src)
+	if !dryRun {
+		content, err := io.ReadAll(src)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+		if err := h.taxCSVService.CheckHeader(bytes.NewReader(content)); err != nil {
+			return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
+				"message": "No valid rows in file",
+				"errors":  []model.CSVRowError{{Row: 1, Reason: err.Error()}},
+			})
+		}
+
+		upload, err := h.taxJobService.Submit(file.Filename, content)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		}
+
+		c.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf("/tax/jobs/%d", upload.ID))
+		return c.JSON(http.StatusAccepted, newJobResponse(upload))
+	}
+
+	result, err := h.taxCSVService.ImportCSV(src)
 	if err != nil {
 		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{"taxes": taxes})
+	if len(result.Taxes) == 0 {
+		if result.Errors == nil {
+			result.Errors = []model.CSVRowError{}
+		}
+		return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
+			"message": "No valid rows in file",
+			"errors":  result.Errors,
+		})
+	}
+
+	if format := exportFormat(c, ""); format != "" {
+		name := strings.TrimSuffix(file.Filename, filepath.Ext(file.Filename)) + "-result"
+		return writeExport(c, format, name, func(w io.Writer) error {
+			return service.WriteUploadResult(w, format, result.Calculations, result.Errors)
+		})
+	}
+	return c.JSON(http.StatusOK, result)
 }
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/LGROW101/assessment-tax/service"
+	"github.com/labstack/echo/v4"
+)
+
+var exportExtensions = map[string]string{
+	service.ExportCSV:  "csv",
+	service.ExportXLSX: "xlsx",
+}
+
+// exportFormat returns the spreadsheet format the Accept header of the
+// request prefers, or "" when it prefers JSON. fallback is the answer for a
+// request that accepts anything.
+func exportFormat(c echo.Context, fallback string) string {
+	accept := c.Request().Header.Get(echo.HeaderAccept)
+	if accept == "" {
+		return fallback
+	}
+
+	best, bestQuality := "", 0.0
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, quality := parseMediaRange(mediaRange)
+
+		var format string
+		switch mediaType {
+		case service.ExportCSV, service.ExportXLSX:
+			format = mediaType
+		case echo.MIMEApplicationJSON:
+			format = ""
+		case "*/*":
+			format = fallback
+		default:
+			continue
+		}
+		if quality > bestQuality {
+			best, bestQuality = format, quality
+		}
+	}
+	if bestQuality == 0 {
+		return fallback
+	}
+	return best
+}
+
+func parseMediaRange(mediaRange string) (string, float64) {
+	params := strings.Split(mediaRange, ";")
+	mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+	quality := 1.0
+	for _, param := range params[1:] {
+		name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if strings.EqualFold(name, "q") {
+			if q, err := strconv.ParseFloat(value, 64); err == nil {
+				quality = q
+			}
+		}
+	}
+	return mediaType, quality
+}
+
+// writeExport answers with a spreadsheet download written by write.
+func writeExport(c echo.Context, format, filename string, write func(io.Writer) error) error {
+	contentType := format
+	if format == service.ExportCSV {
+		contentType += "; charset=utf-8"
+	}
+	c.Response().Header().Set(echo.HeaderContentType, contentType)
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf(`attachment; filename="%s.%s"`, filename, exportExtensions[format]))
+	c.Response().WriteHeader(http.StatusOK)
+	return write(c.Response())
+}
@@ -0,0 +1,90 @@
+// job
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/service"
+	"github.com/labstack/echo/v4"
+)
+
+type JobHandler struct {
+	taxJobService service.TaxJobService
+}
+
+func NewJobHandler(taxJobService service.TaxJobService) *JobHandler {
+	return &JobHandler{
+		taxJobService: taxJobService,
+	}
+}
+
+type JobResponse struct {
+	*model.TaxUpload
+	ResultURL string `json:"resultUrl,omitempty"`
+}
+
+func newJobResponse(upload *model.TaxUpload) *JobResponse {
+	resp := &JobResponse{TaxUpload: upload}
+	if upload.Status == model.UploadCompleted {
+		resp.ResultURL = fmt.Sprintf("/tax/jobs/%d/result", upload.ID)
+	}
+	return resp
+}
+
+func (h *JobHandler) GetJob(c echo.Context) error {
+	id, err := jobIDParam(c)
+	if err != nil {
+		return err
+	}
+
+	upload, err := h.taxJobService.GetJob(id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if upload == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Job not found")
+	}
+
+	return c.JSON(http.StatusOK, newJobResponse(upload))
+}
+
+// GetJobResult downloads the result file of a completed job, as CSV unless
+// the request accepts XLSX.
+func (h *JobHandler) GetJobResult(c echo.Context) error {
+	id, err := jobIDParam(c)
+	if err != nil {
+		return err
+	}
+
+	upload, err := h.taxJobService.GetJob(id)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	if upload == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Job not found")
+	}
+	if upload.Status != model.UploadCompleted {
+		return echo.NewHTTPError(http.StatusConflict, "Job is "+upload.Status)
+	}
+
+	format := exportFormat(c, service.ExportCSV)
+	if format == "" {
+		format = service.ExportCSV // the result is only a file
+	}
+	return writeExport(c, format, fmt.Sprintf("job-%d-result", id), func(w io.Writer) error {
+		return h.taxJobService.WriteResult(id, format, w)
+	})
+}
+
+func jobIDParam(c echo.Context) (uint, error) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, "id must be a positive number")
+	}
+	return uint(id), nil
+}
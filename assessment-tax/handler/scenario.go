@@ -0,0 +1,39 @@
+// Scenario
+package handler
+
+import (
+	"net/http"
+
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/service"
+	"github.com/labstack/echo/v4"
+)
+
+type ScenarioHandler struct {
+	scenarioService service.ScenarioService
+}
+
+func NewScenarioHandler(scenarioService service.ScenarioService) *ScenarioHandler {
+	return &ScenarioHandler{
+		scenarioService: scenarioService,
+	}
+}
+
+// CompareScenarios returns the tax of a request next to the tax of each
+// variation of it, such as a larger RMF contribution, without storing any.
+func (h *ScenarioHandler) CompareScenarios(c echo.Context) error {
+	var req model.ScenarioRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	comparison, err := h.scenarioService.Compare(req)
+	if err != nil {
+		return calculationError(err)
+	}
+
+	return c.JSON(http.StatusOK, comparison)
+}
@@ -0,0 +1,95 @@
+// Withholding
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/service"
+	"github.com/labstack/echo/v4"
+)
+
+type WithholdingHandler struct {
+	withholdingService service.WithholdingService
+	// maxUploadSize is the largest request body an upload may have, in bytes.
+	maxUploadSize int64
+}
+
+func NewWithholdingHandler(withholdingService service.WithholdingService, maxUploadSize int64) *WithholdingHandler {
+	return &WithholdingHandler{
+		withholdingService: withholdingService,
+		maxUploadSize:      maxUploadSize,
+	}
+}
+
+// CalculateWithholding returns the tax to withhold from an employee's salary
+// this month.
+func (h *WithholdingHandler) CalculateWithholding(c echo.Context) error {
+	var req model.WithholdingRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	if err := req.Validate(); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	result, err := h.withholdingService.Calculate(req)
+	if err != nil {
+		return calculationError(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// UploadWithholdingCSV calculates the withholding of every employee of a
+// monthly payroll file right away; nothing is stored. Rejected lines are
+// listed in "errors" and the request fails with 422 only when no line could
+// be used. A request that accepts CSV or XLSX gets the result as a
+// spreadsheet instead of JSON.
+func (h *WithholdingHandler) UploadWithholdingCSV(c echo.Context) error {
+	req := c.Request()
+	req.Body = http.MaxBytesReader(c.Response(), req.Body, h.maxUploadSize)
+
+	file, err := c.FormFile("payrollFile")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return echo.NewHTTPError(http.StatusRequestEntityTooLarge, fmt.Sprintf("upload is larger than %d bytes", tooLarge.Limit))
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+	defer src.Close()
+
+	run, err := h.withholdingService.ImportCSV(src)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+	}
+
+	if len(run.Results) == 0 {
+		if run.Errors == nil {
+			run.Errors = []model.CSVRowError{}
+		}
+		return c.JSON(http.StatusUnprocessableEntity, map[string]interface{}{
+			"message": "No valid rows in file",
+			"errors":  run.Errors,
+		})
+	}
+
+	if format := exportFormat(c, ""); format != "" {
+		name := strings.TrimSuffix(file.Filename, filepath.Ext(file.Filename)) + "-withholding"
+		return writeExport(c, format, name, func(w io.Writer) error {
+			return service.WriteWithholdingRun(w, format, run)
+		})
+	}
+	return c.JSON(http.StatusOK, run)
+}
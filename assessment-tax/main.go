@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -16,7 +17,9 @@ import (
 	_ "github.com/lib/pq"
 
 	"github.com/LGROW101/assessment-tax/config"
+	"github.com/LGROW101/assessment-tax/databases/migration"
 	"github.com/LGROW101/assessment-tax/handler"
+	"github.com/LGROW101/assessment-tax/model"
 	"github.com/LGROW101/assessment-tax/repository"
 	"github.com/LGROW101/assessment-tax/service"
 )
@@ -25,6 +28,24 @@ func main() {
 	// Load configuration
 	cfg := config.LoadConfig()
 
+	// "migrate up|down|status" manages the database schema instead of serving
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg.DatabaseURL, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if cfg.JWTSecret == "" {
+		log.Fatal("JWT_SECRET must be set")
+	}
+
+	if cfg.MigrateOnStart {
+		if err := migration.Up(cfg.DatabaseURL); err != nil {
+			log.Fatal("Failed to run migrations: ", err)
+		}
+	}
+
 	// Connect to database
 	db, err := sql.Open("postgres", cfg.DatabaseURL)
 	if err != nil {
@@ -35,21 +56,55 @@ func main() {
 	// Create repository instances
 	taxRepo := repository.NewTaxRepository(db)
 	adminRepo := repository.NewAdminRepository(db)
+	uploadRepo := repository.NewUploadRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	auditRepo := repository.NewAuditRepository(db)
+	allowanceRepo := repository.NewAllowanceRepository(db)
 
 	// Create service instances
-	taxCalculatorService := service.NewTaxCalculatorService(taxRepo, adminRepo)
-	taxCSVService := service.NewTaxCSVService(taxRepo, adminRepo)
+	taxCalculatorService := service.NewTaxCalculatorService(taxRepo, adminRepo, allowanceRepo)
+	taxCSVService := service.NewTaxCSVService(taxCalculatorService)
+	scenarioService := service.NewScenarioService(taxCalculatorService)
+	withholdingService := service.NewWithholdingService(taxCalculatorService)
+	taxJobService := service.NewTaxJobService(uploadRepo, taxCalculatorService, cfg.JobWorkers)
+	authService := service.NewAuthService(userRepo, cfg.JWTSecret, cfg.TokenTTL)
+
+	// The admin from the environment is the first super-admin
+	if cfg.AdminUsername != "" && cfg.AdminPassword != "" {
+		if err := authService.EnsureSuperAdmin(cfg.AdminUsername, cfg.AdminPassword); err != nil {
+			log.Fatal(err)
+		}
+	}
 	// Create handler instances
 	calculatorHandler := handler.NewCalculatorHandler(taxCalculatorService)
-	csvHandler := handler.NewCSVHandler(taxCSVService)
+	scenarioHandler := handler.NewScenarioHandler(scenarioService)
+	withholdingHandler := handler.NewWithholdingHandler(withholdingService, cfg.MaxUploadSize)
+	csvHandler := handler.NewCSVHandler(taxCSVService, taxJobService, cfg.MaxUploadSize)
+	jobHandler := handler.NewJobHandler(taxJobService)
+	authHandler := handler.NewAuthHandler(authService)
+	auditHandler := handler.NewAuditHandler(auditRepo)
+	allowanceHandler := handler.NewAllowanceHandler(allowanceRepo)
+
+	// Process CSV upload jobs in the background, resuming unfinished ones
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	jobsDone := make(chan struct{})
+	go func() {
+		defer close(jobsDone)
+		taxJobService.Run(jobCtx)
+	}()
 	adminHandler := handler.NewAdminHandler(adminRepo)
 
 	// Create a new Echo instance
 	e := echo.New()
+	e.IPExtractor, err = handler.IPExtractor(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// Middleware
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
+	e.Use(handler.RequestID())
 
 	// Routes
 	e.GET("/", func(c echo.Context) error {
@@ -64,14 +119,54 @@ func main() {
 
 	e.GET("tax/calculations", calculatorHandler.GetAllCalculations)
 
-	e.GET("/admin/deductions", adminHandler.GetConfig)
+	e.GET("tax/calculations/:id", calculatorHandler.GetCalculation)
+
+	e.POST("tax/scenarios", scenarioHandler.CompareScenarios)
+
+	e.POST("tax/gross-up", calculatorHandler.GrossUp)
+
+	e.POST("tax/households", calculatorHandler.CalculateHousehold)
+
+	e.POST("tax/withholding", withholdingHandler.CalculateWithholding)
+
+	e.POST("tax/withholding/upload-csv", withholdingHandler.UploadWithholdingCSV)
+
+	e.POST("/admin/login", authHandler.Login)
+
+	admin := e.Group("/admin")
+
+	admin.POST("/logout", authHandler.Logout, authHandler.RequireRole(model.RoleViewer))
+
+	admin.GET("/deductions", adminHandler.GetConfig, authHandler.RequireRole(model.RoleViewer))
 
-	e.POST("/admin/deductions", adminHandler.UpdateConfig, middleware.BasicAuth(func(username, password string, c echo.Context) (bool, error) {
-		return username == cfg.AdminUsername && password == cfg.AdminPassword, nil
-	}))
+	admin.POST("/deductions", adminHandler.UpdateConfig, authHandler.RequireRole(model.RoleTaxAdmin))
+
+	admin.GET("/rule-sets", adminHandler.ListRuleSets, authHandler.RequireRole(model.RoleViewer))
+
+	admin.POST("/rule-sets", adminHandler.CreateRuleSet, authHandler.RequireRole(model.RoleTaxAdmin))
+
+	admin.GET("/allowances", allowanceHandler.ListAllowanceTypes, authHandler.RequireRole(model.RoleViewer))
+
+	admin.PUT("/allowances/:key", allowanceHandler.SaveAllowanceType, authHandler.RequireRole(model.RoleTaxAdmin))
+
+	admin.DELETE("/allowances/:key", allowanceHandler.DeleteAllowanceType, authHandler.RequireRole(model.RoleTaxAdmin))
+
+	admin.GET("/allowance-groups", allowanceHandler.ListAllowanceGroups, authHandler.RequireRole(model.RoleViewer))
+
+	admin.PUT("/allowance-groups/:key", allowanceHandler.SaveAllowanceGroup, authHandler.RequireRole(model.RoleTaxAdmin))
+
+	admin.GET("/users", authHandler.ListUsers, authHandler.RequireRole(model.RoleSuperAdmin))
+
+	admin.POST("/users", authHandler.CreateUser, authHandler.RequireRole(model.RoleSuperAdmin))
+
+	admin.GET("/audit", auditHandler.ListEntries, authHandler.RequireRole(model.RoleSuperAdmin))
 
 	e.POST("tax/calculations/upload-csv", csvHandler.UploadCSV)
 
+	e.GET("tax/jobs/:id", jobHandler.GetJob)
+
+	e.GET("tax/jobs/:id/result", jobHandler.GetJobResult)
+
 	// Start server with graceful shutdown
 	go func() {
 		if err := e.Start(fmt.Sprintf(":%s", cfg.Port)); err != nil && err != http.ErrServerClosed {
@@ -88,4 +183,47 @@ func main() {
 	if err := e.Shutdown(ctx); err != nil {
 		e.Logger.Fatal(err)
 	}
+	stopJobs()
+	<-jobsDone
+}
+
+// runMigrate runs a migrate subcommand: "up" applies every pending migration,
+// "down [steps]" reverts the last one, or the last steps, and "status" prints
+// the version the database is at and the migrations still pending.
+func runMigrate(databaseURL string, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: %s migrate up|down [steps]|status", os.Args[0])
+	}
+
+	switch args[0] {
+	case "up":
+		if err := migration.Up(databaseURL); err != nil {
+			return err
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive number, got %q", args[1])
+			}
+		}
+		if err := migration.Down(databaseURL, steps); err != nil {
+			return err
+		}
+	case "status":
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+
+	status, err := migration.GetStatus(databaseURL)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("version: %d\n", status.Version)
+	if status.Dirty {
+		fmt.Println("dirty: the last migration failed half way; repair the schema before migrating again")
+	}
+	fmt.Printf("pending: %d %v\n", len(status.Pending), status.Pending)
+	return nil
 }
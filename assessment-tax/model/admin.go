@@ -7,21 +7,74 @@ import (
 )
 
 type AdminRequest struct {
-	PersonalDeduction *float64 `json:"personalDeduction"`
-	KReceipt          *float64 `json:"k_receipt"`
+	TaxYear           *int   `json:"taxYear"`
+	PersonalDeduction *Money `json:"personalDeduction"`
+	KReceipt          *Money `json:"k_receipt"`
+	DonationCap       *Money `json:"donationCap"`
 }
 
+// RuleSetRequest creates a complete rule set version for a tax year.
+type RuleSetRequest struct {
+	TaxYear           int          `json:"taxYear"`
+	PersonalDeduction Money        `json:"personalDeduction"`
+	KReceipt          Money        `json:"kReceipt"`
+	DonationCap       Money        `json:"donationCap"`
+	EffectiveFrom     time.Time    `json:"effectiveFrom"`
+	EffectiveTo       *time.Time   `json:"effectiveTo"`
+	Brackets          []TaxBracket `json:"brackets"`
+}
+
+// AdminConfig is one version of the tax rule set of a tax year: deduction
+// caps, allowance limits, the bracket schedule and the allowance catalogue.
+// Versions are append-only; the highest version of a tax year is the one in
+// force. AllowanceTypes is nil for a rule set that is not stored, which is
+// calculated with the current catalogue.
 type AdminConfig struct {
-	ID                uint      `json:"ID,omitempty" gorm:"primaryKey" db:"id"`
-	PersonalDeduction float64   `json:"PersonalDeduction,omitempty" db:"personal_deduction"`
-	KReceipt          float64   `json:"KReceipt,omitempty" db:"k_receipt"`
-	CreatedAt         time.Time `json:"-" db:"created_at"`
-	UpdatedAt         time.Time `json:"-" db:"updated_at"`
+	ID                uint             `json:"ID,omitempty" gorm:"primaryKey" db:"id"`
+	TaxYear           int              `json:"TaxYear,omitempty" db:"tax_year"`
+	Version           int              `json:"Version,omitempty" db:"version"`
+	PersonalDeduction Money            `json:"PersonalDeduction,omitempty" db:"personal_deduction"`
+	KReceipt          Money            `json:"KReceipt,omitempty" db:"k_receipt"`
+	DonationCap       Money            `json:"DonationCap,omitempty" db:"donation_cap"`
+	EffectiveFrom     time.Time        `json:"EffectiveFrom" db:"effective_from"`
+	EffectiveTo       *time.Time       `json:"EffectiveTo,omitempty" db:"effective_to"`
+	Brackets          []TaxBracket     `json:"Brackets,omitempty" gorm:"-"`
+	AllowanceTypes    []*AllowanceType `json:"AllowanceTypes,omitempty" db:"allowance_types"`
+	CreatedAt         time.Time        `json:"-" db:"created_at"`
+	UpdatedAt         time.Time        `json:"-" db:"updated_at"`
+}
+
+// DefaultRuleSet returns the statutory rule set of the tax year, the one the
+// seed migration stores. It stands in while no rule set is in effect; its
+// Version is 0 as it is not stored.
+func DefaultRuleSet(taxYear int) *AdminConfig {
+	return &AdminConfig{
+		TaxYear:           taxYear,
+		PersonalDeduction: Baht(60000),
+		KReceipt:          Baht(50000),
+		DonationCap:       Baht(100000),
+		EffectiveFrom:     time.Date(taxYear, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Brackets: []TaxBracket{
+			{Threshold: Baht(0), Rate: Percent(0)},
+			{Threshold: Baht(150000), Rate: Percent(10)},
+			{Threshold: Baht(500000), Rate: Percent(15)},
+			{Threshold: Baht(1000000), Rate: Percent(20)},
+			{Threshold: Baht(2000000), Rate: Percent(35)},
+		},
+	}
 }
 
 type AdminResponse struct {
-	PersonalDeduction float64 `json:"personalDeduction,omitempty"`
-	KReceipt          float64 `json:"KReceipt,omitempty"`
+	TaxYear           int              `json:"taxYear,omitempty"`
+	Version           int              `json:"version,omitempty"`
+	PersonalDeduction Money            `json:"personalDeduction,omitempty"`
+	KReceipt          Money            `json:"KReceipt,omitempty"`
+	DonationCap       Money            `json:"donationCap,omitempty"`
+	EffectiveFrom     *time.Time       `json:"effectiveFrom,omitempty"`
+	EffectiveTo       *time.Time       `json:"effectiveTo,omitempty"`
+	Brackets          []TaxBracket     `json:"brackets,omitempty"`
+	AllowanceTypes    []*AllowanceType `json:"allowanceTypes,omitempty"`
+	Default           bool             `json:"default,omitempty"`
 }
 
 func (c *AdminConfig) Validate() error {
@@ -35,5 +88,14 @@ func (c *AdminConfig) Validate() error {
 			return errors.New("k-receipt must be positive")
 		}
 	}
+	if c.DonationCap < 0 {
+		return errors.New("donation cap must not be negative")
+	}
+	if c.TaxYear < 1900 || c.TaxYear > 9999 {
+		return errors.New("tax year is invalid")
+	}
+	if c.EffectiveTo != nil && c.EffectiveTo.Before(c.EffectiveFrom) {
+		return errors.New("effective-to must not be before effective-from")
+	}
 	return nil
 }
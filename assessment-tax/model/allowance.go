@@ -0,0 +1,124 @@
+// model/allowance.go
+package model
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Built-in allowance types. The calculator has rules of its own for them,
+// so they cannot be removed from the catalogue. An education donation (to
+// schools, sports and public hospitals) is deducted at twice its amount.
+const (
+	AllowanceDonation          = "donation"
+	AllowanceEducationDonation = "education-donation"
+	AllowanceKReceipt          = "k-receipt"
+)
+
+// Allowance stages. Allowances of the "after" stage are deducted once every
+// "before" allowance has been, so their percentage caps apply to the income
+// left after them.
+const (
+	AllowanceStageBefore = "before"
+	AllowanceStageAfter  = "after"
+)
+
+var allowanceKeyPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// AllowanceType is an entry of the allowance catalogue. A claim is deducted
+// up to CapAmount and up to CapRate of the income it is deducted from; a nil
+// cap does not limit it.
+//
+// A type with a UnitAmount is claimed per person, by count, such as children
+// or parents: it deducts UnitAmount for each of at most MaxUnits people.
+// Types in the same Group share the group's cap, GroupCap.
+type AllowanceType struct {
+	Key         string    `json:"key" db:"key"`
+	DisplayName string    `json:"displayName" db:"display_name"`
+	CapAmount   *Money    `json:"capAmount,omitempty" db:"cap_amount"`
+	CapRate     *Rate     `json:"capRate,omitempty" db:"cap_rate"`
+	UnitAmount  *Money    `json:"unitAmount,omitempty" db:"unit_amount"`
+	MaxUnits    *int      `json:"maxUnits,omitempty" db:"max_units"`
+	Group       string    `json:"group,omitempty" db:"group_key"`
+	GroupCap    *Money    `json:"groupCap,omitempty"`
+	Stage       string    `json:"stage" db:"stage"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// AllowanceGroup caps the total deducted for the allowance types in it, such
+// as the 500,000 baht shared by retirement savings.
+type AllowanceGroup struct {
+	Key         string    `json:"key" db:"key"`
+	DisplayName string    `json:"displayName" db:"display_name"`
+	CapAmount   Money     `json:"capAmount" db:"cap_amount"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// IsBuiltinAllowance reports whether the allowance type is one the
+// calculator has rules of its own for.
+func IsBuiltinAllowance(key string) bool {
+	return IsDonationAllowance(key) || key == AllowanceKReceipt
+}
+
+// IsDonationAllowance reports whether the allowance type is a donation.
+// Donations are deducted after every other allowance, whatever their stage.
+func IsDonationAllowance(key string) bool {
+	return key == AllowanceDonation || key == AllowanceEducationDonation
+}
+
+// Validate trims the display name, defaults the stage to "before" and checks
+// the entry.
+func (t *AllowanceType) Validate() error {
+	t.DisplayName = strings.TrimSpace(t.DisplayName)
+	if t.Stage == "" {
+		t.Stage = AllowanceStageBefore
+	}
+
+	if !allowanceKeyPattern.MatchString(t.Key) {
+		return errors.New("key must be lowercase letters and digits separated by '-'")
+	}
+	if t.DisplayName == "" {
+		return errors.New("display name is required")
+	}
+	if t.CapAmount != nil && *t.CapAmount < 0 {
+		return errors.New("cap amount must not be negative")
+	}
+	if t.CapRate != nil && (*t.CapRate < 0 || *t.CapRate > Percent(100)) {
+		return errors.New("cap rate must be between 0 and 1")
+	}
+	if t.UnitAmount != nil && *t.UnitAmount < 0 {
+		return errors.New("unit amount must not be negative")
+	}
+	if t.MaxUnits != nil && (t.UnitAmount == nil || *t.MaxUnits < 1) {
+		return errors.New("max units must be at least 1 and needs a unit amount")
+	}
+	if t.UnitAmount != nil && IsBuiltinAllowance(t.Key) {
+		return errors.New("built-in allowance types are claimed by amount")
+	}
+	if t.Group != "" && !allowanceKeyPattern.MatchString(t.Group) {
+		return errors.New("group must be lowercase letters and digits separated by '-'")
+	}
+	if t.Stage != AllowanceStageBefore && t.Stage != AllowanceStageAfter {
+		return errors.New("stage must be before or after")
+	}
+	return nil
+}
+
+// Validate trims the display name and checks the group.
+func (g *AllowanceGroup) Validate() error {
+	g.DisplayName = strings.TrimSpace(g.DisplayName)
+	if !allowanceKeyPattern.MatchString(g.Key) {
+		return errors.New("key must be lowercase letters and digits separated by '-'")
+	}
+	if g.DisplayName == "" {
+		return errors.New("display name is required")
+	}
+	if g.CapAmount < 0 {
+		return errors.New("cap amount must not be negative")
+	}
+	return nil
+}
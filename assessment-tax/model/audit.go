@@ -0,0 +1,77 @@
+// model/audit.go
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Audited admin actions.
+const (
+	AuditDeductionsUpdate     = "deductions.update"
+	AuditRuleSetCreate        = "rule-set.create"
+	AuditUserCreate           = "user.create"
+	AuditAllowanceUpdate      = "allowance.update"
+	AuditAllowanceDelete      = "allowance.delete"
+	AuditAllowanceGroupUpdate = "allowance-group.update"
+)
+
+// AuditEntry records one change made through the admin API: who made it,
+// from where, and the resource before and after. Entries are never changed.
+type AuditEntry struct {
+	ID         uint            `json:"id" db:"id"`
+	Actor      string          `json:"actor" db:"actor"`
+	Action     string          `json:"action" db:"action"`
+	Resource   string          `json:"resource" db:"resource"`
+	ResourceID string          `json:"resourceId,omitempty" db:"resource_id"`
+	OldValue   json.RawMessage `json:"oldValue" db:"old_value"`
+	NewValue   json.RawMessage `json:"newValue" db:"new_value"`
+	ClientIP   string          `json:"clientIp" db:"client_ip"`
+	RequestID  string          `json:"requestId,omitempty" db:"request_id"`
+	CreatedAt  time.Time       `json:"createdAt" db:"created_at"`
+}
+
+// SetOldValue stores the resource as it was before the change; nil is stored
+// as JSON null.
+func (e *AuditEntry) SetOldValue(value any) error {
+	old, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	e.OldValue = old
+	return nil
+}
+
+const (
+	DefaultAuditPageSize = 50
+	MaxAuditPageSize     = 500
+)
+
+// AuditFilter selects audit entries, newest first. Cursor is the ID of the
+// last entry of the previous page.
+type AuditFilter struct {
+	Actor     string
+	Action    string
+	Resource  string
+	RequestID string
+	From      *time.Time
+	To        *time.Time
+	Cursor    uint
+	Limit     int
+}
+
+// Normalize fills in the default page size and validates the filter.
+func (f *AuditFilter) Normalize() error {
+	if f.Limit == 0 {
+		f.Limit = DefaultAuditPageSize
+	}
+	if f.Limit < 0 || f.Limit > MaxAuditPageSize {
+		return fmt.Errorf("limit must be between 1 and %d", MaxAuditPageSize)
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return errors.New("from must be before to")
+	}
+	return nil
+}
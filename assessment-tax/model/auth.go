@@ -0,0 +1,74 @@
+// model/auth.go
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Admin roles, each allowed everything the previous one is.
+const (
+	RoleViewer     = "viewer"
+	RoleTaxAdmin   = "tax-admin"
+	RoleSuperAdmin = "super-admin"
+)
+
+var roleRanks = map[string]int{
+	RoleViewer:     1,
+	RoleTaxAdmin:   2,
+	RoleSuperAdmin: 3,
+}
+
+// ValidRole reports whether role is one of the admin roles.
+func ValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// RoleAllows reports whether a user with role may do what required permits.
+func RoleAllows(role, required string) bool {
+	return ValidRole(role) && roleRanks[role] >= roleRanks[required]
+}
+
+type AdminUser struct {
+	ID           uint      `json:"id" db:"id"`
+	Username     string    `json:"username" db:"username"`
+	PasswordHash string    `json:"-" db:"password_hash"`
+	Role         string    `json:"role" db:"role"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type LoginResponse struct {
+	Token     string    `json:"token"`
+	TokenType string    `json:"tokenType"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Role      string    `json:"role"`
+}
+
+type AdminUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+}
+
+// Validate trims the username and checks the fields of a new user.
+func (r *AdminUserRequest) Validate() error {
+	r.Username = strings.TrimSpace(r.Username)
+	if r.Username == "" {
+		return errors.New("username is required")
+	}
+	if len(r.Password) < 8 {
+		return errors.New("password must be at least 8 characters")
+	}
+	if !ValidRole(r.Role) {
+		return errors.New("role must be viewer, tax-admin or super-admin")
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// CSVRowError reports why a line of an uploaded file was rejected. Row is the
+// line number in the file, counting the header as line 1.
+type CSVRowError struct {
+	Row    int    `json:"row"`
+	Column string `json:"column,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func (e *CSVRowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("row %d: %s", e.Row, e.Reason)
+	}
+	return fmt.Sprintf("row %d, column %s: %s", e.Row, e.Column, e.Reason)
+}
+
+// CSVImportResult holds the results of the rows that were calculated and the
+// errors of the rows that were rejected. Calculations are the full results
+// behind Taxes, used when the result is exported as a spreadsheet.
+type CSVImportResult struct {
+	Taxes        []map[string]Money `json:"taxes"`
+	Errors       []CSVRowError      `json:"errors,omitempty"`
+	DryRun       bool               `json:"dryRun,omitempty"`
+	Calculations []*TaxCalculation  `json:"-"`
+}
+
+const (
+	UploadQueued    = "queued"
+	UploadRunning   = "running"
+	UploadCompleted = "completed"
+	UploadFailed    = "failed"
+)
+
+// TaxUpload is a CSV file processed as a background job. The calculations of
+// its rows are stored, linked by UploadID, only once the whole file has been
+// processed; until then ProcessedCount reports progress.
+type TaxUpload struct {
+	ID             uint          `db:"id" json:"id"`
+	Filename       string        `db:"filename" json:"filename"`
+	Status         string        `db:"status" json:"status"`
+	RowCount       int           `db:"row_count" json:"rowCount"`
+	ProcessedCount int           `db:"processed_count" json:"processedCount"`
+	ErrorCount     int           `db:"error_count" json:"errorCount"`
+	Errors         []CSVRowError `db:"errors" json:"-"`
+	Error          string        `db:"error" json:"error,omitempty"`
+	CreatedAt      time.Time     `db:"created_at" json:"createdAt"`
+	UpdatedAt      time.Time     `db:"updated_at" json:"updatedAt"`
+	FinishedAt     *time.Time    `db:"finished_at" json:"finishedAt,omitempty"`
+	// Attempt counts the claims of the upload; the worker holding the latest
+	// is the only one whose writes are applied.
+	Attempt int `db:"attempt" json:"-"`
+}
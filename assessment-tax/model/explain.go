@@ -0,0 +1,32 @@
+// model/explain.go
+package model
+
+// Kinds of calculation step, in the order a calculation takes them.
+const (
+	StepIncome            = "income"
+	StepExpense           = "expense"
+	StepPersonalAllowance = "personal-allowance"
+	StepAllowance         = "allowance"
+	StepTaxableIncome     = "taxable-income"
+	StepBracket           = "bracket"
+	StepTax               = "tax"
+	StepWHT               = "wht"
+	StepTaxPayable        = "tax-payable"
+	StepTaxRefund         = "tax-refund"
+)
+
+// CalculationStep is one step of the explanation of a calculation. Key names
+// what the step is about: an income category, an allowance type or a bracket.
+// Amount is what the step earns, deducts or taxes. A deduction reports the
+// amount Claimed, the Cap that limited it and the Reason; a step taking a
+// Rate of an amount reports that amount as Base.
+type CalculationStep struct {
+	Step    string `json:"step"`
+	Key     string `json:"key,omitempty"`
+	Claimed *Money `json:"claimed,omitempty"`
+	Base    *Money `json:"base,omitempty"`
+	Rate    *Rate  `json:"rate,omitempty"`
+	Cap     *Money `json:"cap,omitempty"`
+	Amount  Money  `json:"amount"`
+	Reason  string `json:"reason,omitempty"`
+}
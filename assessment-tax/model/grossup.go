@@ -0,0 +1,67 @@
+// model/grossup.go
+package model
+
+import "errors"
+
+// GrossUpRequest asks for the income at which a calculation reaches a target:
+// the net income left after tax, or the tax itself. Exactly one of the two is
+// given. The income is of Category when set, so its expenses are deducted,
+// and is otherwise taken as a total income.
+type GrossUpRequest struct {
+	TaxYear    int         `json:"taxYear,omitempty"`
+	TargetNet  *Money      `json:"targetNet"`
+	TargetTax  *Money      `json:"targetTax"`
+	Category   string      `json:"category"`
+	WHT        Money       `json:"wht"`
+	Allowances []Allowance `json:"allowances"`
+}
+
+// GrossUpResult is the smallest income reaching the target of a
+// GrossUpRequest and the calculation at that income. NetIncome is the
+// income less its tax.
+type GrossUpResult struct {
+	TotalIncome   Money     `json:"totalIncome"`
+	NetIncome     Money     `json:"netIncome"`
+	TaxableIncome Money     `json:"taxableIncome"`
+	Tax           Money     `json:"tax"`
+	TaxPayable    Money     `json:"taxPayable"`
+	TaxRefund     Money     `json:"taxRefund"`
+	TaxLevel      []TaxRate `json:"taxLevel"`
+}
+
+func (r *GrossUpRequest) Validate() error {
+	if (r.TargetNet == nil) == (r.TargetTax == nil) {
+		return errors.New("exactly one of targetNet and targetTax is required")
+	}
+	if r.TargetNet != nil && *r.TargetNet < 0 || r.TargetTax != nil && *r.TargetTax < 0 {
+		return errors.New("target must not be negative")
+	}
+	if r.WHT < 0 {
+		return errors.New("wht must not be negative")
+	}
+	if r.Category != "" {
+		if err := (Income{Category: r.Category}).Validate(); err != nil {
+			return err
+		}
+	}
+	for _, allowance := range r.Allowances {
+		if err := allowance.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Request returns the calculation request of the income.
+func (r *GrossUpRequest) Request(income Money) TaxCalculationRequest {
+	req := TaxCalculationRequest{
+		TaxYear:     r.TaxYear,
+		TotalIncome: income,
+		WHT:         r.WHT,
+		Allowances:  r.Allowances,
+	}
+	if r.Category != "" {
+		req.Incomes = []Income{{Category: r.Category, Amount: income}}
+	}
+	return req
+}
@@ -0,0 +1,64 @@
+// model/household.go
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Household filing options.
+const (
+	FilingSeparate = "separate"
+	FilingJoint    = "joint"
+)
+
+// AllowanceSpouse is the catalogue's allowance for a spouse without income.
+// A joint return already gives both spouses the personal allowance, so it is
+// not claimed there.
+const AllowanceSpouse = "spouse"
+
+// HouseholdRequest holds the calculation requests of two spouses. TaxYear
+// applies to both.
+type HouseholdRequest struct {
+	TaxYear int                     `json:"taxYear,omitempty"`
+	Spouses []TaxCalculationRequest `json:"spouses"`
+}
+
+// HouseholdOption is one way of filing: a return per spouse, or one joint
+// return. Tax is the tax of its returns together, before the WHT credit.
+type HouseholdOption struct {
+	Tax        Money                    `json:"tax"`
+	TaxPayable Money                    `json:"taxPayable"`
+	TaxRefund  Money                    `json:"taxRefund"`
+	Returns    []TaxCalculationResponse `json:"returns"`
+}
+
+// HouseholdResponse compares filing separately with filing jointly and
+// recommends the option with less tax, separate when they are equal.
+type HouseholdResponse struct {
+	Separate    HouseholdOption `json:"separate"`
+	Joint       HouseholdOption `json:"joint"`
+	Recommended string          `json:"recommended"`
+}
+
+func (r *HouseholdRequest) Validate() error {
+	if len(r.Spouses) != 2 {
+		return errors.New("exactly two spouses are required")
+	}
+	for i, spouse := range r.Spouses {
+		if spouse.TotalIncome < 0 || spouse.WHT < 0 {
+			return fmt.Errorf("spouse %d: amounts must not be negative", i+1)
+		}
+		for _, income := range spouse.Incomes {
+			if err := income.Validate(); err != nil {
+				return fmt.Errorf("spouse %d: %w", i+1, err)
+			}
+		}
+		for _, allowance := range spouse.Allowances {
+			if err := allowance.Validate(); err != nil {
+				return fmt.Errorf("spouse %d: %w", i+1, err)
+			}
+		}
+	}
+	return nil
+}
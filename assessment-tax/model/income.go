@@ -0,0 +1,72 @@
+// model/income.go
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Income categories of section 40 of the Revenue Code.
+const (
+	IncomeSalary     = "40(1)" // salary, wages and bonuses
+	IncomeService    = "40(2)" // fees for services, commissions
+	IncomeRoyalty    = "40(3)" // copyright and goodwill
+	IncomeInvestment = "40(4)" // interest and dividends
+	IncomeRent       = "40(5)" // rent of property
+	IncomeProfession = "40(6)" // liberal professions
+	IncomeContract   = "40(7)" // contracts of work
+	IncomeBusiness   = "40(8)" // business, commerce and other income
+)
+
+// ExpenseRule is the standard expense deduction of an income category: Rate
+// of the income, up to Cap. Categories with the same CapGroup share one cap,
+// as salary and service fees share 100,000 baht.
+type ExpenseRule struct {
+	Rate     Rate
+	Cap      *Money
+	CapGroup string
+}
+
+var expenseCap = Baht(100000)
+
+var expenseRules = map[string]ExpenseRule{
+	IncomeSalary:     {Rate: Percent(50), Cap: &expenseCap, CapGroup: "employment"},
+	IncomeService:    {Rate: Percent(50), Cap: &expenseCap, CapGroup: "employment"},
+	IncomeRoyalty:    {Rate: Percent(50), Cap: &expenseCap},
+	IncomeInvestment: {},
+	IncomeRent:       {Rate: Percent(30)},
+	IncomeProfession: {Rate: Percent(30)},
+	IncomeContract:   {Rate: Percent(60)},
+	IncomeBusiness:   {Rate: Percent(60)},
+}
+
+// ExpenseRuleOf returns the expense rule of an income category.
+func ExpenseRuleOf(category string) (ExpenseRule, bool) {
+	rule, ok := expenseRules[category]
+	return rule, ok
+}
+
+// Income is the amount earned in one category of a calculation request.
+type Income struct {
+	Category string `json:"category"`
+	Amount   Money  `json:"amount"`
+}
+
+func (i Income) Validate() error {
+	if _, ok := expenseRules[i.Category]; !ok {
+		return fmt.Errorf("unknown income category %q", i.Category)
+	}
+	if i.Amount < 0 {
+		return errors.New("income amount must not be negative")
+	}
+	return nil
+}
+
+// TotalIncome sums the incomes of every category.
+func TotalIncome(incomes []Income) Money {
+	var total Money
+	for _, income := range incomes {
+		total += income.Amount
+	}
+	return total
+}
@@ -0,0 +1,292 @@
+package model
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in baht held as an integer number of satang, so sums and
+// differences are exact. Anything finer than a satang - decimal input with more
+// than two places, or a share computed with MulRate - is rounded half away
+// from zero (2.345 becomes 2.35, -2.345 becomes -2.35).
+//
+// Money is marshalled as a JSON number with two decimals and stored in
+// Postgres NUMERIC columns.
+type Money int64
+
+const satangPerBaht = 100
+
+// Baht returns the Money value of a whole number of baht.
+func Baht(baht int64) Money {
+	return Money(baht * satangPerBaht)
+}
+
+// ParseMoney parses a decimal amount in baht such as "1234.56".
+func ParseMoney(s string) (Money, error) {
+	r, ok := parseDecimal(s)
+	if !ok {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return moneyFromRat(r.Mul(r, big.NewRat(satangPerBaht, 1)))
+}
+
+// MoneyFromFloat converts a float amount in baht, rounding to the nearest satang.
+func MoneyFromFloat(baht float64) Money {
+	return Money(math.Round(baht * satangPerBaht))
+}
+
+// Satang returns the amount as an integer number of satang.
+func (m Money) Satang() int64 {
+	return int64(m)
+}
+
+// WholeBaht returns the amount truncated to whole baht.
+func (m Money) WholeBaht() int64 {
+	return int64(m) / satangPerBaht
+}
+
+func (m Money) Float64() float64 {
+	return float64(m) / satangPerBaht
+}
+
+// String formats the amount with two decimals, e.g. "29000.00".
+func (m Money) String() string {
+	sign := ""
+	satang := int64(m)
+	if satang < 0 {
+		sign = "-"
+		satang = -satang
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, satang/satangPerBaht, satang%satangPerBaht)
+}
+
+// MulRate returns m multiplied by the rate, rounded to the satang.
+func (m Money) MulRate(rate Rate) Money {
+	r := new(big.Rat).SetFrac(
+		new(big.Int).Mul(big.NewInt(int64(m)), big.NewInt(int64(rate))),
+		big.NewInt(rateScale),
+	)
+	money, _ := moneyFromRat(r)
+	return money
+}
+
+// Div returns m divided into n equal parts, rounded to the satang.
+func (m Money) Div(n int64) Money {
+	money, _ := moneyFromRat(big.NewRat(int64(m), n))
+	return money
+}
+
+func MinMoney(a, b Money) Money {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func MaxMoney(a, b Money) Money {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a numeric string.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	money, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = money
+	return nil
+}
+
+// Scan reads a NUMERIC column.
+func (m *Money) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+	case int:
+		*m = Baht(int64(v))
+	case int64:
+		*m = Baht(v)
+	case float64:
+		*m = MoneyFromFloat(v)
+	case []byte:
+		return m.Scan(string(v))
+	case string:
+		money, err := ParseMoney(v)
+		if err != nil {
+			return err
+		}
+		*m = money
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+	return nil
+}
+
+// Value writes the amount as an exact decimal string.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// decimalPattern is the only form amounts and rates are read in; big.Rat on
+// its own also takes fractions such as "1/3" and exponents such as "1e400000".
+var decimalPattern = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+
+func parseDecimal(s string) (*big.Rat, bool) {
+	s = strings.TrimSpace(s)
+	if !decimalPattern.MatchString(s) {
+		return nil, false
+	}
+	return new(big.Rat).SetString(s)
+}
+
+func moneyFromRat(r *big.Rat) (Money, error) {
+	satang := roundHalfAwayFromZero(r)
+	if !satang.IsInt64() {
+		return 0, errors.New("amount is out of range")
+	}
+	return Money(satang.Int64()), nil
+}
+
+func roundHalfAwayFromZero(r *big.Rat) *big.Int {
+	num := new(big.Int).Abs(r.Num())
+	q, rem := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	if rem.Mul(rem, big.NewInt(2)).Cmp(r.Denom()) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if r.Sign() < 0 {
+		q.Neg(q)
+	}
+	return q
+}
+
+// Rate is a fraction such as a marginal tax rate, held exactly in units of
+// 1/10,000 (0.1 is 1000). It is marshalled as a JSON decimal fraction.
+type Rate int64
+
+const rateScale = 10000
+
+// ParseRate parses a decimal fraction such as "0.15".
+func ParseRate(s string) (Rate, error) {
+	r, ok := parseDecimal(s)
+	if !ok {
+		return 0, fmt.Errorf("invalid rate %q", s)
+	}
+	v := roundHalfAwayFromZero(r.Mul(r, big.NewRat(rateScale, 1)))
+	if !v.IsInt64() {
+		return 0, errors.New("rate is out of range")
+	}
+	return Rate(v.Int64()), nil
+}
+
+// ParsePercent parses a percentage such as "12.5" into its rate, 0.125.
+func ParsePercent(s string) (Rate, error) {
+	r, ok := parseDecimal(s)
+	if !ok {
+		return 0, fmt.Errorf("invalid percentage %q", s)
+	}
+	v := roundHalfAwayFromZero(r.Mul(r, big.NewRat(rateScale, 100)))
+	if !v.IsInt64() {
+		return 0, errors.New("percentage is out of range")
+	}
+	return Rate(v.Int64()), nil
+}
+
+// Percent returns the rate of a whole percentage, e.g. Percent(10) is 0.1.
+func Percent(percent int64) Rate {
+	return Rate(percent * rateScale / 100)
+}
+
+func (r Rate) Float64() float64 {
+	return float64(r) / rateScale
+}
+
+// String formats the rate as a decimal fraction, e.g. "0.15".
+func (r Rate) String() string {
+	sign := ""
+	v := int64(r)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	frac := strings.TrimRight(fmt.Sprintf("%04d", v%rateScale), "0")
+	if frac == "" {
+		return sign + strconv.FormatInt(v/rateScale, 10)
+	}
+	return sign + strconv.FormatInt(v/rateScale, 10) + "." + frac
+}
+
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	s := string(data)
+	if len(data) > 0 && data[0] == '"' {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+	rate, err := ParseRate(s)
+	if err != nil {
+		return err
+	}
+	*r = rate
+	return nil
+}
+
+func (r *Rate) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*r = 0
+	case int:
+		*r = Rate(int64(v) * rateScale)
+	case int64:
+		*r = Rate(v * rateScale)
+	case float64:
+		*r = Rate(math.Round(v * rateScale))
+	case []byte:
+		return r.Scan(string(v))
+	case string:
+		rate, err := ParseRate(v)
+		if err != nil {
+			return err
+		}
+		*r = rate
+	default:
+		return fmt.Errorf("cannot scan %T into Rate", src)
+	}
+	return nil
+}
+
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
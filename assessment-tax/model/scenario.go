@@ -0,0 +1,121 @@
+// model/scenario.go
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+const MaxScenarioVariations = 20
+
+// ScenarioRequest compares the calculation of Base with the calculation of
+// each variation of it. Nothing is stored.
+type ScenarioRequest struct {
+	Base       TaxCalculationRequest `json:"base"`
+	Variations []ScenarioVariation   `json:"variations"`
+}
+
+// ScenarioVariation changes the base request. ExtraIncome is added to the
+// total income as it is; Incomes are added to the incomes of a base broken
+// down by category. Allowances are claimed on top of the base's, or instead
+// of them with ReplaceAllowances. TaxYear and RuleSetVersion, when set,
+// select another rule set.
+type ScenarioVariation struct {
+	Name              string      `json:"name"`
+	ExtraIncome       Money       `json:"extraIncome"`
+	Incomes           []Income    `json:"incomes"`
+	Allowances        []Allowance `json:"allowances"`
+	ReplaceAllowances bool        `json:"replaceAllowances"`
+	TaxYear           int         `json:"taxYear"`
+	RuleSetVersion    int         `json:"ruleSetVersion"`
+}
+
+// ScenarioResult is the outcome of one scenario. Delta is the difference
+// from the base and is left out of the base itself.
+type ScenarioResult struct {
+	Name           string         `json:"name"`
+	TaxYear        int            `json:"taxYear,omitempty"`
+	RuleSetVersion int            `json:"ruleSetVersion,omitempty"`
+	TotalIncome    Money          `json:"totalIncome"`
+	Expenses       Money          `json:"expenses"`
+	TaxableIncome  Money          `json:"taxableIncome"`
+	Tax            Money          `json:"tax"`
+	TaxPayable     Money          `json:"taxPayable"`
+	TaxRefund      Money          `json:"taxRefund"`
+	MarginalRate   Rate           `json:"marginalRate"`
+	Delta          *ScenarioDelta `json:"delta,omitempty"`
+}
+
+// ScenarioDelta is a scenario minus the base; a negative Tax is a saving.
+type ScenarioDelta struct {
+	TotalIncome   Money `json:"totalIncome"`
+	TaxableIncome Money `json:"taxableIncome"`
+	Tax           Money `json:"tax"`
+	TaxPayable    Money `json:"taxPayable"`
+	TaxRefund     Money `json:"taxRefund"`
+}
+
+type ScenarioComparison struct {
+	Base       ScenarioResult   `json:"base"`
+	Variations []ScenarioResult `json:"variations"`
+}
+
+func (r *ScenarioRequest) Validate() error {
+	if len(r.Variations) == 0 || len(r.Variations) > MaxScenarioVariations {
+		return fmt.Errorf("between 1 and %d variations are required", MaxScenarioVariations)
+	}
+	if r.Base.TotalIncome < 0 || r.Base.WHT < 0 {
+		return errors.New("base amounts must not be negative")
+	}
+	if r.Base.RuleSetVersion != 0 && r.Base.TaxYear == 0 {
+		return errors.New("base: ruleSetVersion needs a taxYear")
+	}
+	for _, allowance := range r.Base.Allowances {
+		if err := allowance.Validate(); err != nil {
+			return fmt.Errorf("base: %w", err)
+		}
+	}
+	for i, variation := range r.Variations {
+		for _, allowance := range variation.Allowances {
+			if err := allowance.Validate(); err != nil {
+				return fmt.Errorf("variation %d: %w", i+1, err)
+			}
+		}
+		// a version is only one of a tax year's rule set
+		if variation.RuleSetVersion != 0 && variation.TaxYear == 0 && r.Base.TaxYear == 0 {
+			return fmt.Errorf("variation %d: ruleSetVersion needs a taxYear", i+1)
+		}
+		if variation.ExtraIncome < 0 {
+			return fmt.Errorf("variation %d: extra income must not be negative", i+1)
+		}
+		if len(variation.Incomes) > 0 && len(r.Base.Incomes) == 0 {
+			return fmt.Errorf("variation %d: incomes need a base broken down by incomes", i+1)
+		}
+		if variation.ExtraIncome != 0 && len(r.Base.Incomes) > 0 {
+			return fmt.Errorf("variation %d: give extra income as incomes when the base has them", i+1)
+		}
+	}
+	return nil
+}
+
+// Apply returns the base request changed by the variation.
+func (v ScenarioVariation) Apply(base TaxCalculationRequest) TaxCalculationRequest {
+	req := base
+	req.TotalIncome += v.ExtraIncome
+	if len(v.Incomes) > 0 {
+		req.Incomes = append(append([]Income{}, base.Incomes...), v.Incomes...)
+		req.TotalIncome = TotalIncome(req.Incomes)
+	}
+	if v.ReplaceAllowances {
+		req.Allowances = v.Allowances
+	} else if len(v.Allowances) > 0 {
+		req.Allowances = append(append([]Allowance{}, base.Allowances...), v.Allowances...)
+	}
+	if v.TaxYear != 0 {
+		req.TaxYear, req.RuleSetVersion = v.TaxYear, 0
+	}
+	if v.RuleSetVersion != 0 {
+		req.RuleSetVersion = v.RuleSetVersion
+	}
+	return req
+}
@@ -2,39 +2,165 @@ package model
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// TaxCalculation is the stored record of one calculation: the request as
+// submitted, the rule set version it was computed with and the full result.
+// Records are never updated, so a calculation can be replayed from Request
+// against the same rule set version and compared.
 type TaxCalculation struct {
-	ID                uint        `gorm:"primaryKey"`
-	TotalIncome       float64     `db:"totalIncome"`
-	WHT               float64     `db:"wht"`
-	PersonalAllowance float64     `db:"personal_allowance"`
-	Donation          float64     `db:"donation"`
-	KReceipt          float64     `db:"k_receipt"`
-	Tax               float64     `db:"tax"`
-	TaxPayable        float64     `db:"tax_payable"`
-	TaxRefund         float64     `json:"taxRefund"`
-	TaxLevel          []TaxRate   `gorm:"-" json:"taxLevel"`
-	Allowances        []Allowance `json:"allowances"`
-	CreatedAt         time.Time   `json:"createdAt"`
+	ID                uint                  `gorm:"primaryKey"`
+	TaxYear           int                   `db:"tax_year" json:"taxYear,omitempty"`
+	RuleSetID         uint                  `db:"admin_config_id" json:"ruleSetId,omitempty"`
+	RuleSetVersion    int                   `json:"ruleSetVersion,omitempty"`
+	UploadID          uint                  `db:"upload_id" json:"uploadId,omitempty"`
+	UploadRow         int                   `db:"upload_row" json:"uploadRow,omitempty"`
+	TotalIncome       Money                 `db:"totalIncome"`
+	WHT               Money                 `db:"wht"`
+	Expenses          Money                 `db:"expenses" json:"expenses"`
+	PersonalAllowance Money                 `db:"personal_allowance"`
+	Donation          Money                 `db:"donation"`
+	KReceipt          Money                 `db:"k_receipt"`
+	TaxableIncome     Money                 `db:"taxable_income" json:"taxableIncome"`
+	Tax               Money                 `db:"tax"`
+	TaxPayable        Money                 `db:"tax_payable"`
+	TaxRefund         Money                 `db:"tax_refund" json:"taxRefund"`
+	TaxLevel          []TaxRate             `gorm:"-" db:"tax_level" json:"taxLevel"`
+	MarginalRate      Rate                  `gorm:"-" db:"-" json:"-"` // not stored
+	Steps             []CalculationStep     `gorm:"-" db:"-" json:"-"` // not stored
+	Allowances        []Allowance           `db:"allowances" json:"allowances"`
+	Deductions        map[string]Money      `db:"deductions" json:"deductions,omitempty"` // by allowance type
+	Request           TaxCalculationRequest `gorm:"-" db:"request" json:"request"`
+	CreatedAt         time.Time             `json:"createdAt"`
 }
+
+// TaxCalculationRequest is the input of a calculation. TaxYear 0 selects the
+// rule set in effect on the day of the calculation; RuleSetVersion selects a
+// version of the tax year's rule set other than the latest. When Incomes breaks the
+// income down by category, TotalIncome is their sum and the expenses of each
+// category are deducted; a TotalIncome given alone has no expenses deducted.
+// Explain asks for the steps of the calculation in its response; it is not
+// part of the stored request.
+type TaxCalculationRequest struct {
+	TaxYear        int         `json:"taxYear,omitempty"`
+	RuleSetVersion int         `json:"ruleSetVersion,omitempty"`
+	TotalIncome    Money       `json:"totalIncome"`
+	WHT            Money       `json:"wht"`
+	Incomes        []Income    `json:"incomes,omitempty"`
+	Allowances     []Allowance `json:"allowances"`
+	Explain        bool        `json:"-"`
+}
+
+// TaxCalculationFilter selects a page of stored calculations. Zero values
+// leave a condition out. Cursor is the opaque value returned with the
+// previous page and is only valid with the same Sort and Order.
+type TaxCalculationFilter struct {
+	CreatedFrom *time.Time // inclusive
+	CreatedTo   *time.Time // exclusive
+	MinIncome   *Money
+	MaxIncome   *Money
+	Type        string // "payable", "refund" or ""
+	TaxYear     int
+	UploadID    uint
+	Sort        string // "createdAt", "totalIncome" or "tax"
+	Order       string // "asc" or "desc"
+	Cursor      string
+	Limit       int
+}
+
+const (
+	DefaultCalculationPageSize = 50
+	MaxCalculationPageSize     = 500
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Normalize fills in the default sort, order and page size and validates the filter.
+func (f *TaxCalculationFilter) Normalize() error {
+	switch f.Sort {
+	case "":
+		f.Sort = "createdAt"
+	case "createdAt", "totalIncome", "tax":
+	default:
+		return errors.New("sort must be createdAt, totalIncome or tax")
+	}
+	switch f.Order {
+	case "":
+		f.Order = "desc"
+	case "asc", "desc":
+	default:
+		return errors.New("order must be asc or desc")
+	}
+	switch f.Type {
+	case "", "payable", "refund":
+	default:
+		return errors.New("type must be payable or refund")
+	}
+	if f.Limit == 0 {
+		f.Limit = DefaultCalculationPageSize
+	}
+	if f.Limit < 0 || f.Limit > MaxCalculationPageSize {
+		return fmt.Errorf("limit must be between 1 and %d", MaxCalculationPageSize)
+	}
+	if f.MinIncome != nil && f.MaxIncome != nil && *f.MinIncome > *f.MaxIncome {
+		return errors.New("minIncome must not be greater than maxIncome")
+	}
+	if f.CreatedFrom != nil && f.CreatedTo != nil && !f.CreatedFrom.Before(*f.CreatedTo) {
+		return errors.New("from must be before to")
+	}
+	return nil
+}
+
+// TaxBracket is one band of the progressive schedule: income above Threshold
+// is taxed at Rate until the next bracket's threshold.
+type TaxBracket struct {
+	ID        uint  `json:"-" db:"id"`
+	Threshold Money `json:"threshold" db:"threshold"`
+	Rate      Rate  `json:"rate" db:"rate"`
+}
+
 type TaxRate struct {
-	Level string  `json:"level"`
-	Tax   float64 `json:"tax"`
+	Level string `json:"level"`
+	Tax   Money  `json:"tax"`
 }
 
+// Allowance is one claim of a calculation request. Allowance types claimed
+// per person, such as "child", are claimed with Count instead of Amount.
 type Allowance struct {
-	AllowanceType string  `json:"allowanceType"`
-	Amount        float64 `json:"amount"`
+	AllowanceType string `json:"allowanceType"`
+	Amount        Money  `json:"amount"`
+	Count         int    `json:"count,omitempty"`
+}
+
+// Validate checks the claim without the catalogue; whether the type exists
+// and is claimed the right way is up to the calculator.
+func (a Allowance) Validate() error {
+	if a.AllowanceType == "" {
+		return errors.New("allowanceType is required")
+	}
+	if a.Amount < 0 {
+		return fmt.Errorf("%s: amount must not be negative", a.AllowanceType)
+	}
+	if a.Count < 0 {
+		return fmt.Errorf("%s: count must not be negative", a.AllowanceType)
+	}
+	return nil
 }
 
+// TaxCalculationResponse carries the payable tax or refund. TaxLevel holds the
+// tax accrued in each bracket before the WHT credit, which is reported in WHT.
+// Explanation holds the steps of the calculation when the request asked for
+// them.
 type TaxCalculationResponse struct {
-	Tax       *float64  `json:"tax,omitempty"`
-	TaxRefund *float64  `json:"taxRefund,omitempty"`
-	TaxLevel  []TaxRate `json:"taxLevel,omitempty"`
+	Tax         *Money            `json:"tax,omitempty"`
+	TaxRefund   *Money            `json:"taxRefund,omitempty"`
+	WHT         Money             `json:"wht"`
+	TaxLevel    []TaxRate         `json:"taxLevel,omitempty"`
+	Explanation []CalculationStep `json:"explanation,omitempty"`
 }
 
 func (t *TaxCalculation) BeforeSave(tx *gorm.DB) (err error) {
@@ -0,0 +1,82 @@
+// model/withholding.go
+package model
+
+import "errors"
+
+// WithholdingRequest is one month of an employee's payroll (PND1). Salary is
+// paid in Month; YTDIncome and YTDWithheld are the salary paid and the tax
+// withheld in the earlier months of the tax year. Allowances are claimed for
+// the whole year.
+type WithholdingRequest struct {
+	EmployeeID  string      `json:"employeeId,omitempty"`
+	TaxYear     int         `json:"taxYear,omitempty"`
+	Month       int         `json:"month"`
+	Salary      Money       `json:"salary"`
+	YTDIncome   Money       `json:"ytdIncome"`
+	YTDWithheld Money       `json:"ytdWithheld"`
+	Allowances  []Allowance `json:"allowances"`
+}
+
+// WithholdingResult is the tax to withhold in a month. The annual tax is that
+// of the projected AnnualIncome; Withholding spreads what is left of it after
+// YTDWithheld over the months left, this one included. TrueUp is how much
+// Withholding differs from an even twelfth of the annual tax, positive when
+// earlier months withheld too little. Row is the line of a bulk upload.
+type WithholdingResult struct {
+	Row           int    `json:"row,omitempty"`
+	EmployeeID    string `json:"employeeId,omitempty"`
+	TaxYear       int    `json:"taxYear,omitempty"`
+	Month         int    `json:"month"`
+	Salary        Money  `json:"salary"`
+	AnnualIncome  Money  `json:"annualIncome"`
+	TaxableIncome Money  `json:"taxableIncome"`
+	AnnualTax     Money  `json:"annualTax"`
+	YTDWithheld   Money  `json:"ytdWithheld"`
+	Withholding   Money  `json:"withholding"`
+	TrueUp        Money  `json:"trueUp"`
+}
+
+// WithholdingRun holds the results of a bulk monthly upload and the errors of
+// the rows that were rejected, both in line order.
+type WithholdingRun struct {
+	Results []WithholdingResult `json:"results"`
+	Errors  []CSVRowError       `json:"errors,omitempty"`
+}
+
+func (r *WithholdingRequest) Validate() error {
+	if r.Month < 1 || r.Month > 12 {
+		return errors.New("month must be between 1 and 12")
+	}
+	if r.Salary < 0 || r.YTDIncome < 0 || r.YTDWithheld < 0 {
+		return errors.New("amounts must not be negative")
+	}
+	for _, allowance := range r.Allowances {
+		if err := allowance.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemainingMonths is the number of months left in the tax year, counting
+// Month itself.
+func (r *WithholdingRequest) RemainingMonths() int {
+	return 13 - r.Month
+}
+
+// AnnualIncome projects the salary of the year: the salary paid so far plus
+// this month's salary for every month left.
+func (r *WithholdingRequest) AnnualIncome() Money {
+	return r.YTDIncome + r.Salary*Money(r.RemainingMonths())
+}
+
+// Request returns the annual calculation request of the projected salary.
+func (r *WithholdingRequest) Request() TaxCalculationRequest {
+	income := r.AnnualIncome()
+	return TaxCalculationRequest{
+		TaxYear:     r.TaxYear,
+		TotalIncome: income,
+		Incomes:     []Income{{Category: IncomeSalary, Amount: income}},
+		Allowances:  r.Allowances,
+	}
+}
@@ -3,16 +3,37 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
 
 	"github.com/LGROW101/assessment-tax/model"
+	"github.com/lib/pq"
 )
 
+// ErrVersionConflict is returned when a rule set version is stored on top of
+// a version that is no longer the latest of its tax year.
+var ErrVersionConflict = errors.New("rule set was changed by another request")
+
 type AdminRepository interface {
-	GetConfig() (*model.AdminConfig, error)
-	UpdateConfig(config *model.AdminConfig) error
-	InsertConfig(config *model.AdminConfig) error // เพิ่มบร
+	// GetConfig returns the latest rule set version of the tax year, or the
+	// rule set in effect today when taxYear is 0.
+	GetConfig(taxYear int) (*model.AdminConfig, error)
+	// GetConfigVersion returns one version of the rule set of the tax year,
+	// or nil when there is no such version.
+	GetConfigVersion(taxYear, version int) (*model.AdminConfig, error)
+	ListConfigs(taxYear int) ([]*model.AdminConfig, error)
+	// InsertConfig stores the config and its brackets as the version after
+	// config.Version, which must be the latest version of its tax year (0 when
+	// it has none), with the audit entry of the change when it is not nil.
+	// A config without AllowanceTypes gets the current allowance catalogue.
+	// It returns ErrVersionConflict when another version was stored first.
+	InsertConfig(config *model.AdminConfig, audit *model.AuditEntry) error
 }
 
+// inEffect selects the rule set versions in effect today.
+const inEffect = `effective_from <= CURRENT_DATE AND (effective_to IS NULL OR effective_to >= CURRENT_DATE)`
+
 type adminRepository struct {
 	db *sql.DB
 }
@@ -21,40 +42,267 @@ func NewAdminRepository(db *sql.DB) AdminRepository {
 	return &adminRepository{db: db}
 }
 
-func (r *adminRepository) GetConfig() (*model.AdminConfig, error) {
-	query := `
-        SELECT id, personal_deduction, k_receipt, created_at, updated_at
+const adminConfigColumns = `id, tax_year, version, personal_deduction, k_receipt, donation_cap, effective_from, effective_to, allowance_types, created_at, updated_at`
+
+func (r *adminRepository) GetConfig(taxYear int) (*model.AdminConfig, error) {
+	var row *sql.Row
+	if taxYear == 0 {
+		query := `
+        SELECT ` + adminConfigColumns + `
         FROM admin_configs
-        ORDER BY id DESC
+        WHERE ` + inEffect + `
+        ORDER BY effective_from DESC, version DESC
         LIMIT 1
     `
-	row := r.db.QueryRow(query)
-	var config model.AdminConfig
-	err := row.Scan(&config.ID, &config.PersonalDeduction, &config.KReceipt, &config.CreatedAt, &config.UpdatedAt)
+		row = r.db.QueryRow(query)
+	} else {
+		query := `
+        SELECT ` + adminConfigColumns + `
+        FROM admin_configs
+        WHERE tax_year = $1
+        ORDER BY version DESC
+        LIMIT 1
+    `
+		row = r.db.QueryRow(query, taxYear)
+	}
+
+	config, err := scanAdminConfig(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
-	return &config, nil
+
+	config.Brackets, err = taxBrackets(r.db, config.ID)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (r *adminRepository) GetConfigVersion(taxYear, version int) (*model.AdminConfig, error) {
+	query := `
+        SELECT ` + adminConfigColumns + `
+        FROM admin_configs
+        WHERE tax_year = $1 AND version = $2
+    `
+	config, err := scanAdminConfig(r.db.QueryRow(query, taxYear, version))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	config.Brackets, err = taxBrackets(r.db, config.ID)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (r *adminRepository) ListConfigs(taxYear int) ([]*model.AdminConfig, error) {
+	query := `
+        SELECT ` + adminConfigColumns + `
+        FROM admin_configs
+        WHERE $1 = 0 OR tax_year = $1
+        ORDER BY tax_year DESC, version DESC
+    `
+	rows, err := r.db.Query(query, taxYear)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var configs []*model.AdminConfig
+	for rows.Next() {
+		config, err := scanAdminConfig(rows)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, config := range configs {
+		config.Brackets, err = taxBrackets(r.db, config.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return configs, nil
+}
+
+func (r *adminRepository) InsertConfig(config *model.AdminConfig, audit *model.AuditEntry) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertConfig(tx, config); err != nil {
+		return err
+	}
+
+	if audit != nil {
+		if err := insertAuditEntry(tx, audit, strconv.FormatUint(uint64(config.ID), 10), config); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func insertConfig(tx *sql.Tx, config *model.AdminConfig) error {
+	if config.AllowanceTypes == nil {
+		allowanceTypes, err := listAllowanceTypes(tx)
+		if err != nil {
+			return err
+		}
+		config.AllowanceTypes = append([]*model.AllowanceType{}, allowanceTypes...)
+	}
+	allowanceTypes, err := json.Marshal(config.AllowanceTypes)
+	if err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO admin_configs (tax_year, version, personal_deduction, k_receipt, donation_cap, effective_from, effective_to, allowance_types)
+        SELECT $1, $2 + 1, $3, $4, $5, $6, $7, $8
+        WHERE (SELECT COALESCE(MAX(version), 0) FROM admin_configs WHERE tax_year = $1) = $2
+        RETURNING id, version, created_at, updated_at
+    `
+	err = tx.QueryRow(query, config.TaxYear, config.Version, config.PersonalDeduction, config.KReceipt, config.DonationCap, config.EffectiveFrom, config.EffectiveTo, string(allowanceTypes)).
+		Scan(&config.ID, &config.Version, &config.CreatedAt, &config.UpdatedAt)
+	// No row means a newer version was already committed; a unique violation
+	// means one was committed while this insert ran.
+	var pqErr *pq.Error
+	if err == sql.ErrNoRows || errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return ErrVersionConflict
+	}
+	if err != nil {
+		return err
+	}
+
+	for i := range config.Brackets {
+		bracket := &config.Brackets[i]
+		err = tx.QueryRow(`
+        INSERT INTO tax_brackets (admin_config_id, threshold, rate)
+        VALUES ($1, $2, $3)
+        RETURNING id
+    `, config.ID, bracket.Threshold, bracket.Rate).Scan(&bracket.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (r *adminRepository) UpdateConfig(config *model.AdminConfig) error {
+// reissueRuleSets stores a new version of the latest rule set of every tax
+// year from the one in effect on, carrying the allowance catalogue as tx now
+// has it. Earlier tax years and versions keep the catalogue they had.
+func reissueRuleSets(tx *sql.Tx) error {
 	query := `
-        UPDATE admin_configs
-        SET personal_deduction = $1, k_receipt = $2, updated_at = NOW()
-        WHERE id = 1
+        SELECT DISTINCT ON (tax_year) ` + adminConfigColumns + `
+        FROM admin_configs
+        WHERE tax_year >= COALESCE(
+            (SELECT tax_year FROM admin_configs WHERE ` + inEffect + ` ORDER BY effective_from DESC, version DESC LIMIT 1),
+            EXTRACT(YEAR FROM CURRENT_DATE)::INTEGER
+        )
+        ORDER BY tax_year, version DESC
     `
-	_, err := r.db.Exec(query, config.PersonalDeduction, config.KReceipt)
-	return err
+	rows, err := tx.Query(query)
+	if err != nil {
+		return err
+	}
+	var configs []*model.AdminConfig
+	for rows.Next() {
+		config, err := scanAdminConfig(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		configs = append(configs, config)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, config := range configs {
+		if config.Brackets, err = taxBrackets(tx, config.ID); err != nil {
+			return err
+		}
+		config.AllowanceTypes = nil
+		if err := insertConfig(tx, config); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (r *adminRepository) InsertConfig(config *model.AdminConfig) error {
+// queryer is satisfied by both *sql.DB and *sql.Tx.
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+func taxBrackets(db queryer, configID uint) ([]model.TaxBracket, error) {
 	query := `
-        INSERT INTO admin_configs (personal_deduction, k_receipt)
-        VALUES ($1, $2)
+        SELECT id, threshold, rate
+        FROM tax_brackets
+        WHERE admin_config_id = $1
+        ORDER BY threshold
     `
-	_, err := r.db.Exec(query, config.PersonalDeduction, config.KReceipt)
-	return err
+	rows, err := db.Query(query, configID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var brackets []model.TaxBracket
+	for rows.Next() {
+		var bracket model.TaxBracket
+		if err := rows.Scan(&bracket.ID, &bracket.Threshold, &bracket.Rate); err != nil {
+			return nil, err
+		}
+		brackets = append(brackets, bracket)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return brackets, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAdminConfig(row rowScanner) (*model.AdminConfig, error) {
+	var config model.AdminConfig
+	var allowanceTypes []byte
+	err := row.Scan(
+		&config.ID,
+		&config.TaxYear,
+		&config.Version,
+		&config.PersonalDeduction,
+		&config.KReceipt,
+		&config.DonationCap,
+		&config.EffectiveFrom,
+		&config.EffectiveTo,
+		&allowanceTypes,
+		&config.CreatedAt,
+		&config.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(allowanceTypes, &config.AllowanceTypes); err != nil {
+		return nil, err
+	}
+	return &config, nil
 }
@@ -0,0 +1,302 @@
+// repository/allowance.go
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/lib/pq"
+)
+
+// ErrUnknownAllowanceGroup is returned when an allowance type is saved in a
+// group that does not exist.
+var ErrUnknownAllowanceGroup = errors.New("unknown allowance group")
+
+type AllowanceRepository interface {
+	ListAllowanceTypes() ([]*model.AllowanceType, error)
+	// GetAllowanceType returns nil when no allowance type has the key.
+	GetAllowanceType(key string) (*model.AllowanceType, error)
+	// SaveAllowanceType creates or replaces the allowance type with its key,
+	// storing the audit entry of the change in the same transaction.
+	//
+	// A change of the catalogue applies to the rule sets of the tax year in
+	// effect and later ones, which get a new version carrying it; earlier
+	// rule set versions keep the catalogue they had.
+	SaveAllowanceType(allowanceType *model.AllowanceType, audit *model.AuditEntry) error
+	DeleteAllowanceType(key string, audit *model.AuditEntry) error
+	ListAllowanceGroups() ([]*model.AllowanceGroup, error)
+	// GetAllowanceGroup returns nil when no allowance group has the key.
+	GetAllowanceGroup(key string) (*model.AllowanceGroup, error)
+	// SaveAllowanceGroup creates or replaces the allowance group with its key,
+	// storing the audit entry of the change in the same transaction.
+	SaveAllowanceGroup(group *model.AllowanceGroup, audit *model.AuditEntry) error
+}
+
+type allowanceRepository struct {
+	db *sql.DB
+}
+
+func NewAllowanceRepository(db *sql.DB) AllowanceRepository {
+	return &allowanceRepository{db: db}
+}
+
+// allowanceTypeColumns reads an allowance type with the cap of its group.
+const allowanceTypeColumns = `
+	t.key, t.display_name, t.cap_amount, t.cap_rate, t.unit_amount, t.max_units,
+	COALESCE(t.group_key, ''), g.cap_amount, t.stage, t.created_at, t.updated_at
+	FROM allowance_types t
+	LEFT JOIN allowance_groups g ON g.key = t.group_key`
+
+const allowanceGroupColumns = `key, display_name, cap_amount, created_at, updated_at`
+
+func (r *allowanceRepository) ListAllowanceTypes() ([]*model.AllowanceType, error) {
+	return listAllowanceTypes(r.db)
+}
+
+func listAllowanceTypes(db queryer) ([]*model.AllowanceType, error) {
+	query := `
+	SELECT ` + allowanceTypeColumns + `
+	ORDER BY t.key
+	`
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var allowanceTypes []*model.AllowanceType
+	for rows.Next() {
+		allowanceType, err := scanAllowanceType(rows)
+		if err != nil {
+			return nil, err
+		}
+		allowanceTypes = append(allowanceTypes, allowanceType)
+	}
+
+	return allowanceTypes, rows.Err()
+}
+
+func (r *allowanceRepository) GetAllowanceType(key string) (*model.AllowanceType, error) {
+	query := `
+	SELECT ` + allowanceTypeColumns + `
+	WHERE t.key = $1
+	`
+	allowanceType, err := scanAllowanceType(r.db.QueryRow(query, key))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return allowanceType, nil
+}
+
+func (r *allowanceRepository) SaveAllowanceType(allowanceType *model.AllowanceType, audit *model.AuditEntry) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO allowance_types (key, display_name, cap_amount, cap_rate, unit_amount, max_units, group_key, stage)
+	VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
+	ON CONFLICT (key) DO UPDATE
+	SET display_name = EXCLUDED.display_name,
+		cap_amount = EXCLUDED.cap_amount,
+		cap_rate = EXCLUDED.cap_rate,
+		unit_amount = EXCLUDED.unit_amount,
+		max_units = EXCLUDED.max_units,
+		group_key = EXCLUDED.group_key,
+		stage = EXCLUDED.stage,
+		updated_at = NOW ()
+	WHERE (allowance_types.display_name, allowance_types.cap_amount, allowance_types.cap_rate, allowance_types.unit_amount,
+		allowance_types.max_units, allowance_types.group_key, allowance_types.stage)
+		IS DISTINCT FROM (EXCLUDED.display_name, EXCLUDED.cap_amount, EXCLUDED.cap_rate, EXCLUDED.unit_amount,
+		EXCLUDED.max_units, EXCLUDED.group_key, EXCLUDED.stage)
+	RETURNING created_at, updated_at, (SELECT cap_amount FROM allowance_groups WHERE key = group_key)
+	`
+	err = tx.QueryRow(query, allowanceType.Key, allowanceType.DisplayName, allowanceType.CapAmount, allowanceType.CapRate,
+		allowanceType.UnitAmount, allowanceType.MaxUnits, allowanceType.Group, allowanceType.Stage).
+		Scan(&allowanceType.CreatedAt, &allowanceType.UpdatedAt, &allowanceType.GroupCap)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" { // foreign_key_violation
+		return ErrUnknownAllowanceGroup
+	}
+	// an allowance type saved as it was is left alone, and so are the rule sets
+	changed := err != sql.ErrNoRows
+	if !changed {
+		query := `
+		SELECT t.created_at, t.updated_at, g.cap_amount
+		FROM allowance_types t
+		LEFT JOIN allowance_groups g ON g.key = t.group_key
+		WHERE t.key = $1
+		`
+		err = tx.QueryRow(query, allowanceType.Key).
+			Scan(&allowanceType.CreatedAt, &allowanceType.UpdatedAt, &allowanceType.GroupCap)
+	}
+	if err != nil {
+		return err
+	}
+	if changed {
+		if err := reissueRuleSets(tx); err != nil {
+			return err
+		}
+	}
+
+	if audit != nil {
+		if err := insertAuditEntry(tx, audit, allowanceType.Key, allowanceType); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *allowanceRepository) DeleteAllowanceType(key string, audit *model.AuditEntry) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`DELETE FROM allowance_types WHERE key = $1`, key)
+	if err != nil {
+		return err
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		if err := reissueRuleSets(tx); err != nil {
+			return err
+		}
+	}
+
+	if audit != nil {
+		if err := insertAuditEntry(tx, audit, key, nil); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *allowanceRepository) ListAllowanceGroups() ([]*model.AllowanceGroup, error) {
+	query := `
+	SELECT ` + allowanceGroupColumns + `
+	FROM allowance_groups
+	ORDER BY key
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []*model.AllowanceGroup
+	for rows.Next() {
+		group, err := scanAllowanceGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, rows.Err()
+}
+
+func (r *allowanceRepository) GetAllowanceGroup(key string) (*model.AllowanceGroup, error) {
+	query := `
+	SELECT ` + allowanceGroupColumns + `
+	FROM allowance_groups
+	WHERE key = $1
+	`
+	group, err := scanAllowanceGroup(r.db.QueryRow(query, key))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return group, nil
+}
+
+func (r *allowanceRepository) SaveAllowanceGroup(group *model.AllowanceGroup, audit *model.AuditEntry) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// previous sees the group as it was before the upsert
+	query := `
+	WITH previous AS (SELECT cap_amount FROM allowance_groups WHERE key = $1)
+	INSERT INTO allowance_groups (key, display_name, cap_amount)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (key) DO UPDATE
+	SET display_name = EXCLUDED.display_name,
+		cap_amount = EXCLUDED.cap_amount,
+		updated_at = NOW ()
+	RETURNING created_at, updated_at, (SELECT cap_amount FROM previous)
+	`
+	var previousCap *model.Money
+	err = tx.QueryRow(query, group.Key, group.DisplayName, group.CapAmount).
+		Scan(&group.CreatedAt, &group.UpdatedAt, &previousCap)
+	if err != nil {
+		return err
+	}
+	// the catalogue only carries the cap of a group, which a new group has
+	// no allowance types to apply to yet
+	if previousCap != nil && *previousCap != group.CapAmount {
+		if err := reissueRuleSets(tx); err != nil {
+			return err
+		}
+	}
+
+	if audit != nil {
+		if err := insertAuditEntry(tx, audit, group.Key, group); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func scanAllowanceType(row rowScanner) (*model.AllowanceType, error) {
+	var allowanceType model.AllowanceType
+	err := row.Scan(
+		&allowanceType.Key,
+		&allowanceType.DisplayName,
+		&allowanceType.CapAmount,
+		&allowanceType.CapRate,
+		&allowanceType.UnitAmount,
+		&allowanceType.MaxUnits,
+		&allowanceType.Group,
+		&allowanceType.GroupCap,
+		&allowanceType.Stage,
+		&allowanceType.CreatedAt,
+		&allowanceType.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &allowanceType, nil
+}
+
+func scanAllowanceGroup(row rowScanner) (*model.AllowanceGroup, error) {
+	var group model.AllowanceGroup
+	err := row.Scan(
+		&group.Key,
+		&group.DisplayName,
+		&group.CapAmount,
+		&group.CreatedAt,
+		&group.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
@@ -0,0 +1,140 @@
+// repository/audit.go
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/LGROW101/assessment-tax/model"
+)
+
+type AuditRepository interface {
+	// ListEntries returns one page of entries, newest first, and the cursor of
+	// the next page, which is empty on the last page.
+	ListEntries(filter model.AuditFilter) ([]*model.AuditEntry, string, error)
+}
+
+type auditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+const auditColumns = `id, actor, action, resource, resource_id, old_value, new_value, client_ip, request_id, created_at`
+
+// insertAuditEntry stores the entry of a change to the resource with the ID,
+// whose state after the change is newValue. It runs in the transaction of the
+// change so a change is never stored without its entry.
+func insertAuditEntry(db queryRower, entry *model.AuditEntry, resourceID string, newValue any) error {
+	value, err := json.Marshal(newValue)
+	if err != nil {
+		return err
+	}
+	entry.ResourceID = resourceID
+	entry.NewValue = value
+	if entry.OldValue == nil {
+		entry.OldValue = json.RawMessage("null")
+	}
+
+	query := `
+	INSERT INTO admin_audit (actor, action, resource, resource_id, old_value, new_value, client_ip, request_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING id, created_at
+	`
+	return db.QueryRow(query, entry.Actor, entry.Action, entry.Resource, entry.ResourceID,
+		string(entry.OldValue), string(entry.NewValue), entry.ClientIP, entry.RequestID).
+		Scan(&entry.ID, &entry.CreatedAt)
+}
+
+func (r *auditRepository) ListEntries(filter model.AuditFilter) ([]*model.AuditEntry, string, error) {
+	if err := filter.Normalize(); err != nil {
+		return nil, "", err
+	}
+
+	var conditions []string
+	var args []any
+	where := func(format string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+	if filter.Actor != "" {
+		where("actor = $%d", filter.Actor)
+	}
+	if filter.Action != "" {
+		where("action = $%d", filter.Action)
+	}
+	if filter.Resource != "" {
+		where("resource = $%d", filter.Resource)
+	}
+	if filter.RequestID != "" {
+		where("request_id = $%d", filter.RequestID)
+	}
+	if filter.From != nil {
+		where("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		where("created_at < $%d", *filter.To)
+	}
+	if filter.Cursor != 0 {
+		where("id < $%d", filter.Cursor)
+	}
+
+	query := `SELECT ` + auditColumns + ` FROM admin_audit`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit+1)
+	query += fmt.Sprintf(` ORDER BY id DESC LIMIT $%d`, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var entries []*model.AuditEntry
+	for rows.Next() {
+		var entry model.AuditEntry
+		var oldValue, newValue []byte
+		err := rows.Scan(
+			&entry.ID,
+			&entry.Actor,
+			&entry.Action,
+			&entry.Resource,
+			&entry.ResourceID,
+			&oldValue,
+			&newValue,
+			&entry.ClientIP,
+			&entry.RequestID,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, "", err
+		}
+		entry.OldValue = jsonOrNull(oldValue)
+		entry.NewValue = jsonOrNull(newValue)
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(entries) > filter.Limit {
+		entries = entries[:filter.Limit]
+		nextCursor = strconv.FormatUint(uint64(entries[len(entries)-1].ID), 10)
+	}
+	return entries, nextCursor, nil
+}
+
+func jsonOrNull(value []byte) json.RawMessage {
+	if value == nil {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(value)
+}
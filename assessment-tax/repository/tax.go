@@ -3,13 +3,23 @@ package repository
 
 import (
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/LGROW101/assessment-tax/model"
 )
 
 type TaxRepository interface {
+	// Save stores the calculation and sets its ID and CreatedAt.
 	Save(tax *model.TaxCalculation) error
-	GetAllCalculations() ([]*model.TaxCalculation, error)
+	// GetAllCalculations returns one page of calculations matching the filter
+	// and the cursor of the next page, which is empty on the last page.
+	GetAllCalculations(filter model.TaxCalculationFilter) ([]*model.TaxCalculation, string, error)
+	// GetCalculation returns nil when no calculation has the ID.
+	GetCalculation(id uint) (*model.TaxCalculation, error)
 }
 
 type taxRepository struct {
@@ -21,79 +31,332 @@ func NewTaxRepository(db *sql.DB) TaxRepository {
 }
 
 func (r *taxRepository) Save(tax *model.TaxCalculation) error {
+	return insertCalculation(r.db, tax)
+}
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx.
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func insertCalculation(db queryRower, tax *model.TaxCalculation) error {
+	allowances, err := json.Marshal(tax.Allowances)
+	if err != nil {
+		return err
+	}
+	taxLevel, err := json.Marshal(tax.TaxLevel)
+	if err != nil {
+		return err
+	}
+	deductions, err := json.Marshal(tax.Deductions)
+	if err != nil {
+		return err
+	}
+	request, err := json.Marshal(tax.Request)
+	if err != nil {
+		return err
+	}
+
+	var ruleSetID, uploadID, uploadRow sql.NullInt64
+	if tax.RuleSetID != 0 {
+		ruleSetID = sql.NullInt64{Int64: int64(tax.RuleSetID), Valid: true}
+	}
+	if tax.UploadID != 0 {
+		uploadID = sql.NullInt64{Int64: int64(tax.UploadID), Valid: true}
+		uploadRow = sql.NullInt64{Int64: int64(tax.UploadRow), Valid: true}
+	}
 
 	query := `
 	INSERT INTO tax_calculations (
+		tax_year,
+		admin_config_id,
+		upload_id,
+		upload_row,
 		totalIncome,
 		wht,
+		expenses,
 		personal_allowance,
 		donation,
 		k_receipt,
-		tax
-	) VALUES ($1, $2, $3, $4, $5, $6)
+		taxable_income,
+		tax,
+		tax_payable,
+		tax_refund,
+		allowances,
+		tax_level,
+		request,
+		deductions
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+	RETURNING id, created_at
 	`
 
-	_, err := r.db.Exec(
+	// JSONB parameters are sent as text; lib/pq would encode []byte as bytea.
+	return db.QueryRow(
 		query,
+		tax.TaxYear,
+		ruleSetID,
+		uploadID,
+		uploadRow,
 		tax.TotalIncome,
 		tax.WHT,
+		tax.Expenses,
 		tax.PersonalAllowance,
 		tax.Donation,
 		tax.KReceipt,
+		tax.TaxableIncome,
 		tax.Tax,
-	)
+		tax.TaxPayable,
+		tax.TaxRefund,
+		string(allowances),
+		string(taxLevel),
+		string(request),
+		string(deductions),
+	).Scan(&tax.ID, &tax.CreatedAt)
+}
+
+const taxCalculationColumns = `
+		tc.id,
+		COALESCE(tc.tax_year, 0),
+		COALESCE(tc.admin_config_id, 0),
+		COALESCE(ac.version, 0),
+		COALESCE(tc.upload_id, 0),
+		COALESCE(tc.upload_row, 0),
+		tc.totalIncome,
+		tc.wht,
+		tc.expenses,
+		tc.personal_allowance,
+		tc.donation,
+		tc.k_receipt,
+		tc.taxable_income,
+		tc.tax,
+		tc.tax_payable,
+		tc.tax_refund,
+		tc.allowances,
+		tc.tax_level,
+		tc.request,
+		tc.deductions,
+		tc.created_at
+	FROM
+		tax_calculations tc
+		LEFT JOIN admin_configs ac ON ac.id = tc.admin_config_id`
 
-	return err
+// calculationSortColumns maps a sort key to its column and the Postgres type
+// the cursor value is cast to.
+var calculationSortColumns = map[string]struct{ column, cast string }{
+	"createdAt":   {"tc.created_at", "timestamp"},
+	"totalIncome": {"tc.totalIncome", "numeric"},
+	"tax":         {"tc.tax", "numeric"},
 }
 
-func (r *taxRepository) GetAllCalculations() ([]*model.TaxCalculation, error) {
+// cursorTimeLayout formats a created_at cursor. The column is a TIMESTAMP
+// without time zone, so the cursor carries its wall clock as read and is
+// compared as such, whatever the TimeZone of the session.
+const cursorTimeLayout = "2006-01-02T15:04:05.999999"
 
-	var taxCalculations []*model.TaxCalculation
+type calculationCursor struct {
+	Sort  string `json:"s"`
+	Order string `json:"o"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+func (r *taxRepository) GetAllCalculations(filter model.TaxCalculationFilter) ([]*model.TaxCalculation, string, error) {
+	if err := filter.Normalize(); err != nil {
+		return nil, "", err
+	}
+	sort := calculationSortColumns[filter.Sort]
+
+	var conditions []string
+	var args []any
+	where := func(condition string, values ...any) {
+		placeholders := make([]any, len(values))
+		for i, value := range values {
+			args = append(args, value)
+			placeholders[i] = len(args)
+		}
+		conditions = append(conditions, fmt.Sprintf(condition, placeholders...))
+	}
+
+	if filter.CreatedFrom != nil {
+		where("tc.created_at >= $%d", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		where("tc.created_at < $%d", *filter.CreatedTo)
+	}
+	if filter.MinIncome != nil {
+		where("tc.totalIncome >= $%d", *filter.MinIncome)
+	}
+	if filter.MaxIncome != nil {
+		where("tc.totalIncome <= $%d", *filter.MaxIncome)
+	}
+	switch filter.Type {
+	case "payable":
+		conditions = append(conditions, "tc.tax_payable > 0")
+	case "refund":
+		conditions = append(conditions, "tc.tax_refund > 0")
+	}
+	if filter.TaxYear != 0 {
+		where("tc.tax_year = $%d", filter.TaxYear)
+	}
+	if filter.UploadID != 0 {
+		where("tc.upload_id = $%d", filter.UploadID)
+	}
+	if filter.Cursor != "" {
+		cursor, err := decodeCalculationCursor(filter.Cursor)
+		if err != nil || cursor.Sort != filter.Sort || cursor.Order != filter.Order {
+			return nil, "", model.ErrInvalidCursor
+		}
+		comparison := "<"
+		if filter.Order == "asc" {
+			comparison = ">"
+		}
+		where("("+sort.column+", tc.id) "+comparison+" ($%d::"+sort.cast+", $%d)", cursor.Value, cursor.ID)
+	}
 
 	query := `
-		SELECT
-			id,
-			totalIncome,
-			wht,
-			personal_allowance,
-			donation,
-			k_receipt,
-			tax,
-			created_at
-		FROM
-			tax_calculations
-	`
+	SELECT` + taxCalculationColumns
+	if len(conditions) > 0 {
+		query += `
+	WHERE
+		` + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit+1)
+	query += fmt.Sprintf(`
+	ORDER BY
+		%[1]s %[2]s, tc.id %[2]s
+	LIMIT $%[3]d
+	`, sort.column, strings.ToUpper(filter.Order), len(args))
 
-	rows, err := r.db.Query(query)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 	defer rows.Close()
 
+	var taxCalculations []*model.TaxCalculation
 	for rows.Next() {
-
-		var taxCalculation model.TaxCalculation
-
-		err := rows.Scan(
-			&taxCalculation.ID,
-			&taxCalculation.TotalIncome,
-			&taxCalculation.WHT,
-			&taxCalculation.PersonalAllowance,
-			&taxCalculation.Donation,
-			&taxCalculation.KReceipt,
-			&taxCalculation.Tax,
-			&taxCalculation.CreatedAt,
-		)
+		taxCalculation, err := scanTaxCalculation(rows)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 
-		taxCalculations = append(taxCalculations, &taxCalculation)
+		taxCalculations = append(taxCalculations, taxCalculation)
 	}
 
 	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	// one row past the page tells whether there is a next page
+	if len(taxCalculations) <= filter.Limit {
+		return taxCalculations, "", nil
+	}
+	taxCalculations = taxCalculations[:filter.Limit]
+
+	last := taxCalculations[len(taxCalculations)-1]
+	cursor := calculationCursor{Sort: filter.Sort, Order: filter.Order, ID: last.ID}
+	switch filter.Sort {
+	case "createdAt":
+		cursor.Value = last.CreatedAt.Format(cursorTimeLayout)
+	case "totalIncome":
+		cursor.Value = last.TotalIncome.String()
+	case "tax":
+		cursor.Value = last.Tax.String()
+	}
+	nextCursor, err := encodeCalculationCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return taxCalculations, nextCursor, nil
+}
+
+func (r *taxRepository) GetCalculation(id uint) (*model.TaxCalculation, error) {
+	query := `
+	SELECT` + taxCalculationColumns + `
+	WHERE
+		tc.id = $1
+	`
+
+	taxCalculation, err := scanTaxCalculation(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
 		return nil, err
 	}
+	return taxCalculation, nil
+}
 
-	return taxCalculations, nil
+func scanTaxCalculation(row rowScanner) (*model.TaxCalculation, error) {
+	var taxCalculation model.TaxCalculation
+	var allowances, taxLevel, request, deductions []byte
+
+	err := row.Scan(
+		&taxCalculation.ID,
+		&taxCalculation.TaxYear,
+		&taxCalculation.RuleSetID,
+		&taxCalculation.RuleSetVersion,
+		&taxCalculation.UploadID,
+		&taxCalculation.UploadRow,
+		&taxCalculation.TotalIncome,
+		&taxCalculation.WHT,
+		&taxCalculation.Expenses,
+		&taxCalculation.PersonalAllowance,
+		&taxCalculation.Donation,
+		&taxCalculation.KReceipt,
+		&taxCalculation.TaxableIncome,
+		&taxCalculation.Tax,
+		&taxCalculation.TaxPayable,
+		&taxCalculation.TaxRefund,
+		&allowances,
+		&taxLevel,
+		&request,
+		&deductions,
+		&taxCalculation.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(allowances, &taxCalculation.Allowances); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(taxLevel, &taxCalculation.TaxLevel); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(request, &taxCalculation.Request); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(deductions, &taxCalculation.Deductions); err != nil {
+		return nil, err
+	}
+
+	return &taxCalculation, nil
+}
+
+func encodeCalculationCursor(cursor calculationCursor) (string, error) {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCalculationCursor(value string) (calculationCursor, error) {
+	var cursor calculationCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, err
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, err
+	}
+	// the value is cast in SQL; reject anything that would fail there
+	if cursor.Sort == "createdAt" {
+		_, err = time.Parse(cursorTimeLayout, cursor.Value)
+	} else {
+		_, err = model.ParseMoney(cursor.Value)
+	}
+	return cursor, err
 }
@@ -0,0 +1,271 @@
+// upload
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/LGROW101/assessment-tax/model"
+)
+
+// ErrUploadNotRunning is returned when a worker writes to an upload it no
+// longer holds, e.g. because it was requeued as stale and claimed again.
+var ErrUploadNotRunning = errors.New("upload is not running")
+
+type UploadRepository interface {
+	// CreateUpload stores a queued upload with the content of its file.
+	CreateUpload(upload *model.TaxUpload, content []byte) error
+	// GetUpload returns nil when no upload has the ID.
+	GetUpload(id uint) (*model.TaxUpload, error)
+	// ClaimUpload marks the oldest queued upload as running under a new
+	// Attempt and returns it with its content, or nil when none is queued.
+	// Concurrent callers never claim the same upload.
+	ClaimUpload() (*model.TaxUpload, []byte, error)
+	// ReleaseUpload puts a running upload back in the queue.
+	ReleaseUpload(upload *model.TaxUpload) error
+	// RequeueStaleUploads puts back running uploads that have not reported
+	// progress within timeout, such as those of a server that was stopped.
+	RequeueStaleUploads(timeout time.Duration) (int64, error)
+	UpdateProgress(upload *model.TaxUpload) error
+	FailUpload(upload *model.TaxUpload, reason string) error
+	// ReleaseUpload, UpdateProgress, FailUpload and the result writer's
+	// Complete only apply to the attempt of the upload that was claimed; they
+	// return ErrUploadNotRunning once another worker holds it.
+	// BeginResults starts the transaction that stores the calculations of a
+	// running upload and completes it.
+	BeginResults(upload *model.TaxUpload) (UploadResultWriter, error)
+	// EachResult calls fn with the calculations of the upload in row order.
+	EachResult(id uint, fn func(*model.TaxCalculation) error) error
+}
+
+// UploadResultWriter stores the calculations of one upload in a single
+// transaction; nothing is visible until Complete commits.
+type UploadResultWriter interface {
+	Insert(tax *model.TaxCalculation) error
+	// Complete records the final counts and row errors of the upload and commits.
+	Complete() error
+	Rollback() error
+}
+
+type uploadRepository struct {
+	db *sql.DB
+}
+
+func NewUploadRepository(db *sql.DB) UploadRepository {
+	return &uploadRepository{db: db}
+}
+
+const uploadColumns = `id, filename, status, row_count, processed_count, error_count, errors, error, created_at, updated_at, finished_at, attempt`
+
+func (r *uploadRepository) CreateUpload(upload *model.TaxUpload, content []byte) error {
+	query := `
+	INSERT INTO tax_uploads (filename, status, content)
+	VALUES ($1, $2, $3)
+	RETURNING id, created_at, updated_at
+	`
+	upload.Status = model.UploadQueued
+	return r.db.QueryRow(query, upload.Filename, upload.Status, content).
+		Scan(&upload.ID, &upload.CreatedAt, &upload.UpdatedAt)
+}
+
+func (r *uploadRepository) GetUpload(id uint) (*model.TaxUpload, error) {
+	query := `
+	SELECT ` + uploadColumns + `
+	FROM tax_uploads
+	WHERE id = $1
+	`
+	upload, err := scanUpload(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return upload, nil
+}
+
+func (r *uploadRepository) ClaimUpload() (*model.TaxUpload, []byte, error) {
+	query := `
+	UPDATE tax_uploads
+	SET status = 'running', processed_count = 0, error_count = 0, attempt = attempt + 1, updated_at = NOW()
+	WHERE id = (
+		SELECT id
+		FROM tax_uploads
+		WHERE status = 'queued'
+		ORDER BY id
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING ` + uploadColumns + `, content
+	`
+	var content []byte
+	upload, err := scanUpload(r.db.QueryRow(query), &content)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	return upload, content, nil
+}
+
+func (r *uploadRepository) ReleaseUpload(upload *model.TaxUpload) error {
+	query := `
+	UPDATE tax_uploads
+	SET status = 'queued', updated_at = NOW()
+	WHERE id = $1 AND status = 'running' AND attempt = $2
+	`
+	return r.execHeld(query, upload.ID, upload.Attempt)
+}
+
+func (r *uploadRepository) RequeueStaleUploads(timeout time.Duration) (int64, error) {
+	query := `
+	UPDATE tax_uploads
+	SET status = 'queued', updated_at = NOW()
+	WHERE status = 'running' AND updated_at < NOW() - $1 * INTERVAL '1 second'
+	`
+	result, err := r.db.Exec(query, int64(timeout/time.Second))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *uploadRepository) UpdateProgress(upload *model.TaxUpload) error {
+	query := `
+	UPDATE tax_uploads
+	SET processed_count = $3, error_count = $4, updated_at = NOW()
+	WHERE id = $1 AND status = 'running' AND attempt = $2
+	`
+	return r.execHeld(query, upload.ID, upload.Attempt, upload.ProcessedCount, upload.ErrorCount)
+}
+
+func (r *uploadRepository) FailUpload(upload *model.TaxUpload, reason string) error {
+	query := `
+	UPDATE tax_uploads
+	SET status = 'failed', error = $3, content = NULL, updated_at = NOW(), finished_at = NOW()
+	WHERE id = $1 AND status = 'running' AND attempt = $2
+	`
+	return r.execHeld(query, upload.ID, upload.Attempt, reason)
+}
+
+// execHeld runs an update of a running upload, which changes nothing once
+// the attempt of the caller is no longer the one holding it.
+func (r *uploadRepository) execHeld(query string, args ...any) error {
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrUploadNotRunning
+	}
+	return nil
+}
+
+func (r *uploadRepository) BeginResults(upload *model.TaxUpload) (UploadResultWriter, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &uploadResultWriter{tx: tx, upload: upload}, nil
+}
+
+func (r *uploadRepository) EachResult(id uint, fn func(*model.TaxCalculation) error) error {
+	query := `
+	SELECT` + taxCalculationColumns + `
+	WHERE
+		tc.upload_id = $1
+	ORDER BY
+		tc.upload_row
+	`
+	rows, err := r.db.Query(query, id)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		taxCalculation, err := scanTaxCalculation(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(taxCalculation); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+type uploadResultWriter struct {
+	tx     *sql.Tx
+	upload *model.TaxUpload
+}
+
+func (w *uploadResultWriter) Insert(tax *model.TaxCalculation) error {
+	tax.UploadID = w.upload.ID
+	return insertCalculation(w.tx, tax)
+}
+
+func (w *uploadResultWriter) Complete() error {
+	errs, err := json.Marshal(w.upload.Errors)
+	if err != nil {
+		return err
+	}
+
+	query := `
+	UPDATE tax_uploads
+	SET status = 'completed', row_count = $2, processed_count = $2, error_count = $3, errors = $4,
+		content = NULL, updated_at = NOW(), finished_at = NOW()
+	WHERE id = $1 AND status = 'running' AND attempt = $5
+	RETURNING status, updated_at, finished_at
+	`
+	err = w.tx.QueryRow(query, w.upload.ID, w.upload.RowCount, w.upload.ErrorCount, string(errs), w.upload.Attempt).
+		Scan(&w.upload.Status, &w.upload.UpdatedAt, &w.upload.FinishedAt)
+	if err != nil {
+		w.tx.Rollback()
+		if err == sql.ErrNoRows {
+			return ErrUploadNotRunning
+		}
+		return err
+	}
+	w.upload.ProcessedCount = w.upload.RowCount
+
+	return w.tx.Commit()
+}
+
+func (w *uploadResultWriter) Rollback() error {
+	return w.tx.Rollback()
+}
+
+func scanUpload(row rowScanner, extra ...any) (*model.TaxUpload, error) {
+	var upload model.TaxUpload
+	var errs []byte
+	dest := append([]any{
+		&upload.ID,
+		&upload.Filename,
+		&upload.Status,
+		&upload.RowCount,
+		&upload.ProcessedCount,
+		&upload.ErrorCount,
+		&errs,
+		&upload.Error,
+		&upload.CreatedAt,
+		&upload.UpdatedAt,
+		&upload.FinishedAt,
+		&upload.Attempt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(errs, &upload.Errors); err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
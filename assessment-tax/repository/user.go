@@ -0,0 +1,145 @@
+// repository/user.go
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/lib/pq"
+)
+
+// ErrUsernameTaken is returned when a user is created with the username of
+// another user.
+var ErrUsernameTaken = errors.New("username is taken")
+
+type UserRepository interface {
+	// GetUserByUsername returns nil when no user has the username.
+	GetUserByUsername(username string) (*model.AdminUser, error)
+	ListUsers() ([]*model.AdminUser, error)
+	// CreateUser stores the user with the audit entry of its creation when the
+	// entry is not nil.
+	CreateUser(user *model.AdminUser, audit *model.AuditEntry) error
+	// RevokeToken records that the token with the ID may no longer be used.
+	// It is kept until expiresAt, after which the token is refused anyway.
+	RevokeToken(jti string, expiresAt time.Time) error
+	IsTokenRevoked(jti string) (bool, error)
+}
+
+type userRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+const adminUserColumns = `id, username, password_hash, role, created_at, updated_at`
+
+func (r *userRepository) GetUserByUsername(username string) (*model.AdminUser, error) {
+	query := `
+	SELECT ` + adminUserColumns + `
+	FROM admin_users
+	WHERE username = $1
+	`
+	user, err := scanAdminUser(r.db.QueryRow(query, username))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (r *userRepository) ListUsers() ([]*model.AdminUser, error) {
+	query := `
+	SELECT ` + adminUserColumns + `
+	FROM admin_users
+	ORDER BY id
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*model.AdminUser
+	for rows.Next() {
+		user, err := scanAdminUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (r *userRepository) CreateUser(user *model.AdminUser, audit *model.AuditEntry) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+	INSERT INTO admin_users (username, password_hash, role)
+	VALUES ($1, $2, $3)
+	RETURNING id, created_at, updated_at
+	`
+	err = tx.QueryRow(query, user.Username, user.PasswordHash, user.Role).
+		Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" { // unique_violation
+		return ErrUsernameTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	if audit != nil {
+		if err := insertAuditEntry(tx, audit, strconv.FormatUint(uint64(user.ID), 10), user); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *userRepository) RevokeToken(jti string, expiresAt time.Time) error {
+	query := `
+	INSERT INTO revoked_tokens (jti, expires_at)
+	VALUES ($1, $2)
+	ON CONFLICT (jti) DO NOTHING
+	`
+	_, err := r.db.Exec(query, jti, expiresAt)
+	return err
+}
+
+func (r *userRepository) IsTokenRevoked(jti string) (bool, error) {
+	query := `
+	SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)
+	`
+	var revoked bool
+	err := r.db.QueryRow(query, jti).Scan(&revoked)
+	return revoked, err
+}
+
+func scanAdminUser(row rowScanner) (*model.AdminUser, error) {
+	var user model.AdminUser
+	err := row.Scan(
+		&user.ID,
+		&user.Username,
+		&user.PasswordHash,
+		&user.Role,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
@@ -2,13 +2,23 @@
 package service
 
 import (
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/LGROW101/assessment-tax/model"
 	"github.com/LGROW101/assessment-tax/repository"
 )
 
+var (
+	ErrRuleSetNotFound    = errors.New("no tax rule set is configured for the tax year")
+	ErrRuleSetUnavailable = errors.New("the tax rule set cannot be used")
+)
+
 type AdminServiceInterface interface {
-	GetConfig() (*model.AdminConfig, error)
-	UpdateConfig(config *model.AdminConfig) error
+	GetConfig(taxYear int) (*model.AdminConfig, error)
+	GetRuleSet(taxYear int) (*model.AdminConfig, TaxBracketSchedule, error)
+	GetRuleSetVersion(taxYear, version int) (*model.AdminConfig, TaxBracketSchedule, error)
 }
 
 type AdminService struct {
@@ -21,10 +31,42 @@ func NewAdminService(adminRepo repository.AdminRepository) AdminServiceInterface
 	}
 }
 
-func (s *AdminService) GetConfig() (*model.AdminConfig, error) {
-	return s.adminRepo.GetConfig()
+func (s *AdminService) GetConfig(taxYear int) (*model.AdminConfig, error) {
+	return s.adminRepo.GetConfig(taxYear)
+}
+
+// GetRuleSet returns the rule set of the tax year (0 for the one in effect
+// today) together with its bracket schedule. While no rule set is in effect
+// today the statutory default of the current year is used.
+func (s *AdminService) GetRuleSet(taxYear int) (*model.AdminConfig, TaxBracketSchedule, error) {
+	config, err := s.adminRepo.GetConfig(taxYear)
+	if err != nil {
+		return nil, TaxBracketSchedule{}, err
+	}
+	if config == nil && taxYear == 0 {
+		config = model.DefaultRuleSet(time.Now().Year())
+	}
+	return ruleSetSchedule(config)
 }
 
-func (s *AdminService) UpdateConfig(config *model.AdminConfig) error {
-	return s.adminRepo.UpdateConfig(config)
+// GetRuleSetVersion returns one version of the rule set of the tax year,
+// which need not be the latest, together with its bracket schedule.
+func (s *AdminService) GetRuleSetVersion(taxYear, version int) (*model.AdminConfig, TaxBracketSchedule, error) {
+	config, err := s.adminRepo.GetConfigVersion(taxYear, version)
+	if err != nil {
+		return nil, TaxBracketSchedule{}, err
+	}
+	return ruleSetSchedule(config)
+}
+
+func ruleSetSchedule(config *model.AdminConfig) (*model.AdminConfig, TaxBracketSchedule, error) {
+	if config == nil {
+		return nil, TaxBracketSchedule{}, ErrRuleSetNotFound
+	}
+
+	schedule, err := NewTaxBracketSchedule(config.Brackets)
+	if err != nil {
+		return nil, TaxBracketSchedule{}, fmt.Errorf("%w: %w", ErrRuleSetUnavailable, err)
+	}
+	return config, schedule, nil
 }
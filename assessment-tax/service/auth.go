@@ -0,0 +1,166 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/repository"
+	"github.com/golang-jwt/jwt"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+)
+
+// AuthClaims are the claims of an admin token. The token ID (jti) is what a
+// logout revokes.
+type AuthClaims struct {
+	Role string `json:"role"`
+	jwt.StandardClaims
+}
+
+type AuthService interface {
+	// Login checks the password of the user and issues a signed token.
+	Login(req model.LoginRequest) (*model.LoginResponse, error)
+	// Authenticate verifies a token and returns its claims. Expired, revoked
+	// and badly signed tokens are ErrInvalidToken.
+	Authenticate(token string) (*AuthClaims, error)
+	// Logout revokes the token of the claims.
+	Logout(claims *AuthClaims) error
+	ListUsers() ([]*model.AdminUser, error)
+	// CreateUser stores a new user and the audit entry of its creation.
+	CreateUser(req model.AdminUserRequest, audit *model.AuditEntry) (*model.AdminUser, error)
+	// EnsureSuperAdmin creates the super-admin with the password unless a
+	// user of that name exists, so a new database can be administered.
+	EnsureSuperAdmin(username, password string) error
+}
+
+type authService struct {
+	userRepo repository.UserRepository
+	secret   []byte
+	tokenTTL time.Duration
+}
+
+// NewAuthService returns an AuthService that signs tokens with secret (HS256)
+// valid for tokenTTL.
+func NewAuthService(userRepo repository.UserRepository, secret string, tokenTTL time.Duration) AuthService {
+	return &authService{
+		userRepo: userRepo,
+		secret:   []byte(secret),
+		tokenTTL: tokenTTL,
+	}
+}
+
+func (s *authService) Login(req model.LoginRequest) (*model.LoginResponse, error) {
+	user, err := s.userRepo.GetUserByUsername(req.Username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		// compare anyway so an unknown username takes as long as a wrong password
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(req.Password))
+		return nil, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	jti, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	expiresAt := now.Add(s.tokenTTL)
+	claims := AuthClaims{
+		Role: user.Role,
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			Subject:   user.Username,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: expiresAt.Unix(),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.LoginResponse{
+		Token:     token,
+		TokenType: "Bearer",
+		ExpiresAt: time.Unix(expiresAt.Unix(), 0).UTC(),
+		Role:      user.Role,
+	}, nil
+}
+
+func (s *authService) Authenticate(tokenString string) (*AuthClaims, error) {
+	claims := &AuthClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return s.secret, nil
+	})
+	if err != nil || !token.Valid || claims.Id == "" || !model.ValidRole(claims.Role) {
+		return nil, ErrInvalidToken
+	}
+
+	revoked, err := s.userRepo.IsTokenRevoked(claims.Id)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+func (s *authService) Logout(claims *AuthClaims) error {
+	return s.userRepo.RevokeToken(claims.Id, time.Unix(claims.ExpiresAt, 0))
+}
+
+func (s *authService) ListUsers() ([]*model.AdminUser, error) {
+	return s.userRepo.ListUsers()
+}
+
+func (s *authService) CreateUser(req model.AdminUserRequest, audit *model.AuditEntry) (*model.AdminUser, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	user := &model.AdminUser{Username: req.Username, PasswordHash: string(hash), Role: req.Role}
+	if err := s.userRepo.CreateUser(user, audit); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *authService) EnsureSuperAdmin(username, password string) error {
+	user, err := s.userRepo.GetUserByUsername(username)
+	if err != nil || user != nil {
+		return err
+	}
+	audit := &model.AuditEntry{Actor: "system", Action: model.AuditUserCreate, Resource: "admin_user"}
+	_, err = s.CreateUser(model.AdminUserRequest{Username: username, Password: password, Role: model.RoleSuperAdmin}, audit)
+	if errors.Is(err, repository.ErrUsernameTaken) {
+		return nil // created by another instance meanwhile
+	}
+	return err
+}
+
+// dummyPasswordHash is compared against when the user does not exist.
+var dummyPasswordHash = []byte("$2a$10$Imljp1jNjLBN.LkzEU.z2.5F/WYkycUCncFZ2.cbcjGse2LYTBnpe")
+
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
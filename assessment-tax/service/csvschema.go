@@ -0,0 +1,289 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/LGROW101/assessment-tax/model"
+)
+
+// csvLayout is the fixed columns of a kind of upload: aliases maps a
+// normalized header name to its column.
+type csvLayout struct {
+	aliases  map[string]string
+	required []string
+}
+
+// taxCSVLayout is the layout of calculation uploads.
+var taxCSVLayout = csvLayout{
+	aliases: map[string]string{
+		"totalincome": "totalIncome",
+		"income":      "totalIncome",
+		"wht":         "wht",
+		"taxyear":     "taxYear",
+	},
+	required: []string{"totalIncome", "wht"},
+}
+
+// withholdingCSVLayout is the layout of monthly payroll uploads, one line per
+// employee.
+var withholdingCSVLayout = csvLayout{
+	aliases: map[string]string{
+		"employeeid":  "employeeId",
+		"employee":    "employeeId",
+		"month":       "month",
+		"salary":      "salary",
+		"ytdincome":   "ytdIncome",
+		"ytdwithheld": "ytdWithheld",
+		"taxyear":     "taxYear",
+	},
+	required: []string{"month", "salary"},
+}
+
+// CSVSchema locates the columns of an upload by the names in its header line.
+// Columns may come in any order; unknown columns are ignored.
+type CSVSchema struct {
+	columns    map[string]int
+	allowances []*model.AllowanceType
+}
+
+// ParseCSVHeader builds the schema of a calculation upload from its header
+// line. Every allowance type of the catalogue, given as allowanceTypes, may be
+// a column. It fails when a required column is missing or a column appears
+// twice.
+func ParseCSVHeader(header []string, allowanceTypes []*model.AllowanceType) (*CSVSchema, error) {
+	return taxCSVLayout.parseHeader(header, allowanceTypes)
+}
+
+// ParseWithholdingCSVHeader builds the schema of a monthly payroll upload
+// from its header line, as ParseCSVHeader does for calculation uploads.
+func ParseWithholdingCSVHeader(header []string, allowanceTypes []*model.AllowanceType) (*CSVSchema, error) {
+	return withholdingCSVLayout.parseHeader(header, allowanceTypes)
+}
+
+func (l csvLayout) parseHeader(header []string, allowanceTypes []*model.AllowanceType) (*CSVSchema, error) {
+	schema := &CSVSchema{columns: map[string]int{}}
+
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff") // byte order mark written by Excel
+		}
+		column, ok := l.column(name, allowanceTypes)
+		if !ok {
+			continue
+		}
+		if _, ok := schema.columns[column]; ok {
+			return nil, fmt.Errorf("duplicate column %s", column)
+		}
+		schema.columns[column] = i
+	}
+
+	var missing []string
+	for _, column := range l.required {
+		if _, ok := schema.columns[column]; !ok {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required column(s): %s", strings.Join(missing, ", "))
+	}
+
+	for _, allowanceType := range allowanceTypes {
+		if _, ok := schema.columns[allowanceType.Key]; ok {
+			schema.allowances = append(schema.allowances, allowanceType)
+		}
+	}
+	return schema, nil
+}
+
+// CheckCSVHeader reads the header line of a file and reports why it cannot
+// be used, if it cannot.
+func CheckCSVHeader(reader io.Reader, allowanceTypes []*model.AllowanceType) error {
+	header, err := csv.NewReader(reader).Read()
+	if err == io.EOF {
+		return errors.New("file is empty")
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return parseErr.Err
+	}
+	if err != nil {
+		return err
+	}
+	_, err = ParseCSVHeader(header, allowanceTypes)
+	return err
+}
+
+// column matches a header name ignoring case, spaces, '-' and '_', so
+// "Total Income", "total_income" and "totalIncome" are the same column.
+func (l csvLayout) column(name string, allowanceTypes []*model.AllowanceType) (string, bool) {
+	normalized := normalizeCSVHeader(name)
+	if column, ok := l.aliases[normalized]; ok {
+		return column, true
+	}
+	for _, allowanceType := range allowanceTypes {
+		if normalizeCSVHeader(allowanceType.Key) == normalized {
+			return allowanceType.Key, true
+		}
+	}
+	return "", false
+}
+
+func normalizeCSVHeader(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '_':
+			return -1
+		}
+		return r
+	}, strings.ToLower(strings.TrimSpace(name)))
+}
+
+// ParseRow reads a line into a calculation request. Blank amounts other than
+// totalIncome are 0; a donation may be a percentage of totalIncome and an
+// allowance claimed per person is a number of people. A field that cannot be
+// used is reported as a *model.CSVRowError naming its column.
+func (s *CSVSchema) ParseRow(fields []string) (model.TaxCalculationRequest, error) {
+	var req model.TaxCalculationRequest
+	var err error
+
+	if req.TotalIncome, err = s.money(fields, "totalIncome", true, model.ParseMoney); err != nil {
+		return req, err
+	}
+	if req.WHT, err = s.money(fields, "wht", false, model.ParseMoney); err != nil {
+		return req, err
+	}
+
+	if req.Allowances, err = s.claims(fields, req.TotalIncome); err != nil {
+		return req, err
+	}
+
+	if req.TaxYear, err = s.taxYear(fields); err != nil {
+		return req, err
+	}
+
+	return req, nil
+}
+
+// ParseWithholdingRow reads a line of a payroll upload into a withholding
+// request. Allowances are claimed for the whole year, so a donation given as
+// a percentage is of the projected annual salary.
+func (s *CSVSchema) ParseWithholdingRow(fields []string) (model.WithholdingRequest, error) {
+	req := model.WithholdingRequest{EmployeeID: s.value(fields, "employeeId")}
+	var err error
+
+	value := s.value(fields, "month")
+	if req.Month, err = strconv.Atoi(value); err != nil || req.Month < 1 || req.Month > 12 {
+		return req, &model.CSVRowError{Column: "month", Reason: "must be a month from 1 to 12"}
+	}
+	if req.Salary, err = s.money(fields, "salary", true, model.ParseMoney); err != nil {
+		return req, err
+	}
+	if req.YTDIncome, err = s.money(fields, "ytdIncome", false, model.ParseMoney); err != nil {
+		return req, err
+	}
+	if req.YTDWithheld, err = s.money(fields, "ytdWithheld", false, model.ParseMoney); err != nil {
+		return req, err
+	}
+	if req.Allowances, err = s.claims(fields, req.AnnualIncome()); err != nil {
+		return req, err
+	}
+	if req.TaxYear, err = s.taxYear(fields); err != nil {
+		return req, err
+	}
+
+	return req, nil
+}
+
+// claims reads the allowance columns. A donation may be a percentage of
+// income. A blank cell claims nothing, so a row of an earlier tax year is not
+// held to a type its rule set does not know.
+func (s *CSVSchema) claims(fields []string, income model.Money) ([]model.Allowance, error) {
+	parseDonation := func(value string) (model.Money, error) {
+		return ParseDonation(value, income)
+	}
+	allowances := []model.Allowance{}
+	for _, allowanceType := range s.allowances {
+		if s.value(fields, allowanceType.Key) == "" {
+			continue
+		}
+		if allowanceType.UnitAmount != nil {
+			count, err := s.count(fields, allowanceType.Key)
+			if err != nil {
+				return nil, err
+			}
+			if count == 0 {
+				continue
+			}
+			allowances = append(allowances, model.Allowance{AllowanceType: allowanceType.Key, Count: count})
+			continue
+		}
+
+		parse := model.ParseMoney
+		if model.IsDonationAllowance(allowanceType.Key) {
+			parse = parseDonation
+		}
+		amount, err := s.money(fields, allowanceType.Key, false, parse)
+		if err != nil {
+			return nil, err
+		}
+		allowances = append(allowances, model.Allowance{AllowanceType: allowanceType.Key, Amount: amount})
+	}
+	return allowances, nil
+}
+
+func (s *CSVSchema) taxYear(fields []string) (int, error) {
+	value := s.value(fields, "taxYear")
+	if value == "" {
+		return 0, nil
+	}
+	taxYear, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &model.CSVRowError{Column: "taxYear", Reason: "must be a year"}
+	}
+	return taxYear, nil
+}
+
+func (s *CSVSchema) value(fields []string, column string) string {
+	i, ok := s.columns[column]
+	if !ok || i >= len(fields) {
+		return ""
+	}
+	return strings.TrimSpace(fields[i])
+}
+
+func (s *CSVSchema) money(fields []string, column string, required bool, parse func(string) (model.Money, error)) (model.Money, error) {
+	value := s.value(fields, column)
+	if value == "" {
+		if required {
+			return 0, &model.CSVRowError{Column: column, Reason: "is required"}
+		}
+		return 0, nil
+	}
+
+	amount, err := parse(value)
+	if err != nil {
+		return 0, &model.CSVRowError{Column: column, Reason: err.Error()}
+	}
+	if amount < 0 {
+		return 0, &model.CSVRowError{Column: column, Reason: "must not be negative"}
+	}
+	return amount, nil
+}
+
+func (s *CSVSchema) count(fields []string, column string) (int, error) {
+	value := s.value(fields, column)
+	if value == "" {
+		return 0, nil
+	}
+
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 0 {
+		return 0, &model.CSVRowError{Column: column, Reason: "must be a number of people"}
+	}
+	return count, nil
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LGROW101/assessment-tax/model"
+)
+
+// Formats calculations can be exported in, named by their media type.
+const (
+	ExportCSV  = "text/csv"
+	ExportXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+var ErrUnknownExportFormat = errors.New("unknown export format")
+
+// tableWriter writes the rows of a spreadsheet. Cells are strings, ints,
+// times, model.Money or model.Rate; nil is an empty cell. Numbers are written
+// as numbers and strings as text that no spreadsheet reads as a formula.
+type tableWriter interface {
+	WriteRow(cells ...any) error
+	Close() error
+}
+
+func newTableWriter(w io.Writer, format, sheet string) (tableWriter, error) {
+	switch format {
+	case ExportCSV:
+		return &csvTableWriter{w: csv.NewWriter(w)}, nil
+	case ExportXLSX:
+		return newXLSXWriter(w, sheet)
+	}
+	return nil, ErrUnknownExportFormat
+}
+
+type csvTableWriter struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (t *csvTableWriter) WriteRow(cells ...any) error {
+	t.record = t.record[:0]
+	for _, cell := range cells {
+		t.record = append(t.record, formatCell(cell))
+	}
+	return t.w.Write(t.record)
+}
+
+func (t *csvTableWriter) Close() error {
+	t.w.Flush()
+	return t.w.Error()
+}
+
+func formatCell(cell any) string {
+	switch value := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return escapeFormula(value)
+	case int:
+		return strconv.Itoa(value)
+	case uint:
+		return strconv.FormatUint(uint64(value), 10)
+	case model.Money:
+		return value.String()
+	case model.Rate:
+		return value.String()
+	case time.Time:
+		return value.Format(time.RFC3339)
+	}
+	return fmt.Sprint(cell)
+}
+
+// escapeFormula quotes text a spreadsheet would take for a formula, such as
+// an employee ID or a CSV header of "=HYPERLINK(...)" echoed in a row error,
+// so it is shown as the text it is.
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// calculationColumns lays out calculations one per row: the amounts deducted
+// from the income, with the deduction of each allowance type claimed in any of
+// them, then the tax of each bracket found in any of them. Allowance types and
+// brackets are in the order they first appear.
+type calculationColumns struct {
+	deductions []string
+	levels     []string
+}
+
+func newCalculationColumns(calculations []*model.TaxCalculation) *calculationColumns {
+	columns := &calculationColumns{}
+	for _, taxCalculation := range calculations {
+		columns.add(taxCalculation)
+	}
+	return columns
+}
+
+// add appends the allowance types and brackets of the calculation not seen
+// yet.
+func (c *calculationColumns) add(taxCalculation *model.TaxCalculation) {
+	keys := make([]string, 0, len(taxCalculation.Deductions))
+	for key := range taxCalculation.Deductions {
+		if !slices.Contains(c.deductions, key) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	c.deductions = append(c.deductions, keys...)
+
+	for _, rate := range taxCalculation.TaxLevel {
+		if !slices.Contains(c.levels, rate.Level) {
+			c.levels = append(c.levels, rate.Level)
+		}
+	}
+}
+
+func (c *calculationColumns) header() []any {
+	header := []any{"taxYear", "totalIncome", "wht", "expenses", "personalAllowance", "donation", "kReceipt"}
+	for _, key := range c.deductions {
+		header = append(header, "deduction "+key)
+	}
+	header = append(header, "taxableIncome")
+	for _, level := range c.levels {
+		header = append(header, "tax "+level)
+	}
+	return append(header, "tax", "taxPayable", "taxRefund")
+}
+
+func (c *calculationColumns) row(taxCalculation *model.TaxCalculation) []any {
+	row := []any{
+		taxCalculation.TaxYear,
+		taxCalculation.TotalIncome,
+		taxCalculation.WHT,
+		taxCalculation.Expenses,
+		taxCalculation.PersonalAllowance,
+		taxCalculation.Donation,
+		taxCalculation.KReceipt,
+	}
+	for _, key := range c.deductions {
+		var cell any
+		if amount, ok := taxCalculation.Deductions[key]; ok {
+			cell = amount
+		}
+		row = append(row, cell)
+	}
+	row = append(row, taxCalculation.TaxableIncome)
+	for _, level := range c.levels {
+		var cell any
+		for _, rate := range taxCalculation.TaxLevel {
+			if rate.Level == level {
+				cell = rate.Tax
+				break
+			}
+		}
+		row = append(row, cell)
+	}
+	return append(row, taxCalculation.Tax, taxCalculation.TaxPayable, taxCalculation.TaxRefund)
+}
+
+// emptyRow returns the cells of a row that has no calculation.
+func (c *calculationColumns) emptyRow() []any {
+	return make([]any, len(c.header()))
+}
+
+// WriteCalculations writes the calculations as a spreadsheet in the format,
+// one row per calculation.
+func WriteCalculations(w io.Writer, format string, calculations []*model.TaxCalculation) error {
+	table, err := newTableWriter(w, format, "Calculations")
+	if err != nil {
+		return err
+	}
+
+	columns := newCalculationColumns(calculations)
+	if err := table.WriteRow(append([]any{"id", "createdAt"}, columns.header()...)...); err != nil {
+		return err
+	}
+	for _, taxCalculation := range calculations {
+		row := append([]any{taxCalculation.ID, taxCalculation.CreatedAt}, columns.row(taxCalculation)...)
+		if err := table.WriteRow(row...); err != nil {
+			return err
+		}
+	}
+	return table.Close()
+}
+
+// WriteUploadResult writes the result of an uploaded file as a spreadsheet in
+// the format: one row per line of the file, holding either its calculation or
+// why it was rejected. Both calculations and rowErrors are in line order.
+func WriteUploadResult(w io.Writer, format string, calculations []*model.TaxCalculation, rowErrors []model.CSVRowError) error {
+	table, err := newUploadResultTable(w, format, newCalculationColumns(calculations), rowErrors)
+	if err != nil {
+		return err
+	}
+	for _, taxCalculation := range calculations {
+		if err := table.write(taxCalculation); err != nil {
+			return err
+		}
+	}
+	return table.close()
+}
+
+// uploadResultTable writes the rows of WriteUploadResult one calculation at a
+// time, so the calculations of a job can be streamed from the database. The
+// columns must already hold the brackets of every calculation.
+type uploadResultTable struct {
+	table     tableWriter
+	columns   *calculationColumns
+	rowErrors []model.CSVRowError
+}
+
+func newUploadResultTable(w io.Writer, format string, columns *calculationColumns, rowErrors []model.CSVRowError) (*uploadResultTable, error) {
+	table, err := newTableWriter(w, format, "Results")
+	if err != nil {
+		return nil, err
+	}
+
+	header := append([]any{"row"}, columns.header()...)
+	if err := table.WriteRow(append(header, "error")...); err != nil {
+		return nil, err
+	}
+	return &uploadResultTable{table: table, columns: columns, rowErrors: rowErrors}, nil
+}
+
+// write writes the calculation after the rejected lines that come before it.
+func (t *uploadResultTable) write(taxCalculation *model.TaxCalculation) error {
+	if err := t.writeErrorsBefore(taxCalculation.UploadRow); err != nil {
+		return err
+	}
+	cells := append([]any{taxCalculation.UploadRow}, t.columns.row(taxCalculation)...)
+	return t.table.WriteRow(append(cells, nil)...)
+}
+
+// close writes the rejected lines left and finishes the file.
+func (t *uploadResultTable) close() error {
+	if err := t.writeErrorsBefore(0); err != nil {
+		return err
+	}
+	return t.table.Close()
+}
+
+// writeErrorsBefore writes the rejected lines before row, or all of them
+// when row is 0.
+func (t *uploadResultTable) writeErrorsBefore(row int) error {
+	for len(t.rowErrors) > 0 && (row == 0 || t.rowErrors[0].Row < row) {
+		rowErr := t.rowErrors[0]
+		reason := rowErr.Reason
+		if rowErr.Column != "" {
+			reason = rowErr.Column + ": " + reason
+		}
+		cells := append([]any{rowErr.Row}, t.columns.emptyRow()...)
+		if err := t.table.WriteRow(append(cells, reason)...); err != nil {
+			return err
+		}
+		t.rowErrors = t.rowErrors[1:]
+	}
+	return nil
+}
+
+// WriteWithholdingRun writes the result of a payroll upload as a spreadsheet
+// in the format: one row per line of the file, holding either the amount to
+// withhold or why the line was rejected.
+func WriteWithholdingRun(w io.Writer, format string, run *model.WithholdingRun) error {
+	table, err := newTableWriter(w, format, "Withholding")
+	if err != nil {
+		return err
+	}
+
+	header := []any{"row", "employeeId", "month", "salary", "annualIncome", "taxableIncome", "annualTax", "ytdWithheld", "withholding", "trueUp", "error"}
+	if err := table.WriteRow(header...); err != nil {
+		return err
+	}
+
+	results, rowErrors := run.Results, run.Errors
+	for len(results) > 0 || len(rowErrors) > 0 {
+		var cells []any
+		if len(rowErrors) > 0 && (len(results) == 0 || rowErrors[0].Row < results[0].Row) {
+			rowErr := rowErrors[0]
+			reason := rowErr.Reason
+			if rowErr.Column != "" {
+				reason = rowErr.Column + ": " + reason
+			}
+			cells = append([]any{rowErr.Row}, make([]any, len(header)-2)...)
+			cells = append(cells, reason)
+			rowErrors = rowErrors[1:]
+		} else {
+			result := results[0]
+			cells = []any{result.Row, result.EmployeeID, result.Month, result.Salary, result.AnnualIncome,
+				result.TaxableIncome, result.AnnualTax, result.YTDWithheld, result.Withholding, result.TrueUp, nil}
+			results = results[1:]
+		}
+		if err := table.WriteRow(cells...); err != nil {
+			return err
+		}
+	}
+	return table.Close()
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/LGROW101/assessment-tax/model"
+)
+
+type ScenarioService interface {
+	// Compare computes the base request and every variation of it side by
+	// side without storing them. An error of a variation names it.
+	Compare(req model.ScenarioRequest) (*model.ScenarioComparison, error)
+}
+
+type scenarioService struct {
+	calculatorSvc TaxCalculatorService
+}
+
+// NewScenarioService returns a new instance of ScenarioService. Scenarios are
+// computed by calculatorSvc, so they follow the same rules as calculations.
+func NewScenarioService(calculatorSvc TaxCalculatorService) ScenarioService {
+	return &scenarioService{
+		calculatorSvc: calculatorSvc,
+	}
+}
+
+func (s *scenarioService) Compare(req model.ScenarioRequest) (*model.ScenarioComparison, error) {
+	base, err := s.calculatorSvc.Compute(req.Base)
+	if err != nil {
+		return nil, fmt.Errorf("base: %w", err)
+	}
+
+	comparison := &model.ScenarioComparison{Base: scenarioResult("base", base)}
+	for i, variation := range req.Variations {
+		name := variation.Name
+		if name == "" {
+			name = fmt.Sprintf("variation %d", i+1)
+		}
+
+		taxCalculation, err := s.calculatorSvc.Compute(variation.Apply(req.Base))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		result := scenarioResult(name, taxCalculation)
+		result.Delta = &model.ScenarioDelta{
+			TotalIncome:   result.TotalIncome - comparison.Base.TotalIncome,
+			TaxableIncome: result.TaxableIncome - comparison.Base.TaxableIncome,
+			Tax:           result.Tax - comparison.Base.Tax,
+			TaxPayable:    result.TaxPayable - comparison.Base.TaxPayable,
+			TaxRefund:     result.TaxRefund - comparison.Base.TaxRefund,
+		}
+		comparison.Variations = append(comparison.Variations, result)
+	}
+	return comparison, nil
+}
+
+func scenarioResult(name string, taxCalculation *model.TaxCalculation) model.ScenarioResult {
+	return model.ScenarioResult{
+		Name:           name,
+		TaxYear:        taxCalculation.TaxYear,
+		RuleSetVersion: taxCalculation.RuleSetVersion,
+		TotalIncome:    taxCalculation.TotalIncome,
+		Expenses:       taxCalculation.Expenses,
+		TaxableIncome:  taxCalculation.TaxableIncome,
+		Tax:            taxCalculation.Tax,
+		TaxPayable:     taxCalculation.TaxPayable,
+		TaxRefund:      taxCalculation.TaxRefund,
+		MarginalRate:   taxCalculation.MarginalRate,
+	}
+}
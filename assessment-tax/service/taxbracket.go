@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/LGROW101/assessment-tax/model"
+)
+
+var ErrTaxBracketsNotConfigured = errors.New("tax bracket schedule is not configured")
+
+// TaxBracketSchedule is the progressive tax schedule shared by every tax
+// calculation: brackets ordered by threshold, each taxing the income above
+// its threshold at its marginal rate up to the next threshold.
+type TaxBracketSchedule struct {
+	brackets []model.TaxBracket
+}
+
+// NewTaxBracketSchedule validates the brackets and returns a schedule.
+// The brackets must start at 0 and be ordered by strictly increasing threshold.
+func NewTaxBracketSchedule(brackets []model.TaxBracket) (TaxBracketSchedule, error) {
+	if len(brackets) == 0 {
+		return TaxBracketSchedule{}, ErrTaxBracketsNotConfigured
+	}
+	if brackets[0].Threshold != 0 {
+		return TaxBracketSchedule{}, errors.New("first tax bracket must start at 0")
+	}
+	for i, bracket := range brackets {
+		if bracket.Rate < 0 || bracket.Rate > model.Percent(100) {
+			return TaxBracketSchedule{}, fmt.Errorf("tax bracket %d has invalid rate %s", i, bracket.Rate)
+		}
+		if i > 0 && bracket.Threshold <= brackets[i-1].Threshold {
+			return TaxBracketSchedule{}, errors.New("tax brackets must be ordered by increasing threshold")
+		}
+	}
+
+	return TaxBracketSchedule{brackets: append([]model.TaxBracket(nil), brackets...)}, nil
+}
+
+func (s TaxBracketSchedule) Brackets() []model.TaxBracket {
+	return append([]model.TaxBracket(nil), s.brackets...)
+}
+
+// Tax returns the total tax for the given taxable income.
+func (s TaxBracketSchedule) Tax(taxableIncome model.Money) model.Money {
+	var tax model.Money
+	for _, rate := range s.Breakdown(taxableIncome) {
+		tax += rate.Tax
+	}
+	return tax
+}
+
+// Breakdown returns the tax accrued inside every bracket for the given
+// taxable income, each rounded to the satang. The bracket taxes add up to
+// Tax(taxableIncome).
+func (s TaxBracketSchedule) Breakdown(taxableIncome model.Money) []model.TaxRate {
+	return bandTaxRates(s.bands(taxableIncome))
+}
+
+// bracketBand is the part of a taxable income that falls inside a bracket.
+type bracketBand struct {
+	level  string
+	income model.Money
+	rate   model.Rate
+	tax    model.Money
+}
+
+func bandTaxRates(bands []bracketBand) []model.TaxRate {
+	taxLevel := make([]model.TaxRate, len(bands))
+	for i, band := range bands {
+		taxLevel[i] = model.TaxRate{Level: band.level, Tax: band.tax}
+	}
+	return taxLevel
+}
+
+func (s TaxBracketSchedule) bands(taxableIncome model.Money) []bracketBand {
+	levels := s.Levels()
+	bands := make([]bracketBand, len(s.brackets))
+	for i, bracket := range s.brackets {
+		bands[i] = bracketBand{level: levels[i], rate: bracket.Rate}
+		if taxableIncome <= bracket.Threshold {
+			continue
+		}
+		upper := taxableIncome
+		if i+1 < len(s.brackets) {
+			upper = model.MinMoney(taxableIncome, s.brackets[i+1].Threshold)
+		}
+		bands[i].income = upper - bracket.Threshold
+		bands[i].tax = bands[i].income.MulRate(bracket.Rate)
+	}
+	return bands
+}
+
+// MarginalRate returns the rate the next baht of taxable income above
+// taxableIncome is taxed at.
+func (s TaxBracketSchedule) MarginalRate(taxableIncome model.Money) model.Rate {
+	var rate model.Rate
+	for _, bracket := range s.brackets {
+		if taxableIncome < bracket.Threshold {
+			break
+		}
+		rate = bracket.Rate
+	}
+	return rate
+}
+
+// Levels returns the display label of every bracket, e.g. "150,001-500,000".
+func (s TaxBracketSchedule) Levels() []string {
+	levels := make([]string, len(s.brackets))
+	for i, bracket := range s.brackets {
+		lower := formatBaht(bracket.Threshold)
+		if i > 0 {
+			lower = formatBaht(bracket.Threshold + model.Baht(1))
+		}
+		if i+1 < len(s.brackets) {
+			levels[i] = lower + "-" + formatBaht(s.brackets[i+1].Threshold)
+		} else {
+			levels[i] = lower + " ขึ้นไป"
+		}
+	}
+	return levels
+}
+
+func formatBaht(amount model.Money) string {
+	digits := strconv.FormatInt(amount.WholeBaht(), 10)
+	for i := len(digits) - 3; i > 0; i -= 3 {
+		digits = digits[:i] + "," + digits[i:]
+	}
+	return digits
+}
@@ -1,122 +1,569 @@
 package service
 
 import (
-	"math"
+	"errors"
+	"fmt"
 
 	"github.com/LGROW101/assessment-tax/model"
 	"github.com/LGROW101/assessment-tax/repository"
 )
 
+// ErrUnknownAllowanceType is returned for a claim of an allowance type that
+// is not in the catalogue.
+var ErrUnknownAllowanceType = errors.New("unknown allowance type")
+
+// unknownAllowanceTypeError is the ErrUnknownAllowanceType of a claim, naming
+// its type so an upload can report the column.
+type unknownAllowanceTypeError struct {
+	allowanceType string
+}
+
+func (e *unknownAllowanceTypeError) Error() string {
+	return fmt.Sprintf("%v: %q", ErrUnknownAllowanceType, e.allowanceType)
+}
+
+func (e *unknownAllowanceTypeError) Unwrap() error {
+	return ErrUnknownAllowanceType
+}
+
+// ErrInvalidAllowance is returned for a claim that does not fit its type,
+// such as more children than the type allows.
+var ErrInvalidAllowance = errors.New("invalid allowance")
+
+// ErrTargetUnreachable is returned when no income reaches the target of a
+// gross-up, such as a tax under a schedule that taxes nothing.
+var ErrTargetUnreachable = errors.New("target cannot be reached")
+
+// maxGrossUpIncome bounds the search of a gross-up.
+var maxGrossUpIncome = model.Baht(1_000_000_000_000)
+
+// ErrInvalidIncome is returned for an income of an unknown category or a
+// negative amount.
+var ErrInvalidIncome = errors.New("invalid income")
+
 type TaxCalculatorService interface {
-	GetAllCalculations() ([]*model.TaxCalculation, error)
-	CalculateTax(totalIncome, wht float64, allowances []model.Allowance) (*model.TaxCalculationResponse, error)
+	GetAllCalculations(filter model.TaxCalculationFilter) ([]*model.TaxCalculation, string, error)
+	GetCalculation(id uint) (*model.TaxCalculation, error)
+	CalculateTax(req model.TaxCalculationRequest) (*model.TaxCalculationResponse, error)
+	Compute(req model.TaxCalculationRequest) (*model.TaxCalculation, error)
+	// GrossUp finds the smallest income whose calculation reaches the target
+	// net income or tax of the request.
+	GrossUp(req model.GrossUpRequest) (*model.GrossUpResult, error)
+	// CalculateHousehold computes the tax of two spouses filing separately and
+	// filing jointly, without storing either.
+	CalculateHousehold(req model.HouseholdRequest) (*model.HouseholdResponse, error)
+	// AllowanceTypes returns the allowance types in the catalogue.
+	AllowanceTypes() ([]*model.AllowanceType, error)
+	// LoadRuleSet returns the rule set the request is calculated under, so
+	// many requests of the same tax year can share one load.
+	LoadRuleSet(req model.TaxCalculationRequest) (*RuleSet, error)
+}
+
+// RuleSet is a rule set with its bracket schedule and allowance catalogue.
+type RuleSet struct {
+	Config         *model.AdminConfig
+	Schedule       TaxBracketSchedule
+	AllowanceTypes []*model.AllowanceType
+}
+
+// Compute calculates the request under the rule set without storing it.
+func (r *RuleSet) Compute(req model.TaxCalculationRequest) (*model.TaxCalculation, error) {
+	return compute(r.Config, r.Schedule, r.AllowanceTypes, req)
 }
+
 type taxCalculatorService struct {
-	taxRepo  repository.TaxRepository
-	adminSvc AdminServiceInterface
+	taxRepo       repository.TaxRepository
+	adminSvc      AdminServiceInterface
+	allowanceRepo repository.AllowanceRepository
 }
 
-func NewTaxCalculatorService(taxRepo repository.TaxRepository, adminRepo repository.AdminRepository) TaxCalculatorService {
+func NewTaxCalculatorService(taxRepo repository.TaxRepository, adminRepo repository.AdminRepository, allowanceRepo repository.AllowanceRepository) TaxCalculatorService {
 	return &taxCalculatorService{
-		taxRepo:  taxRepo,
-		adminSvc: NewAdminService(adminRepo),
+		taxRepo:       taxRepo,
+		adminSvc:      NewAdminService(adminRepo),
+		allowanceRepo: allowanceRepo,
 	}
 }
 
-func (s *taxCalculatorService) GetAllCalculations() ([]*model.TaxCalculation, error) {
-	return s.taxRepo.GetAllCalculations()
+func (s *taxCalculatorService) GetAllCalculations(filter model.TaxCalculationFilter) ([]*model.TaxCalculation, string, error) {
+	return s.taxRepo.GetAllCalculations(filter)
+}
+
+func (s *taxCalculatorService) GetCalculation(id uint) (*model.TaxCalculation, error) {
+	return s.taxRepo.GetCalculation(id)
 }
 
-func (s *taxCalculatorService) CalculateTax(totalIncome, wht float64, allowances []model.Allowance) (*model.TaxCalculationResponse, error) {
-	config, err := s.adminSvc.GetConfig()
+// CalculateTax computes the tax of the request and stores the calculation.
+func (s *taxCalculatorService) CalculateTax(req model.TaxCalculationRequest) (*model.TaxCalculationResponse, error) {
+	taxCalculation, err := s.Compute(req)
 	if err != nil {
 		return nil, err
 	}
 
-	// Set default values if not provided
-	personalAllowance := config.PersonalDeduction
-	donation := 0.0
-	kReceipt := 0.0
+	err = s.taxRepo.Save(taxCalculation)
+	if err != nil {
+		return nil, err
+	}
+
+	return calculationResponse(taxCalculation), nil
+}
+
+func calculationResponse(taxCalculation *model.TaxCalculation) *model.TaxCalculationResponse {
+	taxResponse := &model.TaxCalculationResponse{
+		WHT:      taxCalculation.WHT,
+		TaxLevel: taxCalculation.TaxLevel,
+	}
+	if taxCalculation.Request.Explain {
+		taxResponse.Explanation = taxCalculation.Steps
+	}
+
+	if taxCalculation.TaxPayable > 0 {
+		taxResponse.Tax = &taxCalculation.TaxPayable
+	} else if taxCalculation.TaxRefund > 0 {
+		taxResponse.TaxRefund = &taxCalculation.TaxRefund
+	}
+
+	return taxResponse
+}
 
-	for _, allowance := range allowances {
-		switch allowance.AllowanceType {
-		case "donation":
-			donation = math.Min(allowance.Amount, 100000)
-		case "k-receipt":
-			kReceipt = math.Min(allowance.Amount, config.KReceipt)
+// Compute returns the calculation of the request without storing it.
+func (s *taxCalculatorService) Compute(req model.TaxCalculationRequest) (*model.TaxCalculation, error) {
+	ruleSet, err := s.LoadRuleSet(req)
+	if err != nil {
+		return nil, err
+	}
+	return ruleSet.Compute(req)
+}
+
+func (s *taxCalculatorService) LoadRuleSet(req model.TaxCalculationRequest) (*RuleSet, error) {
+	config, schedule, err := s.ruleSet(req)
+	if err != nil {
+		return nil, err
+	}
+
+	allowanceTypes, err := s.allowanceTypes(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RuleSet{Config: config, Schedule: schedule, AllowanceTypes: allowanceTypes}, nil
+}
+
+// compute calculates the request under the rule set and allowance catalogue
+// given, so they can be loaded once for many requests.
+func compute(config *model.AdminConfig, schedule TaxBracketSchedule, allowanceTypes []*model.AllowanceType, req model.TaxCalculationRequest) (*model.TaxCalculation, error) {
+	for _, income := range req.Incomes {
+		if err := income.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidIncome, err)
 		}
 	}
 
-	taxableIncome := totalIncome - personalAllowance - donation - kReceipt
+	totalIncome := req.TotalIncome
+	if len(req.Incomes) > 0 {
+		totalIncome = model.TotalIncome(req.Incomes)
+	}
+	return computeIncome(config, schedule, allowanceTypes, req, totalIncome, deductExpenses(req.Incomes))
+}
 
-	var tax float64
-	switch {
-	case taxableIncome <= 150000:
-		tax = 0
-	case taxableIncome <= 500000:
-		tax = (taxableIncome - 150000) * 0.1
-	case taxableIncome <= 1000000:
-		tax = 35000 + (taxableIncome-500000)*0.15
-	case taxableIncome <= 2000000:
-		tax = 110000 + (taxableIncome-1000000)*0.2
-	default:
-		tax = 310000 + (taxableIncome-2000000)*0.35
+// computeIncome calculates the request for a total income and the steps of
+// its expenses worked out already. Every amount of the result is taken from
+// the steps it records, so its explanation cannot differ from it.
+func computeIncome(config *model.AdminConfig, schedule TaxBracketSchedule, allowanceTypes []*model.AllowanceType, req model.TaxCalculationRequest, totalIncome model.Money, expenseSteps []model.CalculationStep) (*model.TaxCalculation, error) {
+	wht := req.WHT
+	personalAllowance := config.PersonalDeduction
+
+	steps := []model.CalculationStep{{Step: model.StepIncome, Amount: totalIncome}}
+	var expenses model.Money
+	for _, step := range expenseSteps {
+		expenses += step.Amount
 	}
+	steps = append(steps, expenseSteps...)
+	steps = append(steps, model.CalculationStep{Step: model.StepPersonalAllowance, Amount: personalAllowance})
 
-	taxPayable := math.Max(tax-wht, 0)
+	deductions, allowanceSteps, err := deductAllowances(config, allowanceTypes, req.Allowances, totalIncome, expenses)
+	if err != nil {
+		return nil, err
+	}
+	steps = append(steps, allowanceSteps...)
 
-	var taxRefund float64
-	if tax < wht {
-		taxRefund = wht - tax
+	taxableIncome := totalIncome - expenses - personalAllowance
+	for _, amount := range deductions {
+		taxableIncome -= amount
 	}
+	steps = append(steps, model.CalculationStep{Step: model.StepTaxableIncome, Amount: taxableIncome})
 
-	taxLevel := []model.TaxRate{
-		{Level: "0-150,000", Tax: 0},
-		{Level: "150,001-500,000", Tax: 0},
-		{Level: "500,001-1,000,000", Tax: 0},
-		{Level: "1,000,001-2,000,000", Tax: 0},
-		{Level: "2,000,001 ขึ้นไป", Tax: 0},
+	bands := schedule.bands(taxableIncome)
+	var tax model.Money
+	for _, band := range bands {
+		tax += band.tax
+		if band.income > 0 {
+			income, rate := band.income, band.rate
+			steps = append(steps, model.CalculationStep{Step: model.StepBracket, Key: band.level, Base: &income, Rate: &rate, Amount: band.tax})
+		}
 	}
+	steps = append(steps, model.CalculationStep{Step: model.StepTax, Amount: tax})
 
-	switch {
-	case taxableIncome <= 150000:
-		taxLevel[0].Tax = taxPayable
-	case taxableIncome <= 500000:
-		taxLevel[1].Tax = taxPayable
-	case taxableIncome <= 1000000:
-		taxLevel[2].Tax = taxPayable
-	case taxableIncome <= 2000000:
-		taxLevel[3].Tax = taxPayable
-	default:
-		taxLevel[4].Tax = taxPayable
+	taxPayable := model.MaxMoney(tax-wht, 0)
+
+	var taxRefund model.Money
+	if tax < wht {
+		taxRefund = wht - tax
+	}
+	steps = append(steps, model.CalculationStep{Step: model.StepWHT, Amount: wht})
+	if taxRefund > 0 {
+		steps = append(steps, model.CalculationStep{Step: model.StepTaxRefund, Amount: taxRefund})
+	} else {
+		steps = append(steps, model.CalculationStep{Step: model.StepTaxPayable, Amount: taxPayable})
 	}
 
-	taxCalculation := &model.TaxCalculation{
+	return &model.TaxCalculation{
+		TaxYear:           config.TaxYear,
+		RuleSetID:         config.ID,
+		RuleSetVersion:    config.Version,
 		TotalIncome:       totalIncome,
 		WHT:               wht,
+		Expenses:          expenses,
 		PersonalAllowance: personalAllowance,
-		Donation:          donation,
-		KReceipt:          kReceipt,
+		Donation:          deductions[model.AllowanceDonation] + deductions[model.AllowanceEducationDonation],
+		KReceipt:          deductions[model.AllowanceKReceipt],
+		TaxableIncome:     taxableIncome,
 		Tax:               tax,
 		TaxPayable:        taxPayable,
 		TaxRefund:         taxRefund,
-		TaxLevel:          taxLevel,
+		TaxLevel:          bandTaxRates(bands),
+		MarginalRate:      schedule.MarginalRate(taxableIncome),
+		Steps:             steps,
+		Allowances:        req.Allowances,
+		Deductions:        deductions,
+		Request:           req,
+	}, nil
+}
+
+func (s *taxCalculatorService) GrossUp(req model.GrossUpRequest) (*model.GrossUpResult, error) {
+	config, schedule, err := s.adminSvc.GetRuleSet(req.TaxYear)
+	if err != nil {
+		return nil, err
 	}
 
-	err = s.taxRepo.Save(taxCalculation)
+	allowanceTypes, err := s.allowanceTypes(config)
 	if err != nil {
 		return nil, err
 	}
 
-	taxResponse := &model.TaxCalculationResponse{
-		TaxLevel: taxLevel,
+	// Both the net income and the tax grow with the income, so the smallest
+	// income reaching the target is found by bisection, to the satang.
+	target, measure := req.TargetNet, func(c *model.TaxCalculation) model.Money { return c.TotalIncome - c.Tax }
+	if req.TargetTax != nil {
+		target, measure = req.TargetTax, func(c *model.TaxCalculation) model.Money { return c.Tax }
+	}
+	reaches := func(income model.Money) (*model.TaxCalculation, bool, error) {
+		taxCalculation, err := compute(config, schedule, allowanceTypes, req.Request(income))
+		if err != nil {
+			return nil, false, err
+		}
+		return taxCalculation, measure(taxCalculation) >= *target, nil
 	}
 
-	if taxPayable > 0 {
-		taxResponse.Tax = &taxPayable
-	} else if taxRefund > 0 {
-		taxResponse.TaxRefund = &taxRefund
+	low, high := model.Money(-1), model.MaxMoney(*target, model.Baht(1))
+	var taxCalculation *model.TaxCalculation
+	for {
+		var ok bool
+		taxCalculation, ok, err = reaches(high)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			break
+		}
+		if high >= maxGrossUpIncome {
+			return nil, ErrTargetUnreachable
+		}
+		low, high = high, model.MinMoney(high*2, maxGrossUpIncome)
 	}
+	for high-low > 1 {
+		middle := low + (high-low)/2
+		middleCalculation, ok, err := reaches(middle)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			high, taxCalculation = middle, middleCalculation
+		} else {
+			low = middle
+		}
+	}
+
+	return &model.GrossUpResult{
+		TotalIncome:   taxCalculation.TotalIncome,
+		NetIncome:     taxCalculation.TotalIncome - taxCalculation.Tax,
+		TaxableIncome: taxCalculation.TaxableIncome,
+		Tax:           taxCalculation.Tax,
+		TaxPayable:    taxCalculation.TaxPayable,
+		TaxRefund:     taxCalculation.TaxRefund,
+		TaxLevel:      taxCalculation.TaxLevel,
+	}, nil
+}
+
+// CalculateHousehold files each spouse's return on its own, then one joint
+// return of both incomes, in which each spouse's expenses are worked out on
+// their own income, the personal allowance is given for both spouses and the
+// allowances of both are claimed together under the caps of one return. The
+// spouse allowance is only claimed on the separate returns: on the joint one
+// the second personal allowance stands for it.
+func (s *taxCalculatorService) CalculateHousehold(req model.HouseholdRequest) (*model.HouseholdResponse, error) {
+	config, schedule, err := s.adminSvc.GetRuleSet(req.TaxYear)
+	if err != nil {
+		return nil, err
+	}
+
+	allowanceTypes, err := s.allowanceTypes(config)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.HouseholdResponse{}
+	joint := model.TaxCalculationRequest{TaxYear: req.TaxYear}
+	var jointIncome model.Money
+	var jointExpenses []model.CalculationStep
+	for i, spouse := range req.Spouses {
+		spouse.TaxYear = req.TaxYear
+		taxCalculation, err := compute(config, schedule, allowanceTypes, spouse)
+		if err != nil {
+			return nil, fmt.Errorf("spouse %d: %w", i+1, err)
+		}
+		addHouseholdReturn(&response.Separate, taxCalculation)
+
+		jointIncome += taxCalculation.TotalIncome
+		jointExpenses = append(jointExpenses, deductExpenses(spouse.Incomes)...)
+		joint.WHT += spouse.WHT
+		joint.Incomes = append(joint.Incomes, spouse.Incomes...)
+		for _, allowance := range spouse.Allowances {
+			if allowance.AllowanceType != model.AllowanceSpouse {
+				joint.Allowances = append(joint.Allowances, allowance)
+			}
+		}
+	}
+	joint.TotalIncome = jointIncome
+
+	jointConfig := *config
+	jointConfig.PersonalDeduction *= model.Money(len(req.Spouses))
+	taxCalculation, err := computeIncome(&jointConfig, schedule, allowanceTypes, joint, jointIncome, jointExpenses)
+	if err != nil {
+		return nil, fmt.Errorf("joint: %w", err)
+	}
+	addHouseholdReturn(&response.Joint, taxCalculation)
+
+	response.Recommended = model.FilingSeparate
+	if response.Joint.Tax < response.Separate.Tax {
+		response.Recommended = model.FilingJoint
+	}
+	return response, nil
+}
+
+func addHouseholdReturn(option *model.HouseholdOption, taxCalculation *model.TaxCalculation) {
+	option.Tax += taxCalculation.Tax
+	option.TaxPayable += taxCalculation.TaxPayable
+	option.TaxRefund += taxCalculation.TaxRefund
+	option.Returns = append(option.Returns, *calculationResponse(taxCalculation))
+}
+
+func (s *taxCalculatorService) ruleSet(req model.TaxCalculationRequest) (*model.AdminConfig, TaxBracketSchedule, error) {
+	if req.RuleSetVersion != 0 {
+		return s.adminSvc.GetRuleSetVersion(req.TaxYear, req.RuleSetVersion)
+	}
+	return s.adminSvc.GetRuleSet(req.TaxYear)
+}
+
+func (s *taxCalculatorService) AllowanceTypes() ([]*model.AllowanceType, error) {
+	return s.allowanceRepo.ListAllowanceTypes()
+}
+
+// allowanceTypes returns the allowance catalogue stored with the rule set, so
+// a later change of the catalogue leaves its calculations as they were. A rule
+// set that was never stored uses the current catalogue.
+func (s *taxCalculatorService) allowanceTypes(config *model.AdminConfig) ([]*model.AllowanceType, error) {
+	if config.AllowanceTypes != nil {
+		return config.AllowanceTypes, nil
+	}
+	return s.allowanceRepo.ListAllowanceTypes()
+}
+
+// deductExpenses returns the standard expense of each category of the
+// incomes as a step. Categories sharing a cap group are capped together.
+func deductExpenses(incomes []model.Income) []model.CalculationStep {
+	byCategory := map[string]model.Money{}
+	var categories []string
+	for _, income := range incomes {
+		if _, ok := byCategory[income.Category]; !ok {
+			categories = append(categories, income.Category)
+		}
+		byCategory[income.Category] += income.Amount
+	}
+
+	var steps []model.CalculationStep
+	groupExpenses := map[string]model.Money{}
+	for _, category := range categories {
+		rule, _ := model.ExpenseRuleOf(category)
+		income := byCategory[category]
+		expense := deduction{amount: income.MulRate(rule.Rate)}
+		if rule.Cap != nil {
+			left := model.MaxMoney(*rule.Cap-groupExpenses[rule.CapGroup], 0)
+			if rule.CapGroup != "" {
+				expense.limit(left, fmt.Sprintf("capped at the %s left of the %s cap shared by %s", left, *rule.Cap, rule.CapGroup))
+			} else {
+				expense.limit(left, fmt.Sprintf("capped at %s", left))
+			}
+		}
+		if rule.CapGroup != "" {
+			groupExpenses[rule.CapGroup] += expense.amount
+		}
+		steps = append(steps, model.CalculationStep{
+			Step: model.StepExpense, Key: category, Base: &income, Rate: &rule.Rate,
+			Cap: expense.cap, Amount: expense.amount, Reason: expense.reason,
+		})
+	}
+	return steps
+}
+
+// deductAllowances returns the amount deducted for every claimed allowance
+// type, and a step for each in the order they are deducted. Claims of the
+// same type add up; a type claimed per person deducts its unit amount for
+// each person counted. The "before" stage is deducted from the income left
+// after expenses and the personal allowance, but its percentage caps are the
+// statutory ones, taken of totalIncome, such as 15% of wages for the
+// provident fund. The "after" stage is deducted from what the "before" stage
+// leaves, and its percentage caps apply to that. Types of a group also share
+// the group's cap, in catalogue order.
+//
+// Donations come last, as the Revenue Department orders them: the education
+// donation, counted twice, is capped by the income left after every other
+// allowance, and the general donation by what is left after that. The rule
+// set's donation cap limits the two together.
+func deductAllowances(config *model.AdminConfig, allowanceTypes []*model.AllowanceType, claims []model.Allowance, totalIncome, expenses model.Money) (map[string]model.Money, []model.CalculationStep, error) {
+	catalogue := make(map[string]*model.AllowanceType, len(allowanceTypes))
+	groupCaps := map[string]model.Money{}
+	for _, allowanceType := range allowanceTypes {
+		catalogue[allowanceType.Key] = allowanceType
+		if allowanceType.Group != "" && allowanceType.GroupCap != nil {
+			groupCaps[allowanceType.Group] = *allowanceType.GroupCap
+		}
+	}
+
+	claimed := map[string]model.Money{}
+	counted := map[string]int{}
+	for _, claim := range claims {
+		allowanceType, ok := catalogue[claim.AllowanceType]
+		if !ok {
+			return nil, nil, &unknownAllowanceTypeError{allowanceType: claim.AllowanceType}
+		}
+		if allowanceType.UnitAmount == nil {
+			if claim.Count != 0 {
+				return nil, nil, fmt.Errorf("%w: %s is claimed by amount, not count", ErrInvalidAllowance, claim.AllowanceType)
+			}
+			claimed[claim.AllowanceType] += claim.Amount
+			continue
+		}
+		if claim.Amount != 0 {
+			return nil, nil, fmt.Errorf("%w: %s is claimed by count, not amount", ErrInvalidAllowance, claim.AllowanceType)
+		}
+		counted[claim.AllowanceType] += claim.Count
+		claimed[claim.AllowanceType] = *allowanceType.UnitAmount * model.Money(counted[claim.AllowanceType])
+	}
+	for key, count := range counted {
+		if maxUnits := catalogue[key].MaxUnits; maxUnits != nil && count > *maxUnits {
+			return nil, nil, fmt.Errorf("%w: at most %d may be claimed for %s", ErrInvalidAllowance, *maxUnits, key)
+		}
+	}
+
+	income := totalIncome - expenses - config.PersonalDeduction
+	deductions := map[string]model.Money{}
+	var steps []model.CalculationStep
+	deduct := func(key string, allowance deduction, reason string) {
+		if allowance.reason != "" {
+			reason = allowance.reason
+		}
+		amount := claimed[key]
+		steps = append(steps, model.CalculationStep{
+			Step: model.StepAllowance, Key: key, Claimed: &amount,
+			Cap: allowance.cap, Amount: allowance.amount, Reason: reason,
+		})
+		deductions[key] = allowance.amount
+		income -= allowance.amount
+	}
+
+	for _, stage := range []string{model.AllowanceStageBefore, model.AllowanceStageAfter} {
+		base, baseName := model.MaxMoney(totalIncome, 0), "total income"
+		if stage == model.AllowanceStageAfter {
+			base, baseName = model.MaxMoney(income, 0), "the income left"
+		}
+		for _, allowanceType := range allowanceTypes {
+			amount, ok := claimed[allowanceType.Key]
+			if !ok || allowanceType.Stage != stage || model.IsDonationAllowance(allowanceType.Key) {
+				continue
+			}
+			allowance := deduction{amount: amount}
+			allowance.capBy(allowanceType, base, baseName)
+			if allowanceType.Key == model.AllowanceKReceipt {
+				allowance.limit(config.KReceipt, fmt.Sprintf("capped at the rule set's %s", config.KReceipt))
+			}
+			if groupCap, ok := groupCaps[allowanceType.Group]; ok {
+				left := model.MaxMoney(groupCap, 0)
+				allowance.limit(left, fmt.Sprintf("capped at the %s left of group %s", left, allowanceType.Group))
+				allowance.amount = model.MaxMoney(allowance.amount, 0)
+				groupCaps[allowanceType.Group] = groupCap - allowance.amount
+			}
+			deduct(allowanceType.Key, allowance, "claimed in full")
+		}
+	}
+
+	donationCap := config.DonationCap
+	for _, key := range []string{model.AllowanceEducationDonation, model.AllowanceDonation} {
+		amount, ok := claimed[key]
+		if !ok {
+			continue
+		}
+		reason := "claimed in full"
+		if key == model.AllowanceEducationDonation {
+			amount *= 2
+			reason = "counted twice"
+		}
+		allowance := deduction{amount: amount}
+		allowance.capBy(catalogue[key], model.MaxMoney(income, 0), "the income left")
+		left := model.MaxMoney(donationCap, 0)
+		allowance.limit(left, fmt.Sprintf("capped at the %s left of the rule set's donation cap", left))
+		allowance.amount = model.MaxMoney(allowance.amount, 0)
+		if allowance.reason != "" && key == model.AllowanceEducationDonation {
+			allowance.reason = "counted twice, " + allowance.reason
+		}
+		deduct(key, allowance, reason)
+		donationCap -= allowance.amount
+	}
+	return deductions, steps, nil
+}
 
-	return taxResponse, nil
+// deduction is a claim as its caps are applied: what is left of it and the
+// cap that limited it last, with the reason.
+type deduction struct {
+	amount model.Money
+	cap    *model.Money
+	reason string
+}
+
+// limit lowers the deduction to max when it is more.
+func (d *deduction) limit(max model.Money, reason string) {
+	if max < d.amount {
+		d.amount, d.cap, d.reason = max, &max, reason
+	}
+}
+
+// capBy limits the deduction to the caps of its type, taking the percentage
+// cap of base.
+func (d *deduction) capBy(allowanceType *model.AllowanceType, base model.Money, baseName string) {
+	if allowanceType.CapAmount != nil {
+		d.limit(*allowanceType.CapAmount, fmt.Sprintf("capped at %s", *allowanceType.CapAmount))
+	}
+	if allowanceType.CapRate != nil {
+		rate := *allowanceType.CapRate
+		d.limit(base.MulRate(rate), fmt.Sprintf("capped at %s%% of %s, %s", rate*100, baseName, base))
+	}
 }
@@ -2,156 +2,191 @@ package service
 
 import (
 	"encoding/csv"
+	"errors"
 	"io"
-	"strconv"
 	"strings"
 
-	"github.com/LGROW101/assessment-tax/repository"
+	"github.com/LGROW101/assessment-tax/model"
 )
 
 type TaxCSVService interface {
-	ImportCSV(reader io.Reader) ([]map[string]float64, error)
-	CalculateTax(totalIncome, wht, donation float64) (float64, float64, error)
+	// ImportCSV calculates every valid row and reports the rejected ones
+	// without storing anything; stored uploads are processed by TaxJobService.
+	// An error is returned only when the file cannot be read.
+	ImportCSV(reader io.Reader) (*model.CSVImportResult, error)
+	// CheckHeader reports why the header line of a file cannot be used, if
+	// it cannot.
+	CheckHeader(reader io.Reader) error
 }
 
 type taxCSVService struct {
-	taxRepo  repository.TaxRepository
-	adminSvc AdminServiceInterface
+	calculatorSvc TaxCalculatorService
 }
 
-// NewTaxCSVService returns a new instance of TaxCSVService
-func NewTaxCSVService(taxRepo repository.TaxRepository, adminRepo repository.AdminRepository) TaxCSVService {
+// NewTaxCSVService returns a new instance of TaxCSVService. Rows are computed
+// by calculatorSvc so uploads follow the same rules as single calculations.
+func NewTaxCSVService(calculatorSvc TaxCalculatorService) TaxCSVService {
 	return &taxCSVService{
-		taxRepo:  taxRepo,
-		adminSvc: NewAdminService(adminRepo),
+		calculatorSvc: calculatorSvc,
 	}
 }
 
-func (s *taxCSVService) ImportCSV(reader io.Reader) ([]map[string]float64, error) {
-	csvReader := csv.NewReader(reader)
-	csvReader.FieldsPerRecord = -1 // Allow variable number of fields per record
+func (s *taxCSVService) ImportCSV(reader io.Reader) (*model.CSVImportResult, error) {
+	result := &model.CSVImportResult{Taxes: []map[string]model.Money{}, DryRun: true}
 
-	csvReader.Read()
+	allowanceTypes, err := s.calculatorSvc.AllowanceTypes()
+	if err != nil {
+		return nil, err
+	}
 
-	var taxes []map[string]float64
-	for {
-		line, err := csvReader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, err
+	err = readCSVRows(reader, allowanceTypes, func(row int, req model.TaxCalculationRequest, rowErr *model.CSVRowError) error {
+		if rowErr != nil {
+			result.Errors = append(result.Errors, *rowErr)
+			return nil
 		}
 
-		totalIncome, wht, donation, err := ParseFields(line)
-		if err != nil {
-			return nil, err
+		taxCalculation, err := s.calculatorSvc.Compute(req)
+		if rowErr, ok := computeRowError(row, err); ok {
+			result.Errors = append(result.Errors, *rowErr)
+			return nil
 		}
-
-		taxPayable, taxRefund, err := s.CalculateTax(totalIncome, wht, donation)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		taxResult := map[string]float64{
-			"totalIncome": totalIncome,
+		taxCalculation.UploadRow = row
+		result.Calculations = append(result.Calculations, taxCalculation)
+
+		taxResult := map[string]model.Money{
+			"totalIncome": req.TotalIncome,
 		}
 
-		if taxRefund > 0 {
-			taxResult["taxRefund"] = taxRefund
+		if taxCalculation.TaxRefund > 0 {
+			taxResult["taxRefund"] = taxCalculation.TaxRefund
 		} else {
-			taxResult["tax"] = taxPayable
+			taxResult["tax"] = taxCalculation.TaxPayable
 		}
 
-		taxes = append(taxes, taxResult)
-	}
-
-	return taxes, nil
-}
+		result.Taxes = append(result.Taxes, taxResult)
+		return nil
+	})
 
-func (s *taxCSVService) CalculateTax(totalIncome, wht, donation float64) (float64, float64, error) {
-
-	config, err := s.adminSvc.GetConfig()
+	var rowErr *model.CSVRowError
+	if errors.As(err, &rowErr) {
+		result.Errors = append(result.Errors, *rowErr)
+		return result, nil
+	}
 	if err != nil {
-		return 0, 0, err
+		return nil, err
 	}
+	return result, nil
+}
 
-	personalAllowance := config.PersonalDeduction
-
-	taxableIncome := totalIncome - personalAllowance - donation
-
-	var tax float64
-	switch {
-	case taxableIncome <= 0:
-		tax = 0
-	case taxableIncome <= 150000:
-		tax = taxableIncome * 0.05
-	case taxableIncome <= 300000:
-		tax = 7500 + (taxableIncome-150000)*0.05
-	case taxableIncome <= 500000:
-		tax = 15000 + (taxableIncome-300000)*0.10
-	case taxableIncome <= 750000:
-		tax = 35000 + (taxableIncome-500000)*0.15
-	case taxableIncome <= 1000000:
-		tax = 57500 + (taxableIncome-750000)*0.20
-	case taxableIncome <= 2000000:
-		tax = 107500 + (taxableIncome-1000000)*0.25
-	case taxableIncome <= 5000000:
-		tax = 357500 + (taxableIncome-2000000)*0.30
-	default:
-		tax = 1257500 + (taxableIncome-5000000)*0.35
+func (s *taxCSVService) CheckHeader(reader io.Reader) error {
+	allowanceTypes, err := s.calculatorSvc.AllowanceTypes()
+	if err != nil {
+		return err
 	}
+	return CheckCSVHeader(reader, allowanceTypes)
+}
 
-	// Calculate tax payable
-	taxPayable := tax - wht
-	var taxRefund float64
-	if taxPayable < 0 {
-		taxRefund = -taxPayable
-		taxPayable = 0
+// readCSVRows reads the file one line at a time, calling fn with the request
+// of each valid row or the error of a rejected one. A header that cannot be
+// used is returned as a *model.CSVRowError of row 1.
+func readCSVRows(reader io.Reader, allowanceTypes []*model.AllowanceType, fn func(row int, req model.TaxCalculationRequest, rowErr *model.CSVRowError) error) error {
+	var schema *CSVSchema
+	parseHeader := func(header []string) (err error) {
+		schema, err = ParseCSVHeader(header, allowanceTypes)
+		return err
 	}
-
-	return taxPayable, taxRefund, nil
+	return readCSVLines(reader, parseHeader, func(row int, line []string, rowErr *model.CSVRowError) error {
+		if rowErr != nil {
+			return fn(row, model.TaxCalculationRequest{}, rowErr)
+		}
+		req, err := schema.ParseRow(line)
+		if errors.As(err, &rowErr) {
+			rowErr.Row = row
+		} else if err != nil {
+			return err
+		}
+		return fn(row, req, rowErr)
+	})
 }
 
-func ParseFields(fields []string) (float64, float64, float64, error) {
-	var totalIncome, wht, donation float64
-	var err error
+// readCSVLines reads the file one line at a time. The header line is given to
+// parseHeader, whose error is returned as a *model.CSVRowError of row 1; fn
+// is called with the fields of every other line, or with the error of a line
+// that cannot be read.
+func readCSVLines(reader io.Reader, parseHeader func(header []string) error, fn func(row int, line []string, rowErr *model.CSVRowError) error) error {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1 // Allow variable number of fields per record
+	csvReader.ReuseRecord = true
 
-	if len(fields) > 0 {
-		totalIncome, err = strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
-		if err != nil {
-			return 0, 0, 0, err
-		}
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return &model.CSVRowError{Row: 1, Reason: parseErr.Err.Error()}
+	}
+	if err != nil {
+		return err
+	}
+	if err := parseHeader(header); err != nil {
+		return &model.CSVRowError{Row: 1, Reason: err.Error()}
 	}
 
-	if len(fields) > 1 {
-		wht, err = strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+	for {
+		line, err := csvReader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if errors.As(err, &parseErr) {
+			if err := fn(parseErr.StartLine, nil, &model.CSVRowError{Row: parseErr.StartLine, Reason: parseErr.Err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
 		if err != nil {
-			return 0, 0, 0, err
+			return err
 		}
-	}
+		row, _ := csvReader.FieldPos(0)
 
-	if len(fields) > 2 {
-		donation, err = ParseDonation(strings.TrimSpace(fields[2]))
-		if err != nil {
-			return 0, 0, 0, err
+		if err := fn(row, line, nil); err != nil {
+			return err
 		}
 	}
+}
 
-	return totalIncome, wht, donation, nil
+// computeRowError reports a calculation error caused by the row itself, such
+// as a tax year without rules, as an error of that row.
+func computeRowError(row int, err error) (*model.CSVRowError, bool) {
+	if errors.Is(err, ErrRuleSetNotFound) {
+		return &model.CSVRowError{Row: row, Column: "taxYear", Reason: err.Error()}, true
+	}
+	if errors.Is(err, ErrInvalidAllowance) {
+		return &model.CSVRowError{Row: row, Reason: err.Error()}, true
+	}
+	var unknown *unknownAllowanceTypeError
+	if errors.As(err, &unknown) {
+		return &model.CSVRowError{Row: row, Column: unknown.allowanceType, Reason: ErrUnknownAllowanceType.Error()}, true
+	}
+	return nil, false
 }
 
-func ParseDonation(donationStr string) (float64, error) {
+// ParseDonation reads a donation given as an amount or as a percentage of
+// totalIncome, e.g. "10%".
+func ParseDonation(donationStr string, totalIncome model.Money) (model.Money, error) {
 	if strings.HasSuffix(donationStr, "%") {
-		percentage, err := strconv.ParseFloat(strings.TrimSuffix(donationStr, "%"), 64)
+		rate, err := model.ParsePercent(strings.TrimSuffix(donationStr, "%"))
 		if err != nil {
 			return 0, err
 		}
-		return percentage / 100, nil
-	}
-	donation, err := strconv.ParseFloat(donationStr, 64)
-	if err != nil {
-		return 0, err
+		if rate < 0 || rate > model.Percent(100) {
+			return 0, errors.New("percentage must be between 0% and 100%")
+		}
+		return totalIncome.MulRate(rate), nil
 	}
-	return donation, nil
+	return model.ParseMoney(donationStr)
 }
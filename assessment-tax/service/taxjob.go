@@ -0,0 +1,400 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/repository"
+)
+
+var (
+	ErrJobNotFound    = errors.New("job not found")
+	ErrJobNotFinished = errors.New("job has not completed")
+)
+
+const (
+	jobPollInterval = 5 * time.Second
+	// a running job reports progress at least this often ...
+	jobHeartbeat = 10 * time.Second
+	// ... so one silent for longer belongs to a server that stopped
+	jobStaleTimeout = 2 * time.Minute
+)
+
+type TaxJobService interface {
+	// Submit stores the file as a queued job.
+	Submit(filename string, content []byte) (*model.TaxUpload, error)
+	GetJob(id uint) (*model.TaxUpload, error)
+	// WriteResult writes the result file of a completed job in the export
+	// format: one line per row of the upload with its calculation, or why it
+	// was rejected.
+	WriteResult(id uint, format string, w io.Writer) error
+	// Run processes queued jobs one at a time until ctx is done. Jobs left
+	// running by a stopped server are queued again.
+	Run(ctx context.Context)
+}
+
+type taxJobService struct {
+	uploadRepo    repository.UploadRepository
+	calculatorSvc TaxCalculatorService
+	workers       int
+	wake          chan struct{}
+}
+
+// NewTaxJobService returns a TaxJobService that computes the rows of a job
+// with the given number of workers.
+func NewTaxJobService(uploadRepo repository.UploadRepository, calculatorSvc TaxCalculatorService, workers int) TaxJobService {
+	if workers < 1 {
+		workers = 1
+	}
+	return &taxJobService{
+		uploadRepo:    uploadRepo,
+		calculatorSvc: calculatorSvc,
+		workers:       workers,
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+func (s *taxJobService) Submit(filename string, content []byte) (*model.TaxUpload, error) {
+	upload := &model.TaxUpload{Filename: filename}
+	if err := s.uploadRepo.CreateUpload(upload, content); err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return upload, nil
+}
+
+func (s *taxJobService) GetJob(id uint) (*model.TaxUpload, error) {
+	return s.uploadRepo.GetUpload(id)
+}
+
+func (s *taxJobService) WriteResult(id uint, format string, w io.Writer) error {
+	upload, err := s.uploadRepo.GetUpload(id)
+	if err != nil {
+		return err
+	}
+	if upload == nil {
+		return ErrJobNotFound
+	}
+	if upload.Status != model.UploadCompleted {
+		return ErrJobNotFinished
+	}
+
+	// the brackets of every calculation are columns, so they are collected
+	// first; the calculations themselves are then streamed into the file
+	columns := &calculationColumns{}
+	err = s.uploadRepo.EachResult(id, func(taxCalculation *model.TaxCalculation) error {
+		columns.add(taxCalculation)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	table, err := newUploadResultTable(w, format, columns, upload.Errors)
+	if err != nil {
+		return err
+	}
+	if err := s.uploadRepo.EachResult(id, table.write); err != nil {
+		return err
+	}
+	return table.close()
+}
+
+func (s *taxJobService) Run(ctx context.Context) {
+	for {
+		if n, err := s.uploadRepo.RequeueStaleUploads(jobStaleTimeout); err != nil {
+			log.Printf("tax jobs: requeue stale jobs: %v", err)
+		} else if n > 0 {
+			log.Printf("tax jobs: requeued %d stale job(s)", n)
+		}
+
+		for ctx.Err() == nil {
+			upload, content, err := s.uploadRepo.ClaimUpload()
+			if err != nil {
+				log.Printf("tax jobs: claim job: %v", err)
+				break
+			}
+			if upload == nil {
+				break
+			}
+			s.process(ctx, upload, content)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-time.After(jobPollInterval):
+		}
+	}
+}
+
+type jobRow struct {
+	row int
+	req model.TaxCalculationRequest
+}
+
+type jobRowResult struct {
+	row            int
+	taxCalculation *model.TaxCalculation
+	rowErr         *model.CSVRowError
+	err            error
+}
+
+type ruleSetKey struct {
+	taxYear int
+	version int
+}
+
+type loadedRuleSet struct {
+	ruleSet *RuleSet
+	err     error
+}
+
+// ruleSetCache loads each rule set of a job once, however many of its rows
+// fall in that tax year. A failed load is kept too, so every row of a missing
+// tax year gets the same error without another lookup.
+type ruleSetCache struct {
+	calculatorSvc TaxCalculatorService
+	mu            sync.Mutex
+	ruleSets      map[ruleSetKey]loadedRuleSet
+}
+
+func newRuleSetCache(calculatorSvc TaxCalculatorService) *ruleSetCache {
+	return &ruleSetCache{calculatorSvc: calculatorSvc, ruleSets: map[ruleSetKey]loadedRuleSet{}}
+}
+
+func (c *ruleSetCache) compute(req model.TaxCalculationRequest) (*model.TaxCalculation, error) {
+	key := ruleSetKey{taxYear: req.TaxYear, version: req.RuleSetVersion}
+
+	c.mu.Lock()
+	loaded, ok := c.ruleSets[key]
+	if !ok {
+		loaded.ruleSet, loaded.err = c.calculatorSvc.LoadRuleSet(req)
+		c.ruleSets[key] = loaded
+	}
+	c.mu.Unlock()
+
+	if loaded.err != nil {
+		return nil, loaded.err
+	}
+	return loaded.ruleSet.Compute(req)
+}
+
+// process streams the rows of the file through the worker pool into a single
+// transaction, so a job either stores all of its calculations or none.
+func (s *taxJobService) process(ctx context.Context, upload *model.TaxUpload, content []byte) {
+	err := s.processRows(ctx, upload, content)
+	switch {
+	case err == nil:
+		log.Printf("tax jobs: job %d completed: %d row(s), %d rejected", upload.ID, upload.RowCount, upload.ErrorCount)
+	case ctx.Err() != nil:
+		if err := s.uploadRepo.ReleaseUpload(upload); err != nil {
+			log.Printf("tax jobs: release job %d: %v", upload.ID, err)
+		}
+	case errors.Is(err, repository.ErrUploadNotRunning):
+		log.Printf("tax jobs: job %d was taken over by another worker", upload.ID)
+	default:
+		log.Printf("tax jobs: job %d failed: %v", upload.ID, err)
+		if err := s.uploadRepo.FailUpload(upload, err.Error()); err != nil {
+			log.Printf("tax jobs: fail job %d: %v", upload.ID, err)
+		}
+	}
+}
+
+func (s *taxJobService) processRows(ctx context.Context, upload *model.TaxUpload, content []byte) (err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	beat, err := s.startHeartbeat(upload, cancel)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if lost := beat.stop(); lost != nil && err != nil {
+			err = lost
+		}
+	}()
+
+	allowanceTypes, err := s.calculatorSvc.AllowanceTypes()
+	if err != nil {
+		return err
+	}
+
+	writer, err := s.uploadRepo.BeginResults(upload)
+	if err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			writer.Rollback()
+		}
+	}()
+
+	ruleSets := newRuleSetCache(s.calculatorSvc)
+	rows := make(chan jobRow, s.workers)
+	results := make(chan jobRowResult, s.workers)
+
+	var workers sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for row := range rows {
+				taxCalculation, err := ruleSets.compute(row.req)
+				if rowErr, ok := computeRowError(row.row, err); ok {
+					results <- jobRowResult{row: row.row, rowErr: rowErr}
+					continue
+				}
+				results <- jobRowResult{row: row.row, taxCalculation: taxCalculation, err: err}
+			}
+		}()
+	}
+
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(rows)
+		readErr <- readCSVRows(bytes.NewReader(content), allowanceTypes, func(row int, req model.TaxCalculationRequest, rowErr *model.CSVRowError) error {
+			if rowErr != nil {
+				results <- jobRowResult{row: row, rowErr: rowErr}
+				return nil
+			}
+			select {
+			case rows <- jobRow{row: row, req: req}:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	go func() {
+		// the reader only sends results before it closes rows
+		workers.Wait()
+		close(results)
+	}()
+
+	var failure error
+	processed := 0
+	upload.Errors = []model.CSVRowError{}
+	for result := range results {
+		if failure != nil {
+			continue // drain so the workers can finish
+		}
+
+		switch {
+		case result.err != nil:
+			failure = result.err
+		case result.rowErr != nil:
+			upload.Errors = append(upload.Errors, *result.rowErr)
+		default:
+			result.taxCalculation.UploadRow = result.row
+			failure = writer.Insert(result.taxCalculation)
+		}
+		if failure != nil {
+			cancel()
+			continue
+		}
+
+		processed++
+		beat.report(processed, len(upload.Errors))
+	}
+
+	if err := <-readErr; err != nil && failure == nil {
+		failure = err
+	}
+	if failure != nil {
+		return failure
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	sort.Slice(upload.Errors, func(i, j int) bool { return upload.Errors[i].Row < upload.Errors[j].Row })
+	upload.ProcessedCount = processed
+	upload.RowCount = processed
+	upload.ErrorCount = len(upload.Errors)
+
+	committed = true
+	return writer.Complete()
+}
+
+// heartbeat reports the progress of a job every jobHeartbeat on its own, for
+// the whole life of the job, so a job busy with a long insert or its final
+// commit is not taken for stale.
+type heartbeat struct {
+	mu       sync.Mutex
+	progress model.TaxUpload
+	done     chan struct{}
+	stopped  chan struct{}
+	lost     error
+}
+
+// startHeartbeat reports the job as started, which fails with
+// ErrUploadNotRunning when the upload is no longer held, and keeps reporting
+// until stop. It cancels the job when the upload is taken over in between.
+func (s *taxJobService) startHeartbeat(upload *model.TaxUpload, cancel context.CancelFunc) (*heartbeat, error) {
+	beat := &heartbeat{
+		progress: model.TaxUpload{ID: upload.ID, Attempt: upload.Attempt},
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	if err := s.uploadRepo.UpdateProgress(&beat.progress); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		defer close(beat.stopped)
+		ticker := time.NewTicker(jobHeartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-beat.done:
+				return
+			case <-ticker.C:
+			}
+
+			beat.mu.Lock()
+			progress := beat.progress
+			beat.mu.Unlock()
+			err := s.uploadRepo.UpdateProgress(&progress)
+			if errors.Is(err, repository.ErrUploadNotRunning) {
+				beat.lost = err
+				cancel()
+				return
+			}
+			if err != nil {
+				log.Printf("tax jobs: job %d progress: %v", upload.ID, err)
+			}
+		}
+	}()
+	return beat, nil
+}
+
+func (b *heartbeat) report(processed, rejected int) {
+	b.mu.Lock()
+	b.progress.ProcessedCount = processed
+	b.progress.ErrorCount = rejected
+	b.mu.Unlock()
+}
+
+// stop ends the heartbeat and returns ErrUploadNotRunning when the job was
+// taken over while it ran.
+func (b *heartbeat) stop() error {
+	close(b.done)
+	<-b.stopped
+	return b.lost
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"errors"
+	"io"
+
+	"github.com/LGROW101/assessment-tax/model"
+)
+
+type WithholdingService interface {
+	// Calculate returns the tax to withhold from a month's salary.
+	Calculate(req model.WithholdingRequest) (*model.WithholdingResult, error)
+	// ImportCSV calculates the withholding of every valid line of a monthly
+	// payroll file and reports the rejected ones. Nothing is stored. An error
+	// is returned only when the file cannot be read.
+	ImportCSV(reader io.Reader) (*model.WithholdingRun, error)
+}
+
+type withholdingService struct {
+	calculatorSvc TaxCalculatorService
+}
+
+// NewWithholdingService returns a new instance of WithholdingService. The
+// annual tax is computed by calculatorSvc, with the same bracket schedule and
+// allowances as a calculation.
+func NewWithholdingService(calculatorSvc TaxCalculatorService) WithholdingService {
+	return &withholdingService{
+		calculatorSvc: calculatorSvc,
+	}
+}
+
+// Calculate annualises the salary, computes the tax of the year and spreads
+// what has not been withheld yet over the months left. When earlier months
+// withheld more than the year's tax nothing is withheld; the excess is
+// refunded on the annual return.
+func (s *withholdingService) Calculate(req model.WithholdingRequest) (*model.WithholdingResult, error) {
+	taxCalculation, err := s.calculatorSvc.Compute(req.Request())
+	if err != nil {
+		return nil, err
+	}
+
+	var withholding model.Money
+	if due := taxCalculation.Tax - req.YTDWithheld; due > 0 {
+		withholding = due.Div(int64(req.RemainingMonths()))
+	}
+
+	return &model.WithholdingResult{
+		EmployeeID:    req.EmployeeID,
+		TaxYear:       taxCalculation.TaxYear,
+		Month:         req.Month,
+		Salary:        req.Salary,
+		AnnualIncome:  taxCalculation.TotalIncome,
+		TaxableIncome: taxCalculation.TaxableIncome,
+		AnnualTax:     taxCalculation.Tax,
+		YTDWithheld:   req.YTDWithheld,
+		Withholding:   withholding,
+		TrueUp:        withholding - taxCalculation.Tax.Div(12),
+	}, nil
+}
+
+func (s *withholdingService) ImportCSV(reader io.Reader) (*model.WithholdingRun, error) {
+	run := &model.WithholdingRun{Results: []model.WithholdingResult{}}
+
+	allowanceTypes, err := s.calculatorSvc.AllowanceTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	var schema *CSVSchema
+	parseHeader := func(header []string) (err error) {
+		schema, err = ParseWithholdingCSVHeader(header, allowanceTypes)
+		return err
+	}
+	err = readCSVLines(reader, parseHeader, func(row int, line []string, rowErr *model.CSVRowError) error {
+		if rowErr != nil {
+			run.Errors = append(run.Errors, *rowErr)
+			return nil
+		}
+
+		req, err := schema.ParseWithholdingRow(line)
+		if errors.As(err, &rowErr) {
+			rowErr.Row = row
+			run.Errors = append(run.Errors, *rowErr)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		result, err := s.Calculate(req)
+		if rowErr, ok := computeRowError(row, err); ok {
+			run.Errors = append(run.Errors, *rowErr)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		result.Row = row
+		run.Results = append(run.Results, *result)
+		return nil
+	})
+
+	var rowErr *model.CSVRowError
+	if errors.As(err, &rowErr) {
+		run.Errors = append(run.Errors, *rowErr)
+		return run, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
@@ -7,10 +7,14 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/LGROW101/assessment-tax/handler"
 	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/repository"
+	"github.com/LGROW101/assessment-tax/service"
 	"github.com/LGROW101/assessment-tax/tests/repository/mocks"
+	"github.com/golang-jwt/jwt"
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
@@ -24,11 +28,13 @@ func TestGetConfig(t *testing.T) {
 	adminHandler := handler.NewAdminHandler(mockAdminRepo)
 
 	expectedConfig := &model.AdminConfig{
-		PersonalDeduction: 60000,
-		KReceipt:          30000,
+		TaxYear:           2024,
+		Version:           3,
+		PersonalDeduction: model.Baht(60000),
+		KReceipt:          model.Baht(30000),
 	}
 
-	mockAdminRepo.EXPECT().GetConfig().Return(expectedConfig, nil)
+	mockAdminRepo.EXPECT().GetConfig(0).Return(expectedConfig, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/config", nil)
 	rec := httptest.NewRecorder()
@@ -38,6 +44,7 @@ func TestGetConfig(t *testing.T) {
 	err := adminHandler.GetConfig(c)
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"2024-3"`, rec.Header().Get("ETag"))
 
 	var response model.AdminConfig
 	err = json.Unmarshal(rec.Body.Bytes(), &response)
@@ -52,7 +59,7 @@ func TestGetConfigWithError(t *testing.T) {
 	mockAdminRepo := mocks.NewMockAdminRepository(ctrl)
 	adminHandler := handler.NewAdminHandler(mockAdminRepo)
 
-	mockAdminRepo.EXPECT().GetConfig().Return(nil, errors.New("repository error"))
+	mockAdminRepo.EXPECT().GetConfig(0).Return(nil, errors.New("repository error"))
 
 	req := httptest.NewRequest(http.MethodGet, "/config", nil)
 	rec := httptest.NewRecorder()
@@ -72,20 +79,26 @@ func TestUpdateConfig(t *testing.T) {
 	adminHandler := handler.NewAdminHandler(mockAdminRepo)
 
 	existingConfig := &model.AdminConfig{
-		PersonalDeduction: 60000,
-		KReceipt:          30000,
+		TaxYear:           2024,
+		Version:           1,
+		PersonalDeduction: model.Baht(60000),
+		KReceipt:          model.Baht(30000),
 	}
 
-	mockAdminRepo.EXPECT().GetConfig().Return(existingConfig, nil)
-	mockAdminRepo.EXPECT().UpdateConfig(gomock.Any()).DoAndReturn(func(config *model.AdminConfig) error {
-		assert.Equal(t, float64(70000), config.PersonalDeduction)
+	mockAdminRepo.EXPECT().GetConfig(0).Return(existingConfig, nil)
+	mockAdminRepo.EXPECT().GetConfig(2024).Return(existingConfig, nil)
+	mockAdminRepo.EXPECT().InsertConfig(gomock.Any(), gomock.Any()).DoAndReturn(func(config *model.AdminConfig, audit *model.AuditEntry) error {
+		assert.Equal(t, model.Baht(70000), config.PersonalDeduction)
 		assert.Equal(t, existingConfig.KReceipt, config.KReceipt)
+		assert.Equal(t, 1, config.Version)
+		config.Version = 2
 		return nil
 	})
 
 	reqBody := `{"personalDeduction":70000}`
 	req := httptest.NewRequest(http.MethodPut, "/config", strings.NewReader(reqBody))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("If-Match", `"2024-1"`)
 	rec := httptest.NewRecorder()
 	e := echo.New()
 	c := e.NewContext(req, rec)
@@ -93,12 +106,15 @@ func TestUpdateConfig(t *testing.T) {
 	err := adminHandler.UpdateConfig(c)
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"2024-2"`, rec.Header().Get("ETag"))
 
 	var response model.AdminResponse
 	err = json.Unmarshal(rec.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Equal(t, float64(70000), response.PersonalDeduction)
-	assert.Equal(t, float64(0), response.KReceipt)
+	assert.Equal(t, model.Baht(70000), response.PersonalDeduction)
+	assert.Equal(t, model.Baht(0), response.KReceipt)
+	assert.Equal(t, 2024, response.TaxYear)
+	assert.Equal(t, 2, response.Version)
 }
 
 func TestUpdateConfigWithInvalidBody(t *testing.T) {
@@ -128,8 +144,9 @@ func TestUpdateConfigWithValidationError(t *testing.T) {
 	adminHandler := handler.NewAdminHandler(mockAdminRepo)
 
 	originalConfig := &model.AdminConfig{
-		PersonalDeduction: 60000,
-		KReceipt:          30000,
+		TaxYear:           2024,
+		PersonalDeduction: model.Baht(60000),
+		KReceipt:          model.Baht(30000),
 	}
 
 	reqBody := `{"personalDeduction":-1,"kReceipt":40000}`
@@ -139,26 +156,56 @@ func TestUpdateConfigWithValidationError(t *testing.T) {
 	e := echo.New()
 	c := e.NewContext(req, rec)
 
-	mockAdminRepo.EXPECT().GetConfig().Return(originalConfig, nil)
+	mockAdminRepo.EXPECT().GetConfig(0).Return(originalConfig, nil)
+	mockAdminRepo.EXPECT().GetConfig(2024).Return(originalConfig, nil)
 
 	err := adminHandler.UpdateConfig(c)
 	assert.Error(t, err)
 	assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
 }
-func TestGetConfigNotFound(t *testing.T) {
+func TestGetConfigDefault(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockAdminRepo := mocks.NewMockAdminRepository(ctrl)
 	adminHandler := handler.NewAdminHandler(mockAdminRepo)
 
-	mockAdminRepo.EXPECT().GetConfig().Return(nil, nil)
+	mockAdminRepo.EXPECT().GetConfig(0).Return(nil, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/config", nil)
 	rec := httptest.NewRecorder()
 	e := echo.New()
 	c := e.NewContext(req, rec)
 
+	err := adminHandler.GetConfig(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("ETag"))
+
+	var response model.AdminResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.True(t, response.Default)
+	assert.Equal(t, time.Now().Year(), response.TaxYear)
+	assert.Equal(t, model.Baht(60000), response.PersonalDeduction)
+	assert.Equal(t, model.Baht(50000), response.KReceipt)
+	assert.Equal(t, model.Baht(100000), response.DonationCap)
+	assert.Len(t, response.Brackets, 5)
+}
+
+func TestGetConfigNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAdminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminHandler := handler.NewAdminHandler(mockAdminRepo)
+
+	mockAdminRepo.EXPECT().GetConfig(1999).Return(nil, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/config?taxYear=1999", nil)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
 	err := adminHandler.GetConfig(c)
 	assert.Error(t, err)
 	assert.Equal(t, http.StatusNotFound, err.(*echo.HTTPError).Code)
@@ -171,10 +218,11 @@ func TestUpdateConfigInsertError(t *testing.T) {
 	mockAdminRepo := mocks.NewMockAdminRepository(ctrl)
 	adminHandler := handler.NewAdminHandler(mockAdminRepo)
 
-	mockAdminRepo.EXPECT().GetConfig().Return(nil, nil)
-	mockAdminRepo.EXPECT().InsertConfig(gomock.Any()).Return(errors.New("insert error"))
+	mockAdminRepo.EXPECT().GetConfig(2025).Return(nil, nil)
+	mockAdminRepo.EXPECT().GetConfig(0).Return(&model.AdminConfig{TaxYear: 2024, PersonalDeduction: model.Baht(60000)}, nil)
+	mockAdminRepo.EXPECT().InsertConfig(gomock.Any(), gomock.Any()).Return(errors.New("insert error"))
 
-	reqBody := `{"personalDeduction":70000,"kReceipt":40000}`
+	reqBody := `{"taxYear":2025,"personalDeduction":70000,"kReceipt":40000}`
 	req := httptest.NewRequest(http.MethodPut, "/config", strings.NewReader(reqBody))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
@@ -186,6 +234,155 @@ func TestUpdateConfigInsertError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, err.(*echo.HTTPError).Code)
 }
 
+func TestUpdateConfigNewTaxYear(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAdminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminHandler := handler.NewAdminHandler(mockAdminRepo)
+
+	brackets := []model.TaxBracket{{Threshold: model.Baht(0), Rate: model.Percent(0)}, {Threshold: model.Baht(150000), Rate: model.Percent(10)}}
+	mockAdminRepo.EXPECT().GetConfig(2025).Return(nil, nil)
+	mockAdminRepo.EXPECT().GetConfig(0).Return(&model.AdminConfig{
+		ID:                1,
+		TaxYear:           2024,
+		Version:           3,
+		PersonalDeduction: model.Baht(60000),
+		KReceipt:          model.Baht(50000),
+		Brackets:          brackets,
+	}, nil)
+	mockAdminRepo.EXPECT().InsertConfig(gomock.Any(), gomock.Any()).DoAndReturn(func(config *model.AdminConfig, audit *model.AuditEntry) error {
+		assert.Equal(t, 2025, config.TaxYear)
+		assert.Equal(t, time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), config.EffectiveFrom)
+		assert.Equal(t, model.Baht(50000), config.KReceipt)
+		assert.Equal(t, brackets, config.Brackets)
+		assert.Equal(t, 0, config.Version)
+		config.Version = 1
+		return nil
+	})
+
+	reqBody := `{"taxYear":2025,"personalDeduction":70000}`
+	req := httptest.NewRequest(http.MethodPost, "/admin/deductions", strings.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	err := adminHandler.UpdateConfig(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"taxYear":2025,"version":1,"personalDeduction":70000}`, rec.Body.String())
+}
+
+func TestUpdateConfigWithStaleIfMatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAdminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminHandler := handler.NewAdminHandler(mockAdminRepo)
+
+	mockAdminRepo.EXPECT().GetConfig(0).Return(&model.AdminConfig{TaxYear: 2024, Version: 1}, nil)
+	mockAdminRepo.EXPECT().GetConfig(2024).Return(&model.AdminConfig{TaxYear: 2024, Version: 2}, nil)
+
+	reqBody := `{"personalDeduction":70000}`
+	req := httptest.NewRequest(http.MethodPost, "/admin/deductions", strings.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("If-Match", `"2024-1"`)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	err := adminHandler.UpdateConfig(c)
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusConflict, httpErr.Code)
+	assert.Equal(t, `"2024-2"`, rec.Header().Get("ETag"))
+}
+
+func TestUpdateConfigFollowsLatestVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAdminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminHandler := handler.NewAdminHandler(mockAdminRepo)
+
+	mockAdminRepo.EXPECT().GetConfig(0).Return(&model.AdminConfig{TaxYear: 2024, Version: 1, PersonalDeduction: model.Baht(60000)}, nil)
+	mockAdminRepo.EXPECT().GetConfig(2024).Return(&model.AdminConfig{TaxYear: 2024, Version: 2, PersonalDeduction: model.Baht(60000), KReceipt: model.Baht(40000)}, nil)
+	mockAdminRepo.EXPECT().InsertConfig(gomock.Any(), gomock.Any()).DoAndReturn(func(config *model.AdminConfig, _ *model.AuditEntry) error {
+		assert.Equal(t, 2, config.Version)
+		assert.Equal(t, model.Baht(40000), config.KReceipt)
+		config.Version = 3
+		return nil
+	})
+
+	reqBody := `{"personalDeduction":70000}`
+	req := httptest.NewRequest(http.MethodPost, "/admin/deductions", strings.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("If-Match", `"2024-2"`)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	err := adminHandler.UpdateConfig(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"2024-3"`, rec.Header().Get("ETag"))
+}
+
+func TestUpdateConfigVersionConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAdminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminHandler := handler.NewAdminHandler(mockAdminRepo)
+
+	mockAdminRepo.EXPECT().GetConfig(0).Return(&model.AdminConfig{TaxYear: 2024, Version: 1}, nil)
+	mockAdminRepo.EXPECT().GetConfig(2024).Return(&model.AdminConfig{TaxYear: 2024, Version: 1}, nil)
+	mockAdminRepo.EXPECT().InsertConfig(gomock.Any(), gomock.Any()).Return(repository.ErrVersionConflict)
+
+	reqBody := `{"personalDeduction":70000}`
+	req := httptest.NewRequest(http.MethodPost, "/admin/deductions", strings.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	err := adminHandler.UpdateConfig(c)
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusConflict, httpErr.Code)
+}
+
+func TestUpdateConfigFromDefault(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAdminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminHandler := handler.NewAdminHandler(mockAdminRepo)
+
+	mockAdminRepo.EXPECT().GetConfig(0).Return(nil, nil)
+	mockAdminRepo.EXPECT().InsertConfig(gomock.Any(), gomock.Any()).DoAndReturn(func(config *model.AdminConfig, _ *model.AuditEntry) error {
+		assert.Equal(t, time.Now().Year(), config.TaxYear)
+		assert.Equal(t, model.Baht(70000), config.PersonalDeduction)
+		assert.Equal(t, model.Baht(50000), config.KReceipt)
+		assert.Equal(t, model.Baht(100000), config.DonationCap)
+		assert.Len(t, config.Brackets, 5)
+		config.Version = 1
+		return nil
+	})
+
+	reqBody := `{"personalDeduction":70000}`
+	req := httptest.NewRequest(http.MethodPost, "/admin/deductions", strings.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	err := adminHandler.UpdateConfig(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
 func TestUpdateConfigUpdateError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -194,12 +391,15 @@ func TestUpdateConfigUpdateError(t *testing.T) {
 	adminHandler := handler.NewAdminHandler(mockAdminRepo)
 
 	existingConfig := &model.AdminConfig{
-		PersonalDeduction: 60000,
-		KReceipt:          30000,
+		TaxYear:           2024,
+		Version:           1,
+		PersonalDeduction: model.Baht(60000),
+		KReceipt:          model.Baht(30000),
 	}
 
-	mockAdminRepo.EXPECT().GetConfig().Return(existingConfig, nil)
-	mockAdminRepo.EXPECT().UpdateConfig(gomock.Any()).Return(errors.New("update error"))
+	mockAdminRepo.EXPECT().GetConfig(0).Return(existingConfig, nil)
+	mockAdminRepo.EXPECT().GetConfig(2024).Return(existingConfig, nil)
+	mockAdminRepo.EXPECT().InsertConfig(gomock.Any(), gomock.Any()).Return(errors.New("update error"))
 
 	reqBody := `{"personalDeduction":70000}`
 	req := httptest.NewRequest(http.MethodPut, "/config", strings.NewReader(reqBody))
@@ -212,3 +412,115 @@ func TestUpdateConfigUpdateError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, http.StatusInternalServerError, err.(*echo.HTTPError).Code)
 }
+
+func TestGetConfigByTaxYear(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAdminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminHandler := handler.NewAdminHandler(mockAdminRepo)
+
+	mockAdminRepo.EXPECT().GetConfig(2023).Return(&model.AdminConfig{TaxYear: 2023, Version: 2, PersonalDeduction: model.Baht(60000)}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/deductions?taxYear=2023", nil)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	err := adminHandler.GetConfig(c)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"taxYear":2023,"version":2,"personalDeduction":60000}`, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/deductions?taxYear=last", nil)
+	c = e.NewContext(req, httptest.NewRecorder())
+
+	err = adminHandler.GetConfig(c)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+}
+
+func TestListRuleSets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAdminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminHandler := handler.NewAdminHandler(mockAdminRepo)
+
+	mockAdminRepo.EXPECT().ListConfigs(2024).Return([]*model.AdminConfig{
+		{TaxYear: 2024, Version: 2, PersonalDeduction: model.Baht(70000)},
+		{TaxYear: 2024, Version: 1, PersonalDeduction: model.Baht(60000)},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/rule-sets?taxYear=2024", nil)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	err := adminHandler.ListRuleSets(c)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"taxYear":2024,"version":2,"personalDeduction":70000},
+		{"taxYear":2024,"version":1,"personalDeduction":60000}
+	]`, rec.Body.String())
+}
+
+func TestCreateRuleSet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAdminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminHandler := handler.NewAdminHandler(mockAdminRepo)
+
+	mockAdminRepo.EXPECT().GetConfig(2025).Return(&model.AdminConfig{ID: 4, TaxYear: 2025, Version: 1, PersonalDeduction: model.Baht(50000)}, nil)
+	mockAdminRepo.EXPECT().InsertConfig(gomock.Any(), gomock.Any()).DoAndReturn(func(config *model.AdminConfig, audit *model.AuditEntry) error {
+		assert.Equal(t, 2025, config.TaxYear)
+		assert.Equal(t, time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), config.EffectiveFrom)
+		assert.Len(t, config.Brackets, 2)
+		assert.Equal(t, "somchai", audit.Actor)
+		assert.Equal(t, model.AuditRuleSetCreate, audit.Action)
+		assert.Equal(t, "203.0.113.7", audit.ClientIP)
+		assert.Equal(t, "req-1", audit.RequestID)
+		assert.Contains(t, string(audit.OldValue), `"Version":1`)
+		assert.Equal(t, 1, config.Version)
+		config.Version = 2
+		return nil
+	})
+
+	reqBody := `{"taxYear":2025,"personalDeduction":60000,"kReceipt":50000,"donationCap":100000,
+		"brackets":[{"threshold":0,"rate":0},{"threshold":150000,"rate":0.1}]}`
+	req := httptest.NewRequest(http.MethodPost, "/admin/rule-sets", strings.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.RemoteAddr = "203.0.113.7:52114"
+	// neither header is the server's to trust
+	req.Header.Set(echo.HeaderXForwardedFor, "198.51.100.9")
+	req.Header.Set(echo.HeaderXRequestID, "forged")
+	rec := httptest.NewRecorder()
+	rec.Header().Set(echo.HeaderXRequestID, "req-1")
+	e := echo.New()
+	e.IPExtractor, _ = handler.IPExtractor(nil)
+	c := e.NewContext(req, rec)
+	c.Set(handler.AuthClaimsKey, &service.AuthClaims{Role: model.RoleTaxAdmin, StandardClaims: jwt.StandardClaims{Subject: "somchai"}})
+
+	err := adminHandler.CreateRuleSet(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestCreateRuleSetWithInvalidBrackets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAdminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminHandler := handler.NewAdminHandler(mockAdminRepo)
+
+	reqBody := `{"taxYear":2025,"personalDeduction":60000,"brackets":[{"threshold":150000,"rate":0.1}]}`
+	req := httptest.NewRequest(http.MethodPost, "/admin/rule-sets", strings.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	err := adminHandler.CreateRuleSet(c)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+}
@@ -0,0 +1,203 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LGROW101/assessment-tax/handler"
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/repository"
+	"github.com/LGROW101/assessment-tax/tests/repository/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListAllowanceTypes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAllowanceRepo := mocks.NewMockAllowanceRepository(ctrl)
+	allowanceHandler := handler.NewAllowanceHandler(mockAllowanceRepo)
+
+	capAmount := model.Baht(100000)
+	mockAllowanceRepo.EXPECT().ListAllowanceTypes().Return([]*model.AllowanceType{
+		{Key: model.AllowanceDonation, DisplayName: "เงินบริจาค", Stage: model.AllowanceStageAfter},
+		{Key: "provident-fund", DisplayName: "กองทุนสำรองเลี้ยงชีพ", CapAmount: &capAmount, Stage: model.AllowanceStageBefore},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/allowances", nil)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	err := allowanceHandler.ListAllowanceTypes(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Len(t, response, 2)
+	assert.Equal(t, "provident-fund", response[1]["key"])
+	assert.Equal(t, float64(100000), response[1]["capAmount"])
+	assert.NotContains(t, response[0], "capAmount")
+}
+
+func TestSaveAllowanceType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAllowanceRepo := mocks.NewMockAllowanceRepository(ctrl)
+	allowanceHandler := handler.NewAllowanceHandler(mockAllowanceRepo)
+
+	mockAllowanceRepo.EXPECT().GetAllowanceType("provident-fund").Return(nil, nil)
+	mockAllowanceRepo.EXPECT().SaveAllowanceType(gomock.Any(), gomock.Any()).DoAndReturn(func(allowanceType *model.AllowanceType, audit *model.AuditEntry) error {
+		assert.Equal(t, "provident-fund", allowanceType.Key)
+		assert.Equal(t, "กองทุนสำรองเลี้ยงชีพ", allowanceType.DisplayName)
+		assert.Equal(t, model.Percent(15), *allowanceType.CapRate)
+		assert.Nil(t, allowanceType.CapAmount)
+		assert.Equal(t, model.AllowanceStageBefore, allowanceType.Stage)
+		assert.Equal(t, model.AuditAllowanceUpdate, audit.Action)
+		assert.Equal(t, "null", string(audit.OldValue))
+		return nil
+	})
+
+	reqBody := `{"displayName":" กองทุนสำรองเลี้ยงชีพ ","capRate":0.15}`
+	req := httptest.NewRequest(http.MethodPut, "/admin/allowances/provident-fund", strings.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("key")
+	c.SetParamValues("provident-fund")
+
+	err := allowanceHandler.SaveAllowanceType(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestSaveAllowanceTypeWithInvalidBody(t *testing.T) {
+	testCases := []struct {
+		name string
+		key  string
+		body string
+	}{
+		{"Invalid key", "Provident_Fund", `{"displayName":"x"}`},
+		{"Missing display name", "provident-fund", `{"capRate":0.15}`},
+		{"Rate above 1", "provident-fund", `{"displayName":"x","capRate":1.5}`},
+		{"Unknown stage", "provident-fund", `{"displayName":"x","stage":"last"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			allowanceHandler := handler.NewAllowanceHandler(mocks.NewMockAllowanceRepository(ctrl))
+
+			req := httptest.NewRequest(http.MethodPut, "/admin/allowances/"+tc.key, strings.NewReader(tc.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("key")
+			c.SetParamValues(tc.key)
+
+			err := allowanceHandler.SaveAllowanceType(c)
+			httpErr, ok := err.(*echo.HTTPError)
+			assert.True(t, ok)
+			assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+		})
+	}
+}
+
+func TestDeleteAllowanceType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAllowanceRepo := mocks.NewMockAllowanceRepository(ctrl)
+	allowanceHandler := handler.NewAllowanceHandler(mockAllowanceRepo)
+
+	existing := &model.AllowanceType{Key: "sports", DisplayName: "กีฬา", Stage: model.AllowanceStageBefore}
+	mockAllowanceRepo.EXPECT().GetAllowanceType("sports").Return(existing, nil)
+	mockAllowanceRepo.EXPECT().DeleteAllowanceType("sports", gomock.Any()).DoAndReturn(func(key string, audit *model.AuditEntry) error {
+		assert.Equal(t, model.AuditAllowanceDelete, audit.Action)
+		assert.Contains(t, string(audit.OldValue), `"key":"sports"`)
+		return nil
+	})
+	mockAllowanceRepo.EXPECT().GetAllowanceType("golf").Return(nil, nil)
+
+	e := echo.New()
+	for key, expected := range map[string]int{"sports": http.StatusNoContent, "golf": http.StatusNotFound, model.AllowanceDonation: http.StatusBadRequest} {
+		req := httptest.NewRequest(http.MethodDelete, "/admin/allowances/"+key, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("key")
+		c.SetParamValues(key)
+
+		err := allowanceHandler.DeleteAllowanceType(c)
+		if httpErr, ok := err.(*echo.HTTPError); ok {
+			assert.Equal(t, expected, httpErr.Code, key)
+		} else {
+			assert.NoError(t, err)
+			assert.Equal(t, expected, rec.Code, key)
+		}
+	}
+}
+
+func TestSaveAllowanceTypeWithUnknownGroup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAllowanceRepo := mocks.NewMockAllowanceRepository(ctrl)
+	allowanceHandler := handler.NewAllowanceHandler(mockAllowanceRepo)
+
+	mockAllowanceRepo.EXPECT().GetAllowanceType("rmf").Return(nil, nil)
+	mockAllowanceRepo.EXPECT().SaveAllowanceType(gomock.Any(), gomock.Any()).Return(repository.ErrUnknownAllowanceGroup)
+
+	reqBody := `{"displayName":"RMF","capRate":0.3,"group":"pension"}`
+	req := httptest.NewRequest(http.MethodPut, "/admin/allowances/rmf", strings.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("key")
+	c.SetParamValues("rmf")
+
+	err := allowanceHandler.SaveAllowanceType(c)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+}
+
+func TestSaveAllowanceGroup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAllowanceRepo := mocks.NewMockAllowanceRepository(ctrl)
+	allowanceHandler := handler.NewAllowanceHandler(mockAllowanceRepo)
+
+	previous := &model.AllowanceGroup{Key: "retirement", DisplayName: "เงินออมเพื่อการเกษียณ", CapAmount: model.Baht(500000)}
+	mockAllowanceRepo.EXPECT().GetAllowanceGroup("retirement").Return(previous, nil)
+	mockAllowanceRepo.EXPECT().SaveAllowanceGroup(gomock.Any(), gomock.Any()).DoAndReturn(func(group *model.AllowanceGroup, audit *model.AuditEntry) error {
+		assert.Equal(t, model.Baht(450000), group.CapAmount)
+		assert.Equal(t, model.AuditAllowanceGroupUpdate, audit.Action)
+		assert.Contains(t, string(audit.OldValue), "500000")
+		return nil
+	})
+
+	reqBody := `{"displayName":"เงินออมเพื่อการเกษียณ","capAmount":450000}`
+	req := httptest.NewRequest(http.MethodPut, "/admin/allowance-groups/retirement", strings.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("key")
+	c.SetParamValues("retirement")
+
+	err := allowanceHandler.SaveAllowanceGroup(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,116 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/LGROW101/assessment-tax/handler"
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/tests/repository/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListAuditEntries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuditRepo := mocks.NewMockAuditRepository(ctrl)
+	auditHandler := handler.NewAuditHandler(mockAuditRepo)
+
+	from := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+	mockAuditRepo.EXPECT().ListEntries(model.AuditFilter{
+		Actor:  "somchai",
+		Action: model.AuditRuleSetCreate,
+		From:   &from,
+		To:     &to,
+		Cursor: 40,
+		Limit:  2,
+	}).Return([]*model.AuditEntry{
+		{ID: 39, Actor: "somchai", Action: model.AuditRuleSetCreate, Resource: "admin_config", ResourceID: "7",
+			OldValue: json.RawMessage("null"), NewValue: json.RawMessage(`{"taxYear":2025}`), ClientIP: "203.0.113.7"},
+		{ID: 35, Actor: "somchai", Action: model.AuditRuleSetCreate, Resource: "admin_config", ResourceID: "6",
+			OldValue: json.RawMessage("null"), NewValue: json.RawMessage(`{"taxYear":2024}`), ClientIP: "203.0.113.7"},
+	}, "35", nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet,
+		"/admin/audit?actor=somchai&action=rule-set.create&from=2024-05-01&to=2024-05-31&cursor=40&limit=2", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err := auditHandler.ListEntries(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "35", rec.Header().Get("X-Next-Cursor"))
+	assert.Contains(t, rec.Header().Get("Link"), "cursor=35")
+	assert.Contains(t, rec.Header().Get("Link"), `rel="next"`)
+
+	var entries []*model.AuditEntry
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &entries))
+	assert.Len(t, entries, 2)
+	assert.JSONEq(t, `{"taxYear":2025}`, string(entries[0].NewValue))
+}
+
+func TestListAuditEntriesWithInvalidCursor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	auditHandler := handler.NewAuditHandler(mocks.NewMockAuditRepository(ctrl))
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/admin/audit?cursor=abc", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err := auditHandler.ListEntries(c)
+	httpErr, ok := err.(*echo.HTTPError)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+}
+
+func TestRequestID(t *testing.T) {
+	e := echo.New()
+	e.Use(handler.RequestID())
+	e.GET("/", func(c echo.Context) error {
+		assert.Empty(t, c.Request().Header.Get(echo.HeaderXRequestID))
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderXRequestID, "forged")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	requestID := rec.Header().Get(echo.HeaderXRequestID)
+	assert.NotEmpty(t, requestID)
+	assert.NotEqual(t, "forged", requestID)
+}
+
+func TestIPExtractor(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.5:52114"
+	req.Header.Set(echo.HeaderXForwardedFor, "198.51.100.9, 203.0.113.7")
+
+	// without trusted proxies the forwarded header is ignored
+	extractIP, err := handler.IPExtractor(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.5", extractIP(req))
+
+	// the hops are believed only as far back as they are trusted proxies
+	extractIP, err = handler.IPExtractor([]string{"10.0.0.0/8"})
+	assert.NoError(t, err)
+	assert.Equal(t, "203.0.113.7", extractIP(req))
+
+	extractIP, err = handler.IPExtractor([]string{"10.0.0.0/8", " 203.0.113.0/24"})
+	assert.NoError(t, err)
+	assert.Equal(t, "198.51.100.9", extractIP(req))
+
+	_, err = handler.IPExtractor([]string{"10.0.0.5"})
+	assert.Error(t, err)
+}
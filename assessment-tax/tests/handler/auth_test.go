@@ -0,0 +1,144 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LGROW101/assessment-tax/handler"
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/repository"
+	"github.com/LGROW101/assessment-tax/service"
+	"github.com/LGROW101/assessment-tax/tests/service/mocks"
+	"github.com/golang-jwt/jwt"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockAuthService(ctrl)
+	authHandler := handler.NewAuthHandler(mockService)
+
+	mockService.EXPECT().Authenticate("viewer-token").Return(&service.AuthClaims{Role: model.RoleViewer}, nil).Times(2)
+	mockService.EXPECT().Authenticate("revoked-token").Return(nil, service.ErrInvalidToken)
+
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+
+	e := echo.New()
+	for _, tt := range []struct {
+		authorization string
+		role          string
+		code          int
+	}{
+		{"", model.RoleViewer, http.StatusUnauthorized},
+		{"Basic YWRtaW46YWRtaW4=", model.RoleViewer, http.StatusUnauthorized},
+		{"Bearer revoked-token", model.RoleViewer, http.StatusUnauthorized},
+		{"Bearer viewer-token", model.RoleTaxAdmin, http.StatusForbidden},
+		{"Bearer viewer-token", model.RoleViewer, http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/deductions", nil)
+		req.Header.Set(echo.HeaderAuthorization, tt.authorization)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := authHandler.RequireRole(tt.role)(ok)(c)
+		if tt.code == http.StatusOK {
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			continue
+		}
+		assert.Error(t, err, tt.authorization)
+		assert.Equal(t, tt.code, err.(*echo.HTTPError).Code, tt.authorization)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockAuthService(ctrl)
+	authHandler := handler.NewAuthHandler(mockService)
+
+	mockService.EXPECT().Login(model.LoginRequest{Username: "somchai", Password: "s3cret-pass"}).
+		Return(&model.LoginResponse{Token: "token", TokenType: "Bearer", Role: model.RoleTaxAdmin}, nil)
+	mockService.EXPECT().Login(model.LoginRequest{Username: "somchai", Password: "wrong"}).
+		Return(nil, service.ErrInvalidCredentials)
+
+	e := echo.New()
+	for password, code := range map[string]int{"s3cret-pass": http.StatusOK, "wrong": http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/login", strings.NewReader(`{"username":"somchai","password":"`+password+`"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := authHandler.Login(c)
+		if code == http.StatusOK {
+			assert.NoError(t, err)
+			assert.JSONEq(t, `{"token":"token","tokenType":"Bearer","expiresAt":"0001-01-01T00:00:00Z","role":"tax-admin"}`, rec.Body.String())
+			continue
+		}
+		assert.Equal(t, code, err.(*echo.HTTPError).Code)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockAuthService(ctrl)
+	authHandler := handler.NewAuthHandler(mockService)
+
+	claims := &service.AuthClaims{Role: model.RoleViewer, StandardClaims: jwt.StandardClaims{Id: "t1"}}
+	mockService.EXPECT().Authenticate("token").Return(claims, nil)
+	mockService.EXPECT().Logout(claims).Return(nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/admin/logout", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer token")
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err := authHandler.RequireRole(model.RoleViewer)(authHandler.Logout)(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func TestCreateUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockAuthService(ctrl)
+	authHandler := handler.NewAuthHandler(mockService)
+
+	mockService.EXPECT().CreateUser(model.AdminUserRequest{Username: "malee", Password: "long-enough", Role: model.RoleViewer}, gomock.Any()).
+		Return(&model.AdminUser{ID: 3, Username: "malee", PasswordHash: "hash", Role: model.RoleViewer}, nil)
+	mockService.EXPECT().CreateUser(model.AdminUserRequest{Username: "somchai", Password: "long-enough", Role: model.RoleViewer}, gomock.Any()).
+		Return(nil, repository.ErrUsernameTaken)
+
+	e := echo.New()
+	for body, code := range map[string]int{
+		`{"username":" malee ","password":"long-enough","role":"viewer"}`:  http.StatusCreated,
+		`{"username":"somchai","password":"long-enough","role":"viewer"}`:  http.StatusConflict,
+		`{"username":"malee","password":"short","role":"viewer"}`:          http.StatusBadRequest,
+		`{"username":"malee","password":"long-enough","role":"superuser"}`: http.StatusBadRequest,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/admin/users", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := authHandler.CreateUser(c)
+		if code == http.StatusCreated {
+			assert.NoError(t, err)
+			assert.Equal(t, code, rec.Code)
+			assert.NotContains(t, rec.Body.String(), "hash")
+			continue
+		}
+		assert.Equal(t, code, err.(*echo.HTTPError).Code, body)
+	}
+}
@@ -4,12 +4,16 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/LGROW101/assessment-tax/handler"
 	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/service"
 	"github.com/LGROW101/assessment-tax/tests/service/mocks"
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
@@ -23,23 +27,24 @@ func TestCalculateTax(t *testing.T) {
 	mockService := mocks.NewMockTaxCalculatorService(ctrl)
 	calculatorHandler := handler.NewCalculatorHandler(mockService)
 
-	totalIncome := 1000000.0
-	wht := 50000.0
+	totalIncome := model.Baht(1000000)
+	wht := model.Baht(50000)
 	allowances := []model.Allowance{
-		{AllowanceType: "allowance1", Amount: 10000.0},
-		{AllowanceType: "allowance2", Amount: 20000.0},
+		{AllowanceType: "allowance1", Amount: model.Baht(10000)},
+		{AllowanceType: "allowance2", Amount: model.Baht(20000)},
 	}
 	includeTaxLevel := true
 
-	expectedTax := 100000.0
+	expectedTax := model.Baht(100000)
 	expectedTaxResponse := &model.TaxCalculationResponse{
 		Tax: &expectedTax,
+		WHT: wht,
 		TaxLevel: []model.TaxRate{
-			{Level: "Level 2", Tax: 10000.0},
+			{Level: "Level 2", Tax: model.Baht(10000)},
 		},
 	}
 
-	mockService.EXPECT().CalculateTax(totalIncome, wht, allowances).Return(expectedTaxResponse, nil)
+	mockService.EXPECT().CalculateTax(model.TaxCalculationRequest{TotalIncome: totalIncome, WHT: wht, Allowances: allowances}).Return(expectedTaxResponse, nil)
 
 	reqBody, _ := json.Marshal(handler.CalculateTaxRequest{
 		TotalIncome:     totalIncome,
@@ -61,7 +66,8 @@ func TestCalculateTax(t *testing.T) {
 	var response map[string]interface{}
 	err = json.Unmarshal(rec.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Equal(t, expectedTax, response["tax"].(float64))
+	assert.Equal(t, expectedTax.Float64(), response["tax"].(float64))
+	assert.Equal(t, wht.Float64(), response["wht"].(float64))
 
 	taxLevelResponse, ok := response["taxLevel"].([]interface{})
 	assert.True(t, ok)
@@ -69,7 +75,7 @@ func TestCalculateTax(t *testing.T) {
 
 	taxRate := taxLevelResponse[0].(map[string]interface{})
 	assert.Equal(t, expectedTaxResponse.TaxLevel[0].Level, taxRate["level"])
-	assert.Equal(t, expectedTaxResponse.TaxLevel[0].Tax, taxRate["tax"])
+	assert.Equal(t, expectedTaxResponse.TaxLevel[0].Tax.Float64(), taxRate["tax"])
 }
 
 func TestCalculateTaxWithInvalidRequestBody(t *testing.T) {
@@ -80,7 +86,7 @@ func TestCalculateTaxWithInvalidRequestBody(t *testing.T) {
 	calculatorHandler := handler.NewCalculatorHandler(mockService)
 
 	// Add this line to set an empty expectation for CalculateTax
-	mockService.EXPECT().CalculateTax(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	mockService.EXPECT().CalculateTax(gomock.Any()).Times(0)
 
 	invalidReqBody := []byte(`{"invalidField": "invalid"}`)
 
@@ -104,20 +110,21 @@ func TestGetAllCalculations(t *testing.T) {
 
 	expectedCalculations := []*model.TaxCalculation{
 		{
-			TaxPayable: 100000.0,
+			TaxPayable: model.Baht(100000),
 			TaxLevel: []model.TaxRate{
-				{Level: "Level 1", Tax: 5000.0},
+				{Level: "Level 1", Tax: model.Baht(5000)},
 			},
 		},
 		{
-			TaxPayable: 200000.0,
+			TaxPayable: model.Baht(200000),
 			TaxLevel: []model.TaxRate{
-				{Level: "Level 2", Tax: 10000.0},
+				{Level: "Level 2", Tax: model.Baht(10000)},
 			},
 		},
 	}
 
-	mockService.EXPECT().GetAllCalculations().Return(expectedCalculations, nil)
+	mockService.EXPECT().GetAllCalculations(model.TaxCalculationFilter{Sort: "createdAt", Order: "desc", Limit: model.DefaultCalculationPageSize}).
+		Return(expectedCalculations, "", nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/calculations", nil)
 	rec := httptest.NewRecorder()
@@ -132,7 +139,136 @@ func TestGetAllCalculations(t *testing.T) {
 	err = json.Unmarshal(rec.Body.Bytes(), &response)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedCalculations, response)
+	assert.Empty(t, rec.Header().Get("Link"))
 }
+
+func TestGetAllCalculationsExport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockTaxCalculatorService(ctrl)
+	calculatorHandler := handler.NewCalculatorHandler(mockService)
+
+	calculations := []*model.TaxCalculation{
+		{
+			ID:          3,
+			TaxYear:     2024,
+			TotalIncome: model.Baht(500000),
+			TaxLevel:    []model.TaxRate{{Level: "150,001-500,000", Tax: model.Baht(29000)}},
+			Tax:         model.Baht(29000),
+			TaxPayable:  model.Baht(29000),
+			CreatedAt:   time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC),
+		},
+	}
+	mockService.EXPECT().GetAllCalculations(gomock.Any()).Return(calculations, "", nil).Times(3)
+
+	e := echo.New()
+	for accept, contentType := range map[string]string{
+		"text/csv":                         "text/csv; charset=utf-8",
+		service.ExportXLSX:                 service.ExportXLSX,
+		"application/json, text/csv;q=0.5": echo.MIMEApplicationJSON,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/tax/calculations", nil)
+		req.Header.Set(echo.HeaderAccept, accept)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := calculatorHandler.GetAllCalculations(c)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, contentType, rec.Header().Get(echo.HeaderContentType), accept)
+
+		if accept == "text/csv" {
+			assert.Equal(t, `attachment; filename="tax-calculations.csv"`, rec.Header().Get(echo.HeaderContentDisposition))
+			assert.Equal(t, "id,createdAt,taxYear,totalIncome,wht,expenses,personalAllowance,donation,kReceipt,taxableIncome,"+
+				"\"tax 150,001-500,000\",tax,taxPayable,taxRefund\n"+
+				"3,2024-05-01T10:00:00Z,2024,500000.00,0.00,0.00,0.00,0.00,0.00,0.00,29000.00,29000.00,29000.00,0.00\n", rec.Body.String())
+		}
+	}
+}
+
+func TestGetAllCalculationsWithFilters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockTaxCalculatorService(ctrl)
+	calculatorHandler := handler.NewCalculatorHandler(mockService)
+
+	from := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)
+	minIncome := model.Baht(100000)
+	expectedFilter := model.TaxCalculationFilter{
+		CreatedFrom: &from,
+		CreatedTo:   &to,
+		MinIncome:   &minIncome,
+		Type:        "refund",
+		TaxYear:     2024,
+		Sort:        "totalIncome",
+		Order:       "asc",
+		Limit:       2,
+	}
+	mockService.EXPECT().GetAllCalculations(expectedFilter).
+		Return([]*model.TaxCalculation{{ID: 1}, {ID: 2}}, "next-page", nil)
+
+	target := "/tax/calculations?from=2024-01-01&to=2024-01-31&minIncome=100000&type=refund&taxYear=2024&sort=totalIncome&order=asc&limit=2"
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	err := calculatorHandler.GetAllCalculations(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "next-page", rec.Header().Get("X-Next-Cursor"))
+	assert.Contains(t, rec.Header().Get("Link"), "cursor=next-page")
+	assert.Contains(t, rec.Header().Get("Link"), "sort=totalIncome")
+	assert.Contains(t, rec.Header().Get("Link"), `rel="next"`)
+}
+
+func TestGetAllCalculationsWithTimestampOffset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockTaxCalculatorService(ctrl)
+	calculatorHandler := handler.NewCalculatorHandler(mockService)
+
+	mockService.EXPECT().GetAllCalculations(gomock.Any()).
+		DoAndReturn(func(filter model.TaxCalculationFilter) ([]*model.TaxCalculation, string, error) {
+			assert.Equal(t, time.Date(2023, time.December, 31, 17, 0, 0, 0, time.UTC), *filter.CreatedFrom)
+			return []*model.TaxCalculation{}, "", nil
+		})
+
+	req := httptest.NewRequest(http.MethodGet, "/tax/calculations?from="+url.QueryEscape("2024-01-01T00:00:00+07:00"), nil)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	err := calculatorHandler.GetAllCalculations(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestGetAllCalculationsWithInvalidFilters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockTaxCalculatorService(ctrl)
+	calculatorHandler := handler.NewCalculatorHandler(mockService)
+	mockService.EXPECT().GetAllCalculations(gomock.Any()).Return(nil, "", model.ErrInvalidCursor)
+
+	e := echo.New()
+	for _, query := range []string{"limit=0", "limit=1000", "sort=wht", "order=up", "type=all", "from=yesterday", "minIncome=abc", "minIncome=10&maxIncome=5", "cursor=bogus"} {
+		req := httptest.NewRequest(http.MethodGet, "/tax/calculations?"+query, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := calculatorHandler.GetAllCalculations(c)
+		if assert.Error(t, err, query) {
+			assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code, query)
+		}
+	}
+}
+
 func TestCalculateTaxWithoutTaxLevel(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -140,20 +276,20 @@ func TestCalculateTaxWithoutTaxLevel(t *testing.T) {
 	mockService := mocks.NewMockTaxCalculatorService(ctrl)
 	calculatorHandler := handler.NewCalculatorHandler(mockService)
 
-	totalIncome := 1000000.0
-	wht := 50000.0
+	totalIncome := model.Baht(1000000)
+	wht := model.Baht(50000)
 	allowances := []model.Allowance{
-		{AllowanceType: "allowance1", Amount: 10000.0},
-		{AllowanceType: "allowance2", Amount: 20000.0},
+		{AllowanceType: "allowance1", Amount: model.Baht(10000)},
+		{AllowanceType: "allowance2", Amount: model.Baht(20000)},
 	}
 	includeTaxLevel := false
 
-	expectedTax := 100000.0
+	expectedTax := model.Baht(100000)
 	expectedTaxResponse := &model.TaxCalculationResponse{
 		Tax: &expectedTax,
 	}
 
-	mockService.EXPECT().CalculateTax(totalIncome, wht, allowances).Return(expectedTaxResponse, nil)
+	mockService.EXPECT().CalculateTax(model.TaxCalculationRequest{TotalIncome: totalIncome, WHT: wht, Allowances: allowances}).Return(expectedTaxResponse, nil)
 
 	reqBody, _ := json.Marshal(handler.CalculateTaxRequest{
 		TotalIncome:     totalIncome,
@@ -175,8 +311,9 @@ func TestCalculateTaxWithoutTaxLevel(t *testing.T) {
 	var response map[string]interface{}
 	err = json.Unmarshal(rec.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Equal(t, expectedTax, response["tax"].(float64))
+	assert.Equal(t, expectedTax.Float64(), response["tax"].(float64))
 	assert.Nil(t, response["taxLevel"])
+	assert.Nil(t, response["wht"])
 }
 func TestGetAllCalculationsWithServiceError(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -185,7 +322,7 @@ func TestGetAllCalculationsWithServiceError(t *testing.T) {
 	mockService := mocks.NewMockTaxCalculatorService(ctrl)
 	calculatorHandler := handler.NewCalculatorHandler(mockService)
 
-	mockService.EXPECT().GetAllCalculations().Return(nil, errors.New("service error"))
+	mockService.EXPECT().GetAllCalculations(gomock.Any()).Return(nil, "", errors.New("service error"))
 
 	req := httptest.NewRequest(http.MethodGet, "/calculations", nil)
 	rec := httptest.NewRecorder()
@@ -205,9 +342,14 @@ func TestCalculateTaxWithInvalidRequestValues(t *testing.T) {
 	calculatorHandler := handler.NewCalculatorHandler(mockService)
 
 	invalidReqBodies := []handler.CalculateTaxRequest{
-		{TotalIncome: -1000, WHT: 50000, Allowances: []model.Allowance{{AllowanceType: "allowance1", Amount: 10000}}},
-		{TotalIncome: 1000000, WHT: -50000, Allowances: []model.Allowance{{AllowanceType: "allowance1", Amount: 10000}}},
-		{TotalIncome: 1000000, WHT: 50000, Allowances: []model.Allowance{}},
+		{TotalIncome: -1000, WHT: model.Baht(50000), Allowances: []model.Allowance{{AllowanceType: "allowance1", Amount: model.Baht(10000)}}},
+		{TotalIncome: model.Baht(1000000), WHT: -50000, Allowances: []model.Allowance{{AllowanceType: "allowance1", Amount: model.Baht(10000)}}},
+		{TotalIncome: model.Baht(1000000), WHT: model.Baht(50000), Allowances: []model.Allowance{}},
+		{TotalIncome: model.Baht(1000000), WHT: model.Baht(50000), Allowances: []model.Allowance{{AllowanceType: "", Amount: model.Baht(10000)}}},
+		{TotalIncome: model.Baht(1000000), WHT: model.Baht(50000), Allowances: []model.Allowance{{AllowanceType: "donation", Amount: -10000}}},
+		{TotalIncome: model.Baht(1000000), WHT: model.Baht(50000), Allowances: []model.Allowance{{AllowanceType: "child", Count: -1}}},
+		{Incomes: []model.Income{{Category: "40(9)", Amount: model.Baht(10000)}}, Allowances: []model.Allowance{{AllowanceType: "donation"}}},
+		{TotalIncome: model.Baht(1000000), Incomes: []model.Income{{Category: model.IncomeSalary, Amount: model.Baht(900000)}}, Allowances: []model.Allowance{{AllowanceType: "donation"}}},
 	}
 
 	for _, reqBody := range invalidReqBodies {
@@ -231,13 +373,13 @@ func TestCalculateTaxWithServiceError(t *testing.T) {
 	mockService := mocks.NewMockTaxCalculatorService(ctrl)
 	calculatorHandler := handler.NewCalculatorHandler(mockService)
 
-	totalIncome := 1000000.0
-	wht := 50000.0
+	totalIncome := model.Baht(1000000)
+	wht := model.Baht(50000)
 	allowances := []model.Allowance{
-		{AllowanceType: "allowance1", Amount: 10000.0},
+		{AllowanceType: "allowance1", Amount: model.Baht(10000)},
 	}
 
-	mockService.EXPECT().CalculateTax(totalIncome, wht, allowances).Return(nil, errors.New("service error"))
+	mockService.EXPECT().CalculateTax(model.TaxCalculationRequest{TotalIncome: totalIncome, WHT: wht, Allowances: allowances}).Return(nil, errors.New("service error"))
 
 	reqBody, _ := json.Marshal(handler.CalculateTaxRequest{
 		TotalIncome: totalIncome,
@@ -255,3 +397,334 @@ func TestCalculateTaxWithServiceError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, http.StatusInternalServerError, err.(*echo.HTTPError).Code)
 }
+
+func TestCalculateTaxWithTaxYear(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockTaxCalculatorService(ctrl)
+	calculatorHandler := handler.NewCalculatorHandler(mockService)
+
+	allowances := []model.Allowance{{AllowanceType: "donation", Amount: model.Baht(0)}}
+	mockService.EXPECT().CalculateTax(model.TaxCalculationRequest{TaxYear: 2023, TotalIncome: model.Baht(500000), Allowances: allowances}).
+		Return(nil, fmt.Errorf("tax year 2023: %w", service.ErrRuleSetNotFound))
+
+	reqBody, _ := json.Marshal(handler.CalculateTaxRequest{
+		TaxYear:     2023,
+		TotalIncome: model.Baht(500000),
+		Allowances:  allowances,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/calculate-tax", bytes.NewBuffer(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	err := calculatorHandler.CalculateTax(c)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+}
+
+func TestCalculateTaxWithUnavailableRuleSet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockTaxCalculatorService(ctrl)
+	calculatorHandler := handler.NewCalculatorHandler(mockService)
+
+	mockService.EXPECT().CalculateTax(gomock.Any()).
+		Return(nil, fmt.Errorf("%w: %w", service.ErrRuleSetUnavailable, service.ErrTaxBracketsNotConfigured))
+
+	reqBody, _ := json.Marshal(handler.CalculateTaxRequest{
+		TotalIncome: model.Baht(500000),
+		Allowances:  []model.Allowance{{AllowanceType: "donation", Amount: model.Baht(0)}},
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/calculate-tax", bytes.NewBuffer(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	err := calculatorHandler.CalculateTax(c)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, err.(*echo.HTTPError).Code)
+}
+
+func TestCalculateTaxWithUnknownAllowance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockTaxCalculatorService(ctrl)
+	calculatorHandler := handler.NewCalculatorHandler(mockService)
+
+	allowances := []model.Allowance{{AllowanceType: "lottery", Amount: model.Baht(1000)}}
+	mockService.EXPECT().CalculateTax(model.TaxCalculationRequest{TotalIncome: model.Baht(500000), Allowances: allowances}).
+		Return(nil, fmt.Errorf("%w: %q", service.ErrUnknownAllowanceType, "lottery"))
+
+	reqBody, _ := json.Marshal(handler.CalculateTaxRequest{
+		TotalIncome: model.Baht(500000),
+		Allowances:  allowances,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/calculate-tax", bytes.NewBuffer(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	err := calculatorHandler.CalculateTax(c)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+	assert.Contains(t, err.(*echo.HTTPError).Message, "lottery")
+}
+
+func TestGetCalculation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockTaxCalculatorService(ctrl)
+	calculatorHandler := handler.NewCalculatorHandler(mockService)
+
+	expectedCalculation := &model.TaxCalculation{
+		ID:             7,
+		TaxYear:        2024,
+		RuleSetID:      3,
+		RuleSetVersion: 2,
+		TotalIncome:    model.Baht(500000),
+		TaxableIncome:  model.Baht(440000),
+		Tax:            model.Baht(29000),
+		TaxPayable:     model.Baht(29000),
+		TaxLevel: []model.TaxRate{
+			{Level: "0-150,000", Tax: model.Baht(0)},
+			{Level: "150,001-500,000", Tax: model.Baht(29000)},
+		},
+		Allowances: []model.Allowance{{AllowanceType: "donation", Amount: model.Baht(0)}},
+		Request: model.TaxCalculationRequest{
+			TotalIncome: model.Baht(500000),
+			Allowances:  []model.Allowance{{AllowanceType: "donation", Amount: model.Baht(0)}},
+		},
+	}
+	mockService.EXPECT().GetCalculation(uint(7)).Return(expectedCalculation, nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/tax/calculations/7", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("7")
+
+	err := calculatorHandler.GetCalculation(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response *model.TaxCalculation
+	err = json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedCalculation, response)
+}
+
+func TestGetCalculationNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockTaxCalculatorService(ctrl)
+	calculatorHandler := handler.NewCalculatorHandler(mockService)
+
+	mockService.EXPECT().GetCalculation(uint(99)).Return(nil, nil)
+
+	e := echo.New()
+	for id, code := range map[string]int{"99": http.StatusNotFound, "abc": http.StatusBadRequest} {
+		req := httptest.NewRequest(http.MethodGet, "/tax/calculations/"+id, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+
+		err := calculatorHandler.GetCalculation(c)
+		assert.Error(t, err)
+		assert.Equal(t, code, err.(*echo.HTTPError).Code)
+	}
+}
+
+func TestCalculateTaxWithIncomes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockTaxCalculatorService(ctrl)
+	calculatorHandler := handler.NewCalculatorHandler(mockService)
+
+	incomes := []model.Income{
+		{Category: model.IncomeSalary, Amount: model.Baht(600000)},
+		{Category: model.IncomeRent, Amount: model.Baht(120000)},
+	}
+	allowances := []model.Allowance{{AllowanceType: "donation", Amount: model.Baht(0)}}
+	tax := model.Baht(27000)
+	mockService.EXPECT().CalculateTax(model.TaxCalculationRequest{TotalIncome: model.Baht(720000), Incomes: incomes, Allowances: allowances}).
+		Return(&model.TaxCalculationResponse{Tax: &tax}, nil)
+
+	reqBody := `{"incomes":[{"category":"40(1)","amount":600000},{"category":"40(5)","amount":120000}],"allowances":[{"allowanceType":"donation","amount":0}]}`
+	req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewBufferString(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	err := calculatorHandler.CalculateTax(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"tax":27000.00}`, rec.Body.String())
+}
+
+func TestCalculateTaxExplain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockTaxCalculatorService(ctrl)
+	calculatorHandler := handler.NewCalculatorHandler(mockService)
+
+	tax := model.Baht(29000)
+	allowances := []model.Allowance{{AllowanceType: "donation", Amount: 0}}
+	mockService.EXPECT().CalculateTax(model.TaxCalculationRequest{TotalIncome: model.Baht(500000), Allowances: allowances, Explain: true}).
+		Return(&model.TaxCalculationResponse{Tax: &tax, Explanation: []model.CalculationStep{
+			{Step: model.StepIncome, Amount: model.Baht(500000)},
+			{Step: model.StepTax, Amount: tax},
+		}}, nil)
+
+	reqBody := `{"totalIncome":500000,"wht":0,"allowances":[{"allowanceType":"donation","amount":0}]}`
+	req := httptest.NewRequest(http.MethodPost, "/tax/calculations?explain=true", bytes.NewBufferString(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	err := calculatorHandler.CalculateTax(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"tax":29000.00,"explanation":[{"step":"income","amount":500000.00},{"step":"tax","amount":29000.00}]}`, rec.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/tax/calculations?explain=maybe", bytes.NewBufferString(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	err = calculatorHandler.CalculateTax(e.NewContext(req, httptest.NewRecorder()))
+	if assert.Error(t, err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+	}
+}
+
+func TestGrossUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockTaxCalculatorService(ctrl)
+	calculatorHandler := handler.NewCalculatorHandler(mockService)
+
+	mockService.EXPECT().GrossUp(gomock.Any()).DoAndReturn(func(req model.GrossUpRequest) (*model.GrossUpResult, error) {
+		assert.Equal(t, model.Baht(471000), *req.TargetNet)
+		assert.Nil(t, req.TargetTax)
+		return &model.GrossUpResult{TotalIncome: model.Baht(500000), NetIncome: model.Baht(471000), Tax: model.Baht(29000)}, nil
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/tax/gross-up", bytes.NewBufferString(`{"targetNet":471000}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	err := calculatorHandler.GrossUp(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, 500000.0, response["totalIncome"])
+	assert.Equal(t, 29000.0, response["tax"])
+}
+
+func TestGrossUpWithInvalidRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockTaxCalculatorService(ctrl)
+	calculatorHandler := handler.NewCalculatorHandler(mockService)
+	mockService.EXPECT().GrossUp(gomock.Any()).Return(nil, service.ErrTargetUnreachable)
+
+	e := echo.New()
+	for _, reqBody := range []string{
+		`{}`,
+		`{"targetNet":100000,"targetTax":1000}`,
+		`{"targetNet":-1}`,
+		`{"targetNet":100000,"category":"40(9)"}`,
+		`{"targetTax":1000}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/tax/gross-up", bytes.NewBufferString(reqBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := calculatorHandler.GrossUp(c)
+		if assert.Error(t, err, reqBody) {
+			assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code, reqBody)
+		}
+	}
+}
+
+func TestCalculateHousehold(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockTaxCalculatorService(ctrl)
+	calculatorHandler := handler.NewCalculatorHandler(mockService)
+
+	mockService.EXPECT().CalculateHousehold(gomock.Any()).DoAndReturn(func(req model.HouseholdRequest) (*model.HouseholdResponse, error) {
+		assert.Len(t, req.Spouses, 2)
+		assert.Equal(t, model.Baht(1000000), req.Spouses[0].TotalIncome)
+		return &model.HouseholdResponse{
+			Separate:    model.HouseholdOption{Tax: model.Baht(101000)},
+			Joint:       model.HouseholdOption{Tax: model.Baht(92000)},
+			Recommended: model.FilingJoint,
+		}, nil
+	})
+
+	reqBody := `{"spouses":[{"totalIncome":1000000},{"totalIncome":0}]}`
+	req := httptest.NewRequest(http.MethodPost, "/tax/households", bytes.NewBufferString(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	err := calculatorHandler.CalculateHousehold(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, model.FilingJoint, response["recommended"])
+}
+
+func TestCalculateHouseholdWithInvalidRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockTaxCalculatorService(ctrl)
+	calculatorHandler := handler.NewCalculatorHandler(mockService)
+	mockService.EXPECT().CalculateHousehold(gomock.Any()).Return(nil, service.ErrUnknownAllowanceType)
+
+	e := echo.New()
+	for _, reqBody := range []string{
+		`{"spouses":[{"totalIncome":500000}]}`,
+		`{"spouses":[{"totalIncome":500000},{"totalIncome":-1}]}`,
+		`{"spouses":[{"totalIncome":500000},{"incomes":[{"category":"40(9)","amount":1}]}]}`,
+		`{"spouses":[{"totalIncome":500000},{"totalIncome":0,"allowances":[{"allowanceType":"unknown","amount":1}]}]}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/tax/households", bytes.NewBufferString(reqBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := calculatorHandler.CalculateHousehold(c)
+		if assert.Error(t, err, reqBody) {
+			assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code, reqBody)
+		}
+	}
+}
@@ -11,18 +11,22 @@ import (
 	"testing"
 
 	"github.com/LGROW101/assessment-tax/handler"
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/service"
 	"github.com/LGROW101/assessment-tax/tests/service/mocks"
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 )
 
+const uploadLimit = 1 << 20
+
 func TestUploadCSV(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockCSVService := mocks.NewMockTaxCSVService(ctrl)
-	csvHandler := handler.NewCSVHandler(mockCSVService)
+	csvHandler := handler.NewCSVHandler(mockCSVService, mocks.NewMockTaxJobService(ctrl), uploadLimit)
 
 	// Create a new HTTP request with multipart form data
 	body := new(bytes.Buffer)
@@ -30,6 +34,7 @@ func TestUploadCSV(t *testing.T) {
 	part, err := writer.CreateFormFile("taxFile", "test.csv")
 	assert.NoError(t, err)
 	part.Write([]byte("csv data"))
+	writer.WriteField("dryRun", "true")
 	writer.Close()
 
 	req := httptest.NewRequest(http.MethodPost, "/upload", body)
@@ -38,11 +43,11 @@ func TestUploadCSV(t *testing.T) {
 	e := echo.New()
 	c := e.NewContext(req, rec)
 
-	expectedTaxes := []map[string]float64{
-		{"totalIncome": 500000, "tax": 50000},
-		{"totalIncome": 1000000, "tax": 200000},
+	expectedTaxes := []map[string]model.Money{
+		{"totalIncome": model.Baht(500000), "tax": model.Baht(50000)},
+		{"totalIncome": model.Baht(1000000), "tax": model.Baht(200000)},
 	}
-	mockCSVService.EXPECT().ImportCSV(gomock.Any()).Return(expectedTaxes, nil)
+	mockCSVService.EXPECT().ImportCSV(gomock.Any()).Return(&model.CSVImportResult{Taxes: expectedTaxes}, nil)
 
 	err = csvHandler.UploadCSV(c)
 	assert.NoError(t, err)
@@ -69,7 +74,7 @@ func TestUploadCSVWithMissingFile(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockCSVService := mocks.NewMockTaxCSVService(ctrl)
-	csvHandler := handler.NewCSVHandler(mockCSVService)
+	csvHandler := handler.NewCSVHandler(mockCSVService, mocks.NewMockTaxJobService(ctrl), uploadLimit)
 
 	req := httptest.NewRequest(http.MethodPost, "/upload", nil)
 	rec := httptest.NewRecorder()
@@ -87,13 +92,14 @@ func TestUploadCSVWithReadError(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockCSVService := mocks.NewMockTaxCSVService(ctrl)
-	csvHandler := handler.NewCSVHandler(mockCSVService)
+	csvHandler := handler.NewCSVHandler(mockCSVService, mocks.NewMockTaxJobService(ctrl), uploadLimit)
 
 	body := new(bytes.Buffer)
 	writer := multipart.NewWriter(body)
 	part, err := writer.CreateFormFile("taxFile", "test.csv")
 	assert.NoError(t, err)
 	part.Write([]byte("csv data"))
+	writer.WriteField("dryRun", "true")
 	writer.Close()
 
 	req := httptest.NewRequest(http.MethodPost, "/upload", body)
@@ -118,8 +124,9 @@ func convertMapToFloat64(m map[string]interface{}) map[string]float64 {
 	return result
 }
 
-func assertEqualFloat64Maps(t *testing.T, expected, actual map[string]float64) {
-	for k, v := range expected {
+func assertEqualFloat64Maps(t *testing.T, expected map[string]model.Money, actual map[string]float64) {
+	for k, money := range expected {
+		v := money.Float64()
 		actualValue, ok := actual[k]
 		if !ok {
 			t.Errorf("Key %q not found in actual map", k)
@@ -137,13 +144,14 @@ func TestUploadCSVSuccess(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockCSVService := mocks.NewMockTaxCSVService(ctrl)
-	csvHandler := handler.NewCSVHandler(mockCSVService)
+	csvHandler := handler.NewCSVHandler(mockCSVService, mocks.NewMockTaxJobService(ctrl), uploadLimit)
 
 	body := new(bytes.Buffer)
 	writer := multipart.NewWriter(body)
 	part, err := writer.CreateFormFile("taxFile", "test.csv")
 	assert.NoError(t, err)
 	part.Write([]byte("csv data"))
+	writer.WriteField("dryRun", "true")
 	writer.Close()
 
 	req := httptest.NewRequest(http.MethodPost, "/upload", body)
@@ -152,11 +160,11 @@ func TestUploadCSVSuccess(t *testing.T) {
 	e := echo.New()
 	c := e.NewContext(req, rec)
 
-	expectedTaxes := []map[string]float64{
-		{"totalIncome": 500000, "tax": 50000},
-		{"totalIncome": 1000000, "tax": 200000},
+	expectedTaxes := []map[string]model.Money{
+		{"totalIncome": model.Baht(500000), "tax": model.Baht(50000)},
+		{"totalIncome": model.Baht(1000000), "tax": model.Baht(200000)},
 	}
-	mockCSVService.EXPECT().ImportCSV(gomock.Any()).Return(expectedTaxes, nil)
+	mockCSVService.EXPECT().ImportCSV(gomock.Any()).Return(&model.CSVImportResult{Taxes: expectedTaxes}, nil)
 
 	err = csvHandler.UploadCSV(c)
 	assert.NoError(t, err)
@@ -174,8 +182,8 @@ func TestUploadCSVSuccess(t *testing.T) {
 		taxMap, ok := tax.(map[string]interface{})
 		assert.True(t, ok)
 
-		assert.Equal(t, expectedTaxes[i]["totalIncome"], taxMap["totalIncome"])
-		assert.Equal(t, expectedTaxes[i]["tax"], taxMap["tax"])
+		assert.Equal(t, expectedTaxes[i]["totalIncome"].Float64(), taxMap["totalIncome"])
+		assert.Equal(t, expectedTaxes[i]["tax"].Float64(), taxMap["tax"])
 	}
 }
 
@@ -184,7 +192,7 @@ func TestUploadCSVWithoutFile(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockCSVService := mocks.NewMockTaxCSVService(ctrl)
-	csvHandler := handler.NewCSVHandler(mockCSVService)
+	csvHandler := handler.NewCSVHandler(mockCSVService, mocks.NewMockTaxJobService(ctrl), uploadLimit)
 
 	req := httptest.NewRequest(http.MethodPost, "/upload", nil)
 	rec := httptest.NewRecorder()
@@ -202,13 +210,14 @@ func TestUploadCSVServiceError(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockCSVService := mocks.NewMockTaxCSVService(ctrl)
-	csvHandler := handler.NewCSVHandler(mockCSVService)
+	csvHandler := handler.NewCSVHandler(mockCSVService, mocks.NewMockTaxJobService(ctrl), uploadLimit)
 
 	body := new(bytes.Buffer)
 	writer := multipart.NewWriter(body)
 	part, err := writer.CreateFormFile("taxFile", "test.csv")
 	assert.NoError(t, err)
 	part.Write([]byte("csv data"))
+	writer.WriteField("dryRun", "true")
 	writer.Close()
 
 	req := httptest.NewRequest(http.MethodPost, "/upload", body)
@@ -224,3 +233,202 @@ func TestUploadCSVServiceError(t *testing.T) {
 	assert.IsType(t, &echo.HTTPError{}, err)
 	assert.Equal(t, http.StatusInternalServerError, err.(*echo.HTTPError).Code)
 }
+
+func TestUploadCSVWithRowErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCSVService := mocks.NewMockTaxCSVService(ctrl)
+	csvHandler := handler.NewCSVHandler(mockCSVService, mocks.NewMockTaxJobService(ctrl), uploadLimit)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("taxFile", "test.csv")
+	assert.NoError(t, err)
+	part.Write([]byte("csv data"))
+	writer.WriteField("dryRun", "true")
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	mockCSVService.EXPECT().ImportCSV(gomock.Any()).Return(&model.CSVImportResult{
+		Taxes:  []map[string]model.Money{{"totalIncome": model.Baht(500000), "tax": model.Baht(29000)}},
+		Errors: []model.CSVRowError{{Row: 3, Column: "wht", Reason: "must not be negative"}},
+		DryRun: true,
+	}, nil)
+
+	err = csvHandler.UploadCSV(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"taxes": [{"totalIncome": 500000.00, "tax": 29000.00}],
+		"errors": [{"row": 3, "column": "wht", "reason": "must not be negative"}],
+		"dryRun": true
+	}`, rec.Body.String())
+}
+
+func TestUploadCSVWithNoValidRows(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCSVService := mocks.NewMockTaxCSVService(ctrl)
+	csvHandler := handler.NewCSVHandler(mockCSVService, mocks.NewMockTaxJobService(ctrl), uploadLimit)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("taxFile", "test.csv")
+	assert.NoError(t, err)
+	part.Write([]byte("csv data"))
+	writer.WriteField("dryRun", "true")
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	mockCSVService.EXPECT().ImportCSV(gomock.Any()).Return(&model.CSVImportResult{
+		Taxes:  []map[string]model.Money{},
+		Errors: []model.CSVRowError{{Row: 2, Column: "totalIncome", Reason: `invalid amount "abc"`}},
+	}, nil)
+
+	err = csvHandler.UploadCSV(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.JSONEq(t, `{
+		"message": "No valid rows in file",
+		"errors": [{"row": 2, "column": "totalIncome", "reason": "invalid amount \"abc\""}]
+	}`, rec.Body.String())
+}
+
+func TestUploadCSVQueuesJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCSVService := mocks.NewMockTaxCSVService(ctrl)
+	mockJobService := mocks.NewMockTaxJobService(ctrl)
+	csvHandler := handler.NewCSVHandler(mockCSVService, mockJobService, uploadLimit)
+
+	content := "totalIncome,wht,donation\n500000,0,0\n"
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("taxFile", "payroll.csv")
+	assert.NoError(t, err)
+	part.Write([]byte(content))
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	mockCSVService.EXPECT().CheckHeader(gomock.Any()).Return(nil)
+	mockJobService.EXPECT().Submit("payroll.csv", []byte(content)).
+		Return(&model.TaxUpload{ID: 42, Filename: "payroll.csv", Status: model.UploadQueued}, nil)
+
+	err = csvHandler.UploadCSV(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, "/tax/jobs/42", rec.Header().Get(echo.HeaderLocation))
+
+	var response map[string]interface{}
+	err = json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(42), response["id"])
+	assert.Equal(t, "queued", response["status"])
+}
+
+func TestUploadCSVTooLarge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	csvHandler := handler.NewCSVHandler(mocks.NewMockTaxCSVService(ctrl), mocks.NewMockTaxJobService(ctrl), 64)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("taxFile", "payroll.csv")
+	assert.NoError(t, err)
+	part.Write(bytes.Repeat([]byte("500000,0,0\n"), 100))
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+	rec := httptest.NewRecorder()
+
+	err = csvHandler.UploadCSV(echo.New().NewContext(req, rec))
+	if assert.Error(t, err) {
+		assert.Equal(t, http.StatusRequestEntityTooLarge, err.(*echo.HTTPError).Code)
+	}
+}
+
+func TestUploadCSVWithMissingColumn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCSVService := mocks.NewMockTaxCSVService(ctrl)
+	mockJobService := mocks.NewMockTaxJobService(ctrl)
+	csvHandler := handler.NewCSVHandler(mockCSVService, mockJobService, uploadLimit)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("taxFile", "payroll.csv")
+	assert.NoError(t, err)
+	part.Write([]byte("totalIncome,donation\n500000,0\n"))
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	mockCSVService.EXPECT().CheckHeader(gomock.Any()).Return(errors.New("missing required column(s): wht"))
+	mockJobService.EXPECT().Submit(gomock.Any(), gomock.Any()).Times(0)
+
+	err = csvHandler.UploadCSV(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "missing required column(s): wht")
+}
+
+func TestUploadCSVDryRunExport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCSVService := mocks.NewMockTaxCSVService(ctrl)
+	csvHandler := handler.NewCSVHandler(mockCSVService, mocks.NewMockTaxJobService(ctrl), uploadLimit)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("taxFile", "payroll.csv")
+	assert.NoError(t, err)
+	part.Write([]byte("csv data"))
+	writer.WriteField("dryRun", "true")
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", body)
+	req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+	req.Header.Set(echo.HeaderAccept, service.ExportXLSX)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	mockCSVService.EXPECT().ImportCSV(gomock.Any()).Return(&model.CSVImportResult{
+		Taxes:        []map[string]model.Money{{"totalIncome": model.Baht(500000), "tax": model.Baht(29000)}},
+		Calculations: []*model.TaxCalculation{{UploadRow: 2, TotalIncome: model.Baht(500000), TaxPayable: model.Baht(29000)}},
+		DryRun:       true,
+	}, nil)
+
+	err = csvHandler.UploadCSV(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, service.ExportXLSX, rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, `attachment; filename="payroll-result.xlsx"`, rec.Header().Get(echo.HeaderContentDisposition))
+	assert.Equal(t, "PK", rec.Body.String()[:2])
+}
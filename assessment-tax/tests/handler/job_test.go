@@ -0,0 +1,125 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LGROW101/assessment-tax/handler"
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/service"
+	"github.com/LGROW101/assessment-tax/tests/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockTaxJobService(ctrl)
+	jobHandler := handler.NewJobHandler(mockService)
+
+	mockService.EXPECT().GetJob(uint(4)).Return(&model.TaxUpload{
+		ID:             4,
+		Filename:       "payroll.csv",
+		Status:         model.UploadCompleted,
+		RowCount:       3,
+		ProcessedCount: 3,
+		ErrorCount:     1,
+	}, nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/tax/jobs/4", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("4")
+
+	err := jobHandler.GetJob(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response map[string]interface{}
+	err = json.Unmarshal(rec.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "completed", response["status"])
+	assert.Equal(t, float64(3), response["processedCount"])
+	assert.Equal(t, float64(1), response["errorCount"])
+	assert.Equal(t, "/tax/jobs/4/result", response["resultUrl"])
+}
+
+func TestGetJobNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockTaxJobService(ctrl)
+	jobHandler := handler.NewJobHandler(mockService)
+
+	mockService.EXPECT().GetJob(uint(99)).Return(nil, nil)
+
+	e := echo.New()
+	for id, code := range map[string]int{"99": http.StatusNotFound, "abc": http.StatusBadRequest} {
+		req := httptest.NewRequest(http.MethodGet, "/tax/jobs/"+id, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+
+		err := jobHandler.GetJob(c)
+		assert.Error(t, err)
+		assert.Equal(t, code, err.(*echo.HTTPError).Code)
+	}
+}
+
+func TestGetJobResult(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockTaxJobService(ctrl)
+	jobHandler := handler.NewJobHandler(mockService)
+
+	mockService.EXPECT().GetJob(uint(4)).Return(&model.TaxUpload{ID: 4, Status: model.UploadCompleted}, nil)
+	mockService.EXPECT().WriteResult(uint(4), service.ExportCSV, gomock.Any()).DoAndReturn(func(id uint, format string, w io.Writer) error {
+		_, err := io.WriteString(w, "row,totalIncome,tax,taxRefund,error\n2,500000.00,29000.00,0.00,\n")
+		return err
+	})
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/tax/jobs/4/result", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("4")
+
+	err := jobHandler.GetJobResult(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+	assert.Contains(t, rec.Header().Get(echo.HeaderContentDisposition), "job-4-result.csv")
+	assert.Equal(t, "row,totalIncome,tax,taxRefund,error\n2,500000.00,29000.00,0.00,\n", rec.Body.String())
+}
+
+func TestGetJobResultNotCompleted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockTaxJobService(ctrl)
+	jobHandler := handler.NewJobHandler(mockService)
+
+	mockService.EXPECT().GetJob(uint(5)).Return(&model.TaxUpload{ID: 5, Status: model.UploadRunning}, nil)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/tax/jobs/5/result", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("5")
+
+	err := jobHandler.GetJobResult(c)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusConflict, err.(*echo.HTTPError).Code)
+}
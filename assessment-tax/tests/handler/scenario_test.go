@@ -0,0 +1,92 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LGROW101/assessment-tax/handler"
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/service"
+	"github.com/LGROW101/assessment-tax/tests/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareScenarios(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockScenarioService(ctrl)
+	scenarioHandler := handler.NewScenarioHandler(mockService)
+
+	mockService.EXPECT().Compare(gomock.Any()).DoAndReturn(func(req model.ScenarioRequest) (*model.ScenarioComparison, error) {
+		assert.Equal(t, model.Baht(700000), req.Base.TotalIncome)
+		assert.Len(t, req.Variations, 1)
+		assert.Equal(t, "rmf", req.Variations[0].Allowances[0].AllowanceType)
+		return &model.ScenarioComparison{
+			Base: model.ScenarioResult{Name: "base", Tax: model.Baht(48500), MarginalRate: model.Percent(15)},
+			Variations: []model.ScenarioResult{{
+				Name:         "More RMF",
+				Tax:          model.Baht(34000),
+				MarginalRate: model.Percent(10),
+				Delta:        &model.ScenarioDelta{Tax: model.Baht(-14500)},
+			}},
+		}, nil
+	})
+
+	reqBody := `{"base":{"totalIncome":700000,"allowances":[{"allowanceType":"rmf","amount":50000}]},
+		"variations":[{"name":"More RMF","allowances":[{"allowanceType":"rmf","amount":100000}]}]}`
+	req := httptest.NewRequest(http.MethodPost, "/tax/scenarios", strings.NewReader(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	err := scenarioHandler.CompareScenarios(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Base       map[string]interface{}   `json:"base"`
+		Variations []map[string]interface{} `json:"variations"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.NotContains(t, response.Base, "delta")
+	assert.Equal(t, 0.15, response.Base["marginalRate"])
+	assert.Equal(t, -14500.0, response.Variations[0]["delta"].(map[string]interface{})["tax"])
+}
+
+func TestCompareScenariosWithInvalidRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockScenarioService(ctrl)
+	scenarioHandler := handler.NewScenarioHandler(mockService)
+	mockService.EXPECT().Compare(gomock.Any()).Return(nil, fmt.Errorf("Next year: %w", service.ErrRuleSetNotFound))
+
+	e := echo.New()
+	for _, reqBody := range []string{
+		`{"base":{"totalIncome":500000},"variations":[]}`,
+		`{"base":{"totalIncome":500000},"variations":[{"extraIncome":-1}]}`,
+		`{"base":{"totalIncome":500000},"variations":[{"incomes":[{"category":"40(1)","amount":1}]}]}`,
+		`{"base":{"totalIncome":500000},"variations":[{"allowances":[{"allowanceType":"","amount":1}]}]}`,
+		`{"base":{"totalIncome":500000,"ruleSetVersion":2},"variations":[{"extraIncome":1}]}`,
+		`{"base":{"totalIncome":500000},"variations":[{"ruleSetVersion":2}]}`,
+		`{"base":{"totalIncome":500000},"variations":[{"name":"Next year","taxYear":2030}]}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/tax/scenarios", strings.NewReader(reqBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := scenarioHandler.CompareScenarios(c)
+		if assert.Error(t, err, reqBody) {
+			assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code, reqBody)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// withholding_test
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LGROW101/assessment-tax/handler"
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/service"
+	"github.com/LGROW101/assessment-tax/tests/service/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateWithholding(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockWithholdingService(ctrl)
+	withholdingHandler := handler.NewWithholdingHandler(mockService, uploadLimit)
+
+	mockService.EXPECT().Calculate(gomock.Any()).DoAndReturn(func(req model.WithholdingRequest) (*model.WithholdingResult, error) {
+		assert.Equal(t, 7, req.Month)
+		assert.Equal(t, model.Baht(50000), req.Salary)
+		assert.Equal(t, model.Baht(12000), req.YTDWithheld)
+		return &model.WithholdingResult{Month: 7, AnnualTax: model.Baht(29000), Withholding: model.Money(283333), TrueUp: model.Money(41666)}, nil
+	})
+
+	reqBody := `{"month":7,"salary":50000,"ytdIncome":300000,"ytdWithheld":12000}`
+	req := httptest.NewRequest(http.MethodPost, "/tax/withholding", bytes.NewBufferString(reqBody))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e := echo.New()
+	c := e.NewContext(req, rec)
+
+	err := withholdingHandler.CalculateWithholding(c)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, 2833.33, response["withholding"])
+	assert.Equal(t, 416.66, response["trueUp"])
+}
+
+func TestCalculateWithholdingWithInvalidRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockWithholdingService(ctrl)
+	withholdingHandler := handler.NewWithholdingHandler(mockService, uploadLimit)
+	mockService.EXPECT().Calculate(gomock.Any()).Return(nil, service.ErrRuleSetNotFound)
+
+	e := echo.New()
+	for _, reqBody := range []string{
+		`{"month":0,"salary":50000}`,
+		`{"month":13,"salary":50000}`,
+		`{"month":1,"salary":-1}`,
+		`{"month":1,"salary":50000,"allowances":[{"allowanceType":"","amount":1}]}`,
+		`{"taxYear":1999,"month":1,"salary":50000}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/tax/withholding", bytes.NewBufferString(reqBody))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		err := withholdingHandler.CalculateWithholding(c)
+		if assert.Error(t, err, reqBody) {
+			assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code, reqBody)
+		}
+	}
+}
+
+func payrollUpload(t *testing.T) (*bytes.Buffer, string) {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("payrollFile", "payroll.csv")
+	assert.NoError(t, err)
+	part.Write([]byte("csv data"))
+	writer.Close()
+	return body, writer.FormDataContentType()
+}
+
+func TestUploadWithholdingCSV(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockWithholdingService(ctrl)
+	withholdingHandler := handler.NewWithholdingHandler(mockService, uploadLimit)
+	mockService.EXPECT().ImportCSV(gomock.Any()).Return(&model.WithholdingRun{
+		Results: []model.WithholdingResult{{Row: 2, EmployeeID: "E1", Month: 1, Withholding: model.Money(241667)}},
+		Errors:  []model.CSVRowError{{Row: 3, Column: "month", Reason: "must be a month from 1 to 12"}},
+	}, nil).Times(2)
+
+	e := echo.New()
+	body, contentType := payrollUpload(t)
+	req := httptest.NewRequest(http.MethodPost, "/tax/withholding/upload-csv", body)
+	req.Header.Set(echo.HeaderContentType, contentType)
+	rec := httptest.NewRecorder()
+
+	err := withholdingHandler.UploadWithholdingCSV(e.NewContext(req, rec))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response model.WithholdingRun
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Len(t, response.Results, 1)
+	assert.Len(t, response.Errors, 1)
+
+	body, contentType = payrollUpload(t)
+	req = httptest.NewRequest(http.MethodPost, "/tax/withholding/upload-csv", body)
+	req.Header.Set(echo.HeaderContentType, contentType)
+	req.Header.Set(echo.HeaderAccept, "text/csv")
+	rec = httptest.NewRecorder()
+
+	err = withholdingHandler.UploadWithholdingCSV(e.NewContext(req, rec))
+	assert.NoError(t, err)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get(echo.HeaderContentType))
+	assert.Contains(t, rec.Header().Get(echo.HeaderContentDisposition), `filename="payroll-withholding.csv"`)
+}
+
+func TestUploadWithholdingCSVWithNoValidRows(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockWithholdingService(ctrl)
+	withholdingHandler := handler.NewWithholdingHandler(mockService, uploadLimit)
+	mockService.EXPECT().ImportCSV(gomock.Any()).Return(&model.WithholdingRun{
+		Results: []model.WithholdingResult{},
+		Errors:  []model.CSVRowError{{Row: 1, Reason: "missing required column(s): salary"}},
+	}, nil)
+
+	body, contentType := payrollUpload(t)
+	req := httptest.NewRequest(http.MethodPost, "/tax/withholding/upload-csv", body)
+	req.Header.Set(echo.HeaderContentType, contentType)
+	rec := httptest.NewRecorder()
+
+	err := withholdingHandler.UploadWithholdingCSV(echo.New().NewContext(req, rec))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestUploadWithholdingCSVTooLarge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	withholdingHandler := handler.NewWithholdingHandler(mocks.NewMockWithholdingService(ctrl), 64)
+
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("payrollFile", "payroll.csv")
+	assert.NoError(t, err)
+	part.Write(bytes.Repeat([]byte("E1,1,50000\n"), 100))
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/tax/withholding/upload-csv", body)
+	req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+	rec := httptest.NewRecorder()
+
+	err = withholdingHandler.UploadWithholdingCSV(echo.New().NewContext(req, rec))
+	if assert.Error(t, err) {
+		assert.Equal(t, http.StatusRequestEntityTooLarge, err.(*echo.HTTPError).Code)
+	}
+}
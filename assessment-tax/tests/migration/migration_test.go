@@ -0,0 +1,99 @@
+package migration_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/LGROW101/assessment-tax/databases/migration"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersions(t *testing.T) {
+	versions, err := migration.Versions()
+
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, versions) {
+		assert.Equal(t, uint(2), versions[0])
+		for i := 1; i < len(versions); i++ {
+			assert.Equal(t, versions[i-1]+1, versions[i], "migrations must be numbered without gaps")
+		}
+	}
+}
+
+// TestUpDownUp applies every migration, reverts them all and applies them
+// again on the database of TEST_DATABASE_URL, which it leaves migrated. Other
+// packages testing on the same database must not run alongside (go test -p 1).
+func TestUpDownUp(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	versions, err := migration.Versions()
+	assert.NoError(t, err)
+	latest := versions[len(versions)-1]
+
+	assert.NoError(t, migration.Up(databaseURL))
+	status, err := migration.GetStatus(databaseURL)
+	assert.NoError(t, err)
+	assert.Equal(t, &migration.Status{Version: latest, Pending: []uint{}}, status)
+
+	// reverting every migration keeps the record of them, so the database
+	// reads as never migrated rather than failing to open
+	assert.NoError(t, migration.Down(databaseURL, len(versions)))
+	status, err = migration.GetStatus(databaseURL)
+	assert.NoError(t, err)
+	assert.Equal(t, &migration.Status{Version: 0, Pending: versions}, status)
+
+	assert.NoError(t, migration.Up(databaseURL))
+	status, err = migration.GetStatus(databaseURL)
+	assert.NoError(t, err)
+	assert.Equal(t, &migration.Status{Version: latest, Pending: []uint{}}, status)
+}
+
+// TestDeductionsBackfill applies 000018 to a database that already has
+// calculations, which the trigger keeping them immutable must not stop.
+func TestDeductionsBackfill(t *testing.T) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	assert.NoError(t, migration.Up(databaseURL))
+	versions, err := migration.Versions()
+	assert.NoError(t, err)
+	// revert down to 000017, the version before the deductions column
+	var steps int
+	for _, version := range versions {
+		if version > 17 {
+			steps++
+		}
+	}
+	assert.NoError(t, migration.Down(databaseURL, steps))
+
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		t.Fatalf("opening the test database: %v", err)
+	}
+	defer db.Close()
+
+	var id int
+	err = db.QueryRow(`INSERT INTO tax_calculations (totalIncome, donation, k_receipt) VALUES (500000.00, 10000.00, 0.00) RETURNING id`).Scan(&id)
+	if err != nil {
+		t.Fatalf("inserting a calculation: %v", err)
+	}
+	defer db.Exec(`DELETE FROM tax_calculations WHERE id = $1`, id)
+
+	assert.NoError(t, migration.Up(databaseURL))
+	status, err := migration.GetStatus(databaseURL)
+	assert.NoError(t, err)
+	assert.False(t, status.Dirty)
+
+	var deductions string
+	assert.NoError(t, db.QueryRow(`SELECT deductions FROM tax_calculations WHERE id = $1`, id).Scan(&deductions))
+	assert.JSONEq(t, `{"donation": 10000.00}`, deductions)
+
+	// the rows are immutable again afterwards
+	_, err = db.Exec(`UPDATE tax_calculations SET tax = 1 WHERE id = $1`, id)
+	assert.ErrorContains(t, err, "immutable")
+}
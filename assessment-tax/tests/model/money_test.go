@@ -0,0 +1,114 @@
+package model_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected model.Money
+		hasError bool
+	}{
+		{"Whole baht", "29000", model.Baht(29000), false},
+		{"Satang", "1234.56", model.Money(123456), false},
+		{"Exponent", "1e5", 0, true},
+		{"Fraction", "1/3", 0, true},
+		{"Round half up", "2.345", model.Money(235), false},
+		{"Round down", "2.344", model.Money(234), false},
+		{"Negative rounds away from zero", "-2.345", model.Money(-235), false},
+		{"Invalid", "abc", 0, true},
+		{"Out of range", "100000000000000000000", 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			money, err := model.ParseMoney(tc.input)
+			if tc.hasError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, money)
+			}
+		})
+	}
+}
+
+func TestParseMoneyHidesHugeAmounts(t *testing.T) {
+	_, err := model.ParseMoney("1e400000")
+	assert.EqualError(t, err, `invalid amount "1e400000"`)
+
+	_, err = model.ParseMoney("1" + strings.Repeat("0", 400000))
+	assert.EqualError(t, err, "amount is out of range")
+}
+
+func TestMoney_MulRate(t *testing.T) {
+	assert.Equal(t, model.Baht(29000), model.Baht(290000).MulRate(model.Percent(10)))
+	assert.Equal(t, model.Money(2), model.Money(15).MulRate(model.Percent(10)))
+	assert.Equal(t, model.Money(1), model.Money(14).MulRate(model.Percent(10)))
+	assert.Equal(t, model.Money(-2), model.Money(-15).MulRate(model.Percent(10)))
+}
+
+func TestMoney_Div(t *testing.T) {
+	assert.Equal(t, model.Baht(2500), model.Baht(30000).Div(12))
+	assert.Equal(t, model.Money(241667), model.Baht(29000).Div(12))
+	assert.Equal(t, model.Money(3), model.Money(5).Div(2))
+	assert.Equal(t, model.Money(-3), model.Money(-5).Div(2))
+}
+
+func TestMoney_JSON(t *testing.T) {
+	var payload struct {
+		Income model.Money `json:"income"`
+		WHT    model.Money `json:"wht"`
+		Rate   model.Rate  `json:"rate"`
+	}
+	err := json.Unmarshal([]byte(`{"income": 500000.10, "wht": "0.2", "rate": 0.15}`), &payload)
+	assert.NoError(t, err)
+	assert.Equal(t, model.Money(50000010), payload.Income)
+	assert.Equal(t, model.Money(20), payload.WHT)
+	assert.Equal(t, model.Percent(15), payload.Rate)
+
+	data, err := json.Marshal(payload)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"income": 500000.10, "wht": 0.20, "rate": 0.15}`, string(data))
+	assert.Contains(t, string(data), `"income":500000.10`)
+}
+
+func TestMoney_Scan(t *testing.T) {
+	var money model.Money
+	assert.NoError(t, money.Scan([]byte("29000.50")))
+	assert.Equal(t, model.Money(2900050), money)
+	assert.NoError(t, money.Scan(int64(5)))
+	assert.Equal(t, model.Baht(5), money)
+	assert.NoError(t, money.Scan(nil))
+	assert.Equal(t, model.Money(0), money)
+	assert.Error(t, money.Scan(true))
+
+	value, err := model.Money(-5).Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "-0.05", value)
+
+	var rate model.Rate
+	assert.NoError(t, rate.Scan([]byte("0.3500")))
+	assert.Equal(t, model.Percent(35), rate)
+	assert.Equal(t, "0.35", rate.String())
+}
+
+func TestParsePercent(t *testing.T) {
+	rate, err := model.ParsePercent("10")
+	assert.NoError(t, err)
+	assert.Equal(t, model.Percent(10), rate)
+
+	rate, err = model.ParsePercent(" 12.5 ")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.125", rate.String())
+
+	_, err = model.ParsePercent("ten")
+	assert.Error(t, err)
+}
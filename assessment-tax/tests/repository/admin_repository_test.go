@@ -1,15 +1,19 @@
 package repository_test
 
 import (
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/LGROW101/assessment-tax/model"
 	"github.com/LGROW101/assessment-tax/repository"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
+var adminConfigColumns = []string{"id", "tax_year", "version", "personal_deduction", "k_receipt", "donation_cap", "effective_from", "effective_to", "allowance_types", "created_at", "updated_at"}
+
 func TestAdminRepository_GetConfig(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -19,33 +23,83 @@ func TestAdminRepository_GetConfig(t *testing.T) {
 
 	repo := repository.NewAdminRepository(db)
 
-	rows := sqlmock.NewRows([]string{"id", "personal_deduction", "k_receipt", "created_at", "updated_at"})
-	mock.ExpectQuery("^SELECT id, personal_deduction, k_receipt, created_at, updated_at FROM admin_configs ORDER BY id DESC LIMIT 1$").WillReturnRows(rows)
+	rows := sqlmock.NewRows(adminConfigColumns)
+	mock.ExpectQuery("^SELECT (.+) FROM admin_configs WHERE effective_from <= CURRENT_DATE AND \\(effective_to IS NULL OR effective_to >= CURRENT_DATE\\) ORDER BY effective_from DESC, version DESC LIMIT 1$").WillReturnRows(rows)
 
-	config, err := repo.GetConfig()
+	config, err := repo.GetConfig(0)
 	assert.NoError(t, err)
 	assert.Nil(t, config)
 
+	effectiveFrom := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	childAllowance := model.Baht(30000)
 	createdAt := time.Now().Add(-24 * time.Hour)
 	updatedAt := time.Now()
-	rows = sqlmock.NewRows([]string{"id", "personal_deduction", "k_receipt", "created_at", "updated_at"}).
-		AddRow(1, 60000.0, 30000.0, createdAt, updatedAt)
-	mock.ExpectQuery("^SELECT id, personal_deduction, k_receipt, created_at, updated_at FROM admin_configs ORDER BY id DESC LIMIT 1$").WillReturnRows(rows)
+	rows = sqlmock.NewRows(adminConfigColumns).
+		AddRow(1, 2024, 2, "60000.00", "30000.00", "100000.00", effectiveFrom, nil, []byte(`[{"key":"child","displayName":"บุตร","unitAmount":30000.00,"stage":"before"}]`), createdAt, updatedAt)
+	mock.ExpectQuery("^SELECT (.+) FROM admin_configs WHERE tax_year = \\$1 ORDER BY version DESC LIMIT 1$").
+		WithArgs(2024).
+		WillReturnRows(rows)
+	mock.ExpectQuery("^SELECT id, threshold, rate FROM tax_brackets WHERE admin_config_id = \\$1 ORDER BY threshold$").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "threshold", "rate"}).
+			AddRow(1, "0.00", "0.0000").
+			AddRow(2, "150000.00", "0.1000"))
 
 	expectedConfig := &model.AdminConfig{
 		ID:                1,
-		PersonalDeduction: 60000.0,
-		KReceipt:          30000.0,
-		CreatedAt:         createdAt,
-		UpdatedAt:         updatedAt,
+		TaxYear:           2024,
+		Version:           2,
+		PersonalDeduction: model.Baht(60000),
+		KReceipt:          model.Baht(30000),
+		DonationCap:       model.Baht(100000),
+		EffectiveFrom:     effectiveFrom,
+		Brackets: []model.TaxBracket{
+			{ID: 1, Threshold: model.Baht(0), Rate: model.Percent(0)},
+			{ID: 2, Threshold: model.Baht(150000), Rate: model.Percent(10)},
+		},
+		AllowanceTypes: []*model.AllowanceType{
+			{Key: "child", DisplayName: "บุตร", UnitAmount: &childAllowance, Stage: model.AllowanceStageBefore},
+		},
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
 	}
 
-	config, err = repo.GetConfig()
+	config, err = repo.GetConfig(2024)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedConfig, config)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAdminRepository_GetConfigSkipsExpired(t *testing.T) {
+	db := testDatabase(t)
+	repo := repository.NewAdminRepository(db)
+
+	// a version of this year that took effect yesterday and already ended
+	var expiredID uint
+	err := db.QueryRow(`
+        INSERT INTO admin_configs (tax_year, version, personal_deduction, k_receipt, donation_cap, effective_from, effective_to, allowance_types)
+        SELECT tax_year, MAX(version) + 1, 60000.00, 50000.00, 100000.00, CURRENT_DATE - 1, CURRENT_DATE - 1, '[]'
+        FROM admin_configs
+        WHERE tax_year = EXTRACT(YEAR FROM CURRENT_DATE)::INTEGER
+        GROUP BY tax_year
+        RETURNING id
+    `).Scan(&expiredID)
+	if err != nil {
+		t.Fatalf("inserting the expired rule set: %v", err)
+	}
+	t.Cleanup(func() { db.Exec(`DELETE FROM admin_configs WHERE id = $1`, expiredID) })
+
+	config, err := repo.GetConfig(0)
+	assert.NoError(t, err)
+	if assert.NotNil(t, config) {
+		assert.NotEqual(t, expiredID, config.ID)
+		if config.EffectiveTo != nil {
+			assert.False(t, config.EffectiveTo.Before(time.Now().Truncate(24*time.Hour)))
+		}
+	}
 }
 
-func TestAdminRepository_UpdateConfig(t *testing.T) {
+func TestAdminRepository_GetConfigVersion(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
@@ -54,18 +108,64 @@ func TestAdminRepository_UpdateConfig(t *testing.T) {
 
 	repo := repository.NewAdminRepository(db)
 
-	config := &model.AdminConfig{
-		PersonalDeduction: 70000.0,
-		KReceipt:          40000.0,
+	mock.ExpectQuery("^SELECT (.+) FROM admin_configs WHERE tax_year = \\$1 AND version = \\$2$").
+		WithArgs(2024, 9).
+		WillReturnRows(sqlmock.NewRows(adminConfigColumns))
+
+	config, err := repo.GetConfigVersion(2024, 9)
+	assert.NoError(t, err)
+	assert.Nil(t, config)
+
+	effectiveFrom := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("^SELECT (.+) FROM admin_configs WHERE tax_year = \\$1 AND version = \\$2$").
+		WithArgs(2024, 1).
+		WillReturnRows(sqlmock.NewRows(adminConfigColumns).
+			AddRow(4, 2024, 1, "60000.00", "50000.00", "100000.00", effectiveFrom, nil, []byte(`[]`), effectiveFrom, effectiveFrom))
+	mock.ExpectQuery("^SELECT id, threshold, rate FROM tax_brackets WHERE admin_config_id = \\$1 ORDER BY threshold$").
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "threshold", "rate"}).AddRow(7, "0.00", "0.0000"))
+
+	config, err = repo.GetConfigVersion(2024, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(4), config.ID)
+	assert.Equal(t, 1, config.Version)
+	assert.Len(t, config.Brackets, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAdminRepository_ListConfigs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 	}
+	defer db.Close()
+
+	repo := repository.NewAdminRepository(db)
 
-	mock.ExpectExec("^UPDATE admin_configs SET personal_deduction = \\$1, k_receipt = \\$2, updated_at = NOW\\(\\) WHERE id = 1$").
-		WithArgs(config.PersonalDeduction, config.KReceipt).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	effectiveFrom := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Now()
+	rows := sqlmock.NewRows(adminConfigColumns).
+		AddRow(2, 2024, 2, 70000.0, 50000.0, 100000.0, effectiveFrom, nil, []byte(`[]`), now, now).
+		AddRow(1, 2024, 1, 60000.0, 50000.0, 100000.0, effectiveFrom, nil, []byte(`[]`), now, now)
+	mock.ExpectQuery("^SELECT (.+) FROM admin_configs WHERE \\$1 = 0 OR tax_year = \\$1 ORDER BY tax_year DESC, version DESC$").
+		WithArgs(2024).
+		WillReturnRows(rows)
+	mock.ExpectQuery("^SELECT id, threshold, rate FROM tax_brackets").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "threshold", "rate"}).AddRow(3, 0.0, 0.0))
+	mock.ExpectQuery("^SELECT id, threshold, rate FROM tax_brackets").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "threshold", "rate"}).AddRow(1, 0.0, 0.0))
 
-	err = repo.UpdateConfig(config)
+	configs, err := repo.ListConfigs(2024)
 	assert.NoError(t, err)
+	assert.Len(t, configs, 2)
+	assert.Equal(t, 2, configs[0].Version)
+	assert.Equal(t, []model.TaxBracket{{ID: 3}}, configs[0].Brackets)
+	assert.Equal(t, 1, configs[1].Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
 func TestAdminRepository_InsertConfig(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -75,15 +175,105 @@ func TestAdminRepository_InsertConfig(t *testing.T) {
 
 	repo := repository.NewAdminRepository(db)
 
+	effectiveFrom := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
 	config := &model.AdminConfig{
-		PersonalDeduction: 60000.0,
-		KReceipt:          30000.0,
+		TaxYear:           2024,
+		Version:           2,
+		PersonalDeduction: model.Baht(60000),
+		KReceipt:          model.Baht(30000),
+		DonationCap:       model.Baht(100000),
+		EffectiveFrom:     effectiveFrom,
+		Brackets: []model.TaxBracket{
+			{Threshold: model.Baht(0), Rate: model.Percent(0)},
+			{Threshold: model.Baht(150000), Rate: model.Percent(10)},
+		},
 	}
 
-	mock.ExpectExec("^INSERT INTO admin_configs \\(personal_deduction, k_receipt\\) VALUES \\(\\$1, \\$2\\)$").
-		WithArgs(config.PersonalDeduction, config.KReceipt).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("^SELECT (.+) FROM allowance_types t LEFT JOIN allowance_groups g ON g.key = t.group_key ORDER BY t.key$").
+		WillReturnRows(sqlmock.NewRows(allowanceTypeColumns).
+			AddRow("child", "บุตร", nil, nil, "30000.00", nil, "", nil, "before", now, now))
+	mock.ExpectQuery("^INSERT INTO admin_configs \\(tax_year, version, personal_deduction, k_receipt, donation_cap, effective_from, effective_to, allowance_types\\) SELECT \\$1, \\$2 \\+ 1, (.+) WHERE \\(SELECT COALESCE\\(MAX\\(version\\), 0\\) FROM admin_configs WHERE tax_year = \\$1\\) = \\$2").
+		WithArgs(2024, 2, model.Baht(60000), model.Baht(30000), model.Baht(100000), effectiveFrom, nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version", "created_at", "updated_at"}).AddRow(7, 3, now, now))
+	mock.ExpectQuery("^INSERT INTO tax_brackets \\(admin_config_id, threshold, rate\\) VALUES \\(\\$1, \\$2, \\$3\\) RETURNING id$").
+		WithArgs(7, model.Money(0), model.Rate(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	mock.ExpectQuery("^INSERT INTO tax_brackets").
+		WithArgs(7, model.Baht(150000), model.Percent(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(12))
+	mock.ExpectCommit()
 
-	err = repo.InsertConfig(config)
+	err = repo.InsertConfig(config, nil)
 	assert.NoError(t, err)
+	assert.Equal(t, uint(7), config.ID)
+	assert.Equal(t, 3, config.Version)
+	assert.Equal(t, uint(12), config.Brackets[1].ID)
+	assert.Len(t, config.AllowanceTypes, 1)
+	assert.Equal(t, "child", config.AllowanceTypes[0].Key)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAdminRepository_InsertConfigRollback(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewAdminRepository(db)
+
+	config := &model.AdminConfig{
+		TaxYear:        2024,
+		Brackets:       []model.TaxBracket{{Threshold: model.Baht(0), Rate: model.Percent(0)}},
+		AllowanceTypes: []*model.AllowanceType{},
+	}
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("^INSERT INTO admin_configs").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version", "created_at", "updated_at"}).AddRow(7, 1, now, now))
+	mock.ExpectQuery("^INSERT INTO tax_brackets").
+		WillReturnError(errors.New("database error"))
+	mock.ExpectRollback()
+
+	err = repo.InsertConfig(config, nil)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAdminRepository_InsertConfigVersionConflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewAdminRepository(db)
+
+	config := &model.AdminConfig{
+		TaxYear:        2024,
+		Version:        1,
+		Brackets:       []model.TaxBracket{{Threshold: model.Baht(0), Rate: model.Percent(0)}},
+		AllowanceTypes: []*model.AllowanceType{},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^INSERT INTO admin_configs").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version", "created_at", "updated_at"}))
+	mock.ExpectRollback()
+
+	err = repo.InsertConfig(config, nil)
+	assert.ErrorIs(t, err, repository.ErrVersionConflict)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^INSERT INTO admin_configs").
+		WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectRollback()
+
+	err = repo.InsertConfig(config, nil)
+	assert.ErrorIs(t, err, repository.ErrVersionConflict)
+	assert.Equal(t, 1, config.Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -0,0 +1,238 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/repository"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var allowanceTypeColumns = []string{"key", "display_name", "cap_amount", "cap_rate", "unit_amount", "max_units", "group_key", "group_cap", "stage", "created_at", "updated_at"}
+
+func TestAllowanceRepository_ListAllowanceTypes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewAllowanceRepository(db)
+
+	now := time.Now()
+	mock.ExpectQuery("^SELECT (.+) FROM allowance_types t LEFT JOIN allowance_groups g ON g.key = t.group_key ORDER BY t.key$").
+		WillReturnRows(sqlmock.NewRows(allowanceTypeColumns).
+			AddRow("child", "บุตร", nil, nil, "30000.00", nil, "", nil, "before", now, now).
+			AddRow("provident-fund", "กองทุนสำรองเลี้ยงชีพ", "500000.00", "0.1500", nil, nil, "retirement", "500000.00", "before", now, now))
+
+	allowanceTypes, err := repo.ListAllowanceTypes()
+	assert.NoError(t, err)
+	assert.Len(t, allowanceTypes, 2)
+	assert.Nil(t, allowanceTypes[0].CapAmount)
+	assert.Nil(t, allowanceTypes[0].CapRate)
+	assert.Equal(t, model.Baht(30000), *allowanceTypes[0].UnitAmount)
+	assert.Nil(t, allowanceTypes[0].MaxUnits)
+	assert.Nil(t, allowanceTypes[0].GroupCap)
+	assert.Equal(t, model.Baht(500000), *allowanceTypes[1].CapAmount)
+	assert.Equal(t, model.Percent(15), *allowanceTypes[1].CapRate)
+	assert.Equal(t, "retirement", allowanceTypes[1].Group)
+	assert.Equal(t, model.Baht(500000), *allowanceTypes[1].GroupCap)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAllowanceRepository_SaveAllowanceType(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewAllowanceRepository(db)
+
+	capRate := model.Percent(15)
+	allowanceType := &model.AllowanceType{Key: "provident-fund", DisplayName: "กองทุนสำรองเลี้ยงชีพ", CapRate: &capRate, Group: "retirement", Stage: model.AllowanceStageBefore}
+	audit := &model.AuditEntry{Actor: "somchai", Action: model.AuditAllowanceUpdate, Resource: "allowance_type"}
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("^INSERT INTO allowance_types \\(key, display_name, cap_amount, cap_rate, unit_amount, max_units, group_key, stage\\) VALUES (.+) ON CONFLICT \\(key\\) DO UPDATE").
+		WithArgs("provident-fund", "กองทุนสำรองเลี้ยงชีพ", nil, &capRate, nil, nil, "retirement", "before").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at", "cap_amount"}).AddRow(now, now, "500000.00"))
+	// the open rule set gets a new version carrying the changed catalogue
+	effectiveFrom := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("^SELECT DISTINCT ON \\(tax_year\\) (.+) FROM admin_configs WHERE tax_year >= COALESCE(.+) ORDER BY tax_year, version DESC$").
+		WillReturnRows(sqlmock.NewRows(adminConfigColumns).
+			AddRow(3, 2024, 2, "60000.00", "50000.00", "100000.00", effectiveFrom, nil, []byte(`[]`), now, now))
+	mock.ExpectQuery("^SELECT id, threshold, rate FROM tax_brackets").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "threshold", "rate"}).AddRow(5, "0.00", "0.0000"))
+	mock.ExpectQuery("^SELECT (.+) FROM allowance_types t").
+		WillReturnRows(sqlmock.NewRows(allowanceTypeColumns).
+			AddRow("provident-fund", "กองทุนสำรองเลี้ยงชีพ", "500000.00", "0.1500", nil, nil, "retirement", "500000.00", "before", now, now))
+	mock.ExpectQuery("^INSERT INTO admin_configs").
+		WithArgs(2024, 2, model.Baht(60000), model.Baht(50000), model.Baht(100000), effectiveFrom, nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version", "created_at", "updated_at"}).AddRow(4, 3, now, now))
+	mock.ExpectQuery("^INSERT INTO tax_brackets").
+		WithArgs(4, model.Money(0), model.Rate(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(6))
+	mock.ExpectQuery("^INSERT INTO admin_audit").
+		WithArgs("somchai", model.AuditAllowanceUpdate, "allowance_type", "provident-fund", "null", sqlmock.AnyArg(), "", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(42, now))
+	mock.ExpectCommit()
+
+	err = repo.SaveAllowanceType(allowanceType, audit)
+	assert.NoError(t, err)
+	assert.Equal(t, now, allowanceType.UpdatedAt)
+	assert.Equal(t, model.Baht(500000), *allowanceType.GroupCap)
+	assert.Equal(t, uint(42), audit.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAllowanceRepository_SaveAllowanceTypeUnchanged(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewAllowanceRepository(db)
+
+	allowanceType := &model.AllowanceType{Key: "provident-fund", DisplayName: "กองทุนสำรองเลี้ยงชีพ", Group: "retirement", Stage: model.AllowanceStageBefore}
+
+	created := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery("^INSERT INTO allowance_types (.+) WHERE (.+) IS DISTINCT FROM (.+) RETURNING").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at", "cap_amount"}))
+	// nothing changed, so the rule sets are not reissued
+	mock.ExpectQuery("^SELECT t.created_at, t.updated_at, g.cap_amount FROM allowance_types t (.+) WHERE t.key = \\$1$").
+		WithArgs("provident-fund").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at", "cap_amount"}).AddRow(created, created, "500000.00"))
+	mock.ExpectCommit()
+
+	err = repo.SaveAllowanceType(allowanceType, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, created, allowanceType.UpdatedAt)
+	assert.Equal(t, model.Baht(500000), *allowanceType.GroupCap)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAllowanceRepository_SaveAllowanceTypeUnknownGroup(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewAllowanceRepository(db)
+
+	allowanceType := &model.AllowanceType{Key: "rmf", DisplayName: "RMF", Group: "pension", Stage: model.AllowanceStageBefore}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^INSERT INTO allowance_types").
+		WillReturnError(&pq.Error{Code: "23503"})
+	mock.ExpectRollback()
+
+	err = repo.SaveAllowanceType(allowanceType, nil)
+	assert.ErrorIs(t, err, repository.ErrUnknownAllowanceGroup)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAllowanceRepository_SaveAllowanceGroup(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewAllowanceRepository(db)
+
+	group := &model.AllowanceGroup{Key: "retirement", DisplayName: "เงินออมเพื่อการเกษียณ", CapAmount: model.Baht(500000)}
+	audit := &model.AuditEntry{Actor: "somchai", Action: model.AuditAllowanceGroupUpdate, Resource: "allowance_group"}
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("^WITH previous AS (.+) INSERT INTO allowance_groups \\(key, display_name, cap_amount\\) VALUES (.+) ON CONFLICT \\(key\\) DO UPDATE").
+		WithArgs("retirement", "เงินออมเพื่อการเกษียณ", model.Baht(500000)).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at", "cap_amount"}).AddRow(now, now, "400000.00"))
+	// the cap changed, so the open rule sets get a new version
+	mock.ExpectQuery("^SELECT DISTINCT ON \\(tax_year\\) (.+) FROM admin_configs").
+		WillReturnRows(sqlmock.NewRows(adminConfigColumns))
+	mock.ExpectQuery("^INSERT INTO admin_audit").
+		WithArgs("somchai", model.AuditAllowanceGroupUpdate, "allowance_group", "retirement", "null", sqlmock.AnyArg(), "", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(43, now))
+	mock.ExpectCommit()
+
+	err = repo.SaveAllowanceGroup(group, audit)
+	assert.NoError(t, err)
+	assert.Equal(t, now, group.CreatedAt)
+	assert.Equal(t, uint(43), audit.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAllowanceRepository_SaveAllowanceGroupRenamed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewAllowanceRepository(db)
+
+	group := &model.AllowanceGroup{Key: "retirement", DisplayName: "เงินออมเพื่อเกษียณ", CapAmount: model.Baht(500000)}
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("^WITH previous AS (.+) INSERT INTO allowance_groups").
+		WithArgs("retirement", "เงินออมเพื่อเกษียณ", model.Baht(500000)).
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "updated_at", "cap_amount"}).AddRow(now, now, "500000.00"))
+	mock.ExpectCommit()
+
+	err = repo.SaveAllowanceGroup(group, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAllowanceRepository_DeleteAllowanceType(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewAllowanceRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("^DELETE FROM allowance_types WHERE key = \\$1$").
+		WithArgs("sports").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("^SELECT DISTINCT ON \\(tax_year\\) (.+) FROM admin_configs").
+		WillReturnRows(sqlmock.NewRows(adminConfigColumns))
+	mock.ExpectCommit()
+
+	err = repo.DeleteAllowanceType("sports", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAllowanceRepository_DeleteMissingAllowanceType(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewAllowanceRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("^DELETE FROM allowance_types WHERE key = \\$1$").
+		WithArgs("sports").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err = repo.DeleteAllowanceType("sports", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,98 @@
+package repository_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+var auditColumns = []string{"id", "actor", "action", "resource", "resource_id", "old_value", "new_value", "client_ip", "request_id", "created_at"}
+
+func TestAuditRepository_ListEntries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewAuditRepository(db)
+
+	from := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Now()
+	mock.ExpectQuery("^SELECT (.+) FROM admin_audit WHERE actor = \\$1 AND action = \\$2 AND created_at >= \\$3 AND id < \\$4 ORDER BY id DESC LIMIT \\$5$").
+		WithArgs("somchai", model.AuditDeductionsUpdate, from, 40, 3).
+		WillReturnRows(sqlmock.NewRows(auditColumns).
+			AddRow(39, "somchai", model.AuditDeductionsUpdate, "admin_config", "5", []byte(`{"Version":1}`), []byte(`{"Version":2}`), "203.0.113.7", "req-2", now).
+			AddRow(35, "somchai", model.AuditDeductionsUpdate, "admin_config", "5", nil, []byte(`{"Version":1}`), "203.0.113.7", "req-1", now).
+			AddRow(31, "somchai", model.AuditDeductionsUpdate, "admin_config", "4", nil, []byte(`{}`), "203.0.113.7", "", now))
+
+	entries, nextCursor, err := repo.ListEntries(model.AuditFilter{
+		Actor:  "somchai",
+		Action: model.AuditDeductionsUpdate,
+		From:   &from,
+		Cursor: 40,
+		Limit:  2,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "35", nextCursor)
+	assert.JSONEq(t, `{"Version":1}`, string(entries[0].OldValue))
+	assert.Equal(t, json.RawMessage("null"), entries[1].OldValue)
+	assert.Equal(t, "req-1", entries[1].RequestID)
+
+	mock.ExpectQuery("^SELECT (.+) FROM admin_audit ORDER BY id DESC LIMIT \\$1$").
+		WithArgs(model.DefaultAuditPageSize + 1).
+		WillReturnRows(sqlmock.NewRows(auditColumns))
+
+	entries, nextCursor, err = repo.ListEntries(model.AuditFilter{})
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+	assert.Empty(t, nextCursor)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAdminRepository_InsertConfigWithAudit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewAdminRepository(db)
+
+	config := &model.AdminConfig{
+		TaxYear:        2024,
+		Brackets:       []model.TaxBracket{{Threshold: model.Baht(0), Rate: model.Percent(0)}},
+		AllowanceTypes: []*model.AllowanceType{},
+	}
+	audit := &model.AuditEntry{
+		Actor:     "somchai",
+		Action:    model.AuditDeductionsUpdate,
+		Resource:  "admin_config",
+		ClientIP:  "203.0.113.7",
+		RequestID: "req-1",
+	}
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("^INSERT INTO admin_configs").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version", "created_at", "updated_at"}).AddRow(7, 2, now, now))
+	mock.ExpectQuery("^INSERT INTO tax_brackets").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+	mock.ExpectQuery("^INSERT INTO admin_audit \\(actor, action, resource, resource_id, old_value, new_value, client_ip, request_id\\)").
+		WithArgs("somchai", model.AuditDeductionsUpdate, "admin_config", "7", "null", sqlmock.AnyArg(), "203.0.113.7", "req-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(41, now))
+	mock.ExpectCommit()
+
+	err = repo.InsertConfig(config, audit)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(41), audit.ID)
+	assert.Equal(t, "7", audit.ResourceID)
+	assert.Contains(t, string(audit.NewValue), `"Version":2`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	sql "database/sql"
 	reflect "reflect"
 
 	model "github.com/LGROW101/assessment-tax/model"
@@ -35,44 +36,144 @@ func (m *MockAdminRepository) EXPECT() *MockAdminRepositoryMockRecorder {
 }
 
 // GetConfig mocks base method.
-func (m *MockAdminRepository) GetConfig() (*model.AdminConfig, error) {
+func (m *MockAdminRepository) GetConfig(taxYear int) (*model.AdminConfig, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetConfig")
+	ret := m.ctrl.Call(m, "GetConfig", taxYear)
 	ret0, _ := ret[0].(*model.AdminConfig)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetConfig indicates an expected call of GetConfig.
-func (mr *MockAdminRepositoryMockRecorder) GetConfig() *gomock.Call {
+func (mr *MockAdminRepositoryMockRecorder) GetConfig(taxYear interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfig", reflect.TypeOf((*MockAdminRepository)(nil).GetConfig))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfig", reflect.TypeOf((*MockAdminRepository)(nil).GetConfig), taxYear)
+}
+
+// GetConfigVersion mocks base method.
+func (m *MockAdminRepository) GetConfigVersion(taxYear, version int) (*model.AdminConfig, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConfigVersion", taxYear, version)
+	ret0, _ := ret[0].(*model.AdminConfig)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetConfigVersion indicates an expected call of GetConfigVersion.
+func (mr *MockAdminRepositoryMockRecorder) GetConfigVersion(taxYear, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigVersion", reflect.TypeOf((*MockAdminRepository)(nil).GetConfigVersion), taxYear, version)
 }
 
 // InsertConfig mocks base method.
-func (m *MockAdminRepository) InsertConfig(config *model.AdminConfig) error {
+func (m *MockAdminRepository) InsertConfig(config *model.AdminConfig, audit *model.AuditEntry) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InsertConfig", config)
+	ret := m.ctrl.Call(m, "InsertConfig", config, audit)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // InsertConfig indicates an expected call of InsertConfig.
-func (mr *MockAdminRepositoryMockRecorder) InsertConfig(config interface{}) *gomock.Call {
+func (mr *MockAdminRepositoryMockRecorder) InsertConfig(config, audit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertConfig", reflect.TypeOf((*MockAdminRepository)(nil).InsertConfig), config)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertConfig", reflect.TypeOf((*MockAdminRepository)(nil).InsertConfig), config, audit)
+}
+
+// ListConfigs mocks base method.
+func (m *MockAdminRepository) ListConfigs(taxYear int) ([]*model.AdminConfig, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListConfigs", taxYear)
+	ret0, _ := ret[0].([]*model.AdminConfig)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListConfigs indicates an expected call of ListConfigs.
+func (mr *MockAdminRepositoryMockRecorder) ListConfigs(taxYear interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListConfigs", reflect.TypeOf((*MockAdminRepository)(nil).ListConfigs), taxYear)
+}
+
+// Mockqueryer is a mock of queryer interface.
+type Mockqueryer struct {
+	ctrl     *gomock.Controller
+	recorder *MockqueryerMockRecorder
+}
+
+// MockqueryerMockRecorder is the mock recorder for Mockqueryer.
+type MockqueryerMockRecorder struct {
+	mock *Mockqueryer
+}
+
+// NewMockqueryer creates a new mock instance.
+func NewMockqueryer(ctrl *gomock.Controller) *Mockqueryer {
+	mock := &Mockqueryer{ctrl: ctrl}
+	mock.recorder = &MockqueryerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockqueryer) EXPECT() *MockqueryerMockRecorder {
+	return m.recorder
+}
+
+// Query mocks base method.
+func (m *Mockqueryer) Query(query string, args ...any) (*sql.Rows, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Query", varargs...)
+	ret0, _ := ret[0].(*sql.Rows)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Query indicates an expected call of Query.
+func (mr *MockqueryerMockRecorder) Query(query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Query", reflect.TypeOf((*Mockqueryer)(nil).Query), varargs...)
+}
+
+// MockrowScanner is a mock of rowScanner interface.
+type MockrowScanner struct {
+	ctrl     *gomock.Controller
+	recorder *MockrowScannerMockRecorder
+}
+
+// MockrowScannerMockRecorder is the mock recorder for MockrowScanner.
+type MockrowScannerMockRecorder struct {
+	mock *MockrowScanner
+}
+
+// NewMockrowScanner creates a new mock instance.
+func NewMockrowScanner(ctrl *gomock.Controller) *MockrowScanner {
+	mock := &MockrowScanner{ctrl: ctrl}
+	mock.recorder = &MockrowScannerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockrowScanner) EXPECT() *MockrowScannerMockRecorder {
+	return m.recorder
 }
 
-// UpdateConfig mocks base method.
-func (m *MockAdminRepository) UpdateConfig(config *model.AdminConfig) error {
+// Scan mocks base method.
+func (m *MockrowScanner) Scan(dest ...any) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateConfig", config)
+	varargs := []interface{}{}
+	for _, a := range dest {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Scan", varargs...)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateConfig indicates an expected call of UpdateConfig.
-func (mr *MockAdminRepositoryMockRecorder) UpdateConfig(config interface{}) *gomock.Call {
+// Scan indicates an expected call of Scan.
+func (mr *MockrowScannerMockRecorder) Scan(dest ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateConfig", reflect.TypeOf((*MockAdminRepository)(nil).UpdateConfig), config)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockrowScanner)(nil).Scan), dest...)
 }
@@ -0,0 +1,137 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../../repository/allowance.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	model "github.com/LGROW101/assessment-tax/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAllowanceRepository is a mock of AllowanceRepository interface.
+type MockAllowanceRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAllowanceRepositoryMockRecorder
+}
+
+// MockAllowanceRepositoryMockRecorder is the mock recorder for MockAllowanceRepository.
+type MockAllowanceRepositoryMockRecorder struct {
+	mock *MockAllowanceRepository
+}
+
+// NewMockAllowanceRepository creates a new mock instance.
+func NewMockAllowanceRepository(ctrl *gomock.Controller) *MockAllowanceRepository {
+	mock := &MockAllowanceRepository{ctrl: ctrl}
+	mock.recorder = &MockAllowanceRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAllowanceRepository) EXPECT() *MockAllowanceRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteAllowanceType mocks base method.
+func (m *MockAllowanceRepository) DeleteAllowanceType(key string, audit *model.AuditEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAllowanceType", key, audit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAllowanceType indicates an expected call of DeleteAllowanceType.
+func (mr *MockAllowanceRepositoryMockRecorder) DeleteAllowanceType(key, audit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAllowanceType", reflect.TypeOf((*MockAllowanceRepository)(nil).DeleteAllowanceType), key, audit)
+}
+
+// GetAllowanceGroup mocks base method.
+func (m *MockAllowanceRepository) GetAllowanceGroup(key string) (*model.AllowanceGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllowanceGroup", key)
+	ret0, _ := ret[0].(*model.AllowanceGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllowanceGroup indicates an expected call of GetAllowanceGroup.
+func (mr *MockAllowanceRepositoryMockRecorder) GetAllowanceGroup(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllowanceGroup", reflect.TypeOf((*MockAllowanceRepository)(nil).GetAllowanceGroup), key)
+}
+
+// GetAllowanceType mocks base method.
+func (m *MockAllowanceRepository) GetAllowanceType(key string) (*model.AllowanceType, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAllowanceType", key)
+	ret0, _ := ret[0].(*model.AllowanceType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAllowanceType indicates an expected call of GetAllowanceType.
+func (mr *MockAllowanceRepositoryMockRecorder) GetAllowanceType(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllowanceType", reflect.TypeOf((*MockAllowanceRepository)(nil).GetAllowanceType), key)
+}
+
+// ListAllowanceGroups mocks base method.
+func (m *MockAllowanceRepository) ListAllowanceGroups() ([]*model.AllowanceGroup, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAllowanceGroups")
+	ret0, _ := ret[0].([]*model.AllowanceGroup)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAllowanceGroups indicates an expected call of ListAllowanceGroups.
+func (mr *MockAllowanceRepositoryMockRecorder) ListAllowanceGroups() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllowanceGroups", reflect.TypeOf((*MockAllowanceRepository)(nil).ListAllowanceGroups))
+}
+
+// ListAllowanceTypes mocks base method.
+func (m *MockAllowanceRepository) ListAllowanceTypes() ([]*model.AllowanceType, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAllowanceTypes")
+	ret0, _ := ret[0].([]*model.AllowanceType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAllowanceTypes indicates an expected call of ListAllowanceTypes.
+func (mr *MockAllowanceRepositoryMockRecorder) ListAllowanceTypes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllowanceTypes", reflect.TypeOf((*MockAllowanceRepository)(nil).ListAllowanceTypes))
+}
+
+// SaveAllowanceGroup mocks base method.
+func (m *MockAllowanceRepository) SaveAllowanceGroup(group *model.AllowanceGroup, audit *model.AuditEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAllowanceGroup", group, audit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAllowanceGroup indicates an expected call of SaveAllowanceGroup.
+func (mr *MockAllowanceRepositoryMockRecorder) SaveAllowanceGroup(group, audit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAllowanceGroup", reflect.TypeOf((*MockAllowanceRepository)(nil).SaveAllowanceGroup), group, audit)
+}
+
+// SaveAllowanceType mocks base method.
+func (m *MockAllowanceRepository) SaveAllowanceType(allowanceType *model.AllowanceType, audit *model.AuditEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveAllowanceType", allowanceType, audit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveAllowanceType indicates an expected call of SaveAllowanceType.
+func (mr *MockAllowanceRepositoryMockRecorder) SaveAllowanceType(allowanceType, audit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveAllowanceType", reflect.TypeOf((*MockAllowanceRepository)(nil).SaveAllowanceType), allowanceType, audit)
+}
@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	sql "database/sql"
 	reflect "reflect"
 
 	model "github.com/LGROW101/assessment-tax/model"
@@ -35,18 +36,34 @@ func (m *MockTaxRepository) EXPECT() *MockTaxRepositoryMockRecorder {
 }
 
 // GetAllCalculations mocks base method.
-func (m *MockTaxRepository) GetAllCalculations() ([]*model.TaxCalculation, error) {
+func (m *MockTaxRepository) GetAllCalculations(filter model.TaxCalculationFilter) ([]*model.TaxCalculation, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllCalculations")
+	ret := m.ctrl.Call(m, "GetAllCalculations", filter)
 	ret0, _ := ret[0].([]*model.TaxCalculation)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAllCalculations indicates an expected call of GetAllCalculations.
+func (mr *MockTaxRepositoryMockRecorder) GetAllCalculations(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllCalculations", reflect.TypeOf((*MockTaxRepository)(nil).GetAllCalculations), filter)
+}
+
+// GetCalculation mocks base method.
+func (m *MockTaxRepository) GetCalculation(id uint) (*model.TaxCalculation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCalculation", id)
+	ret0, _ := ret[0].(*model.TaxCalculation)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAllCalculations indicates an expected call of GetAllCalculations.
-func (mr *MockTaxRepositoryMockRecorder) GetAllCalculations() *gomock.Call {
+// GetCalculation indicates an expected call of GetCalculation.
+func (mr *MockTaxRepositoryMockRecorder) GetCalculation(id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllCalculations", reflect.TypeOf((*MockTaxRepository)(nil).GetAllCalculations))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCalculation", reflect.TypeOf((*MockTaxRepository)(nil).GetCalculation), id)
 }
 
 // Save mocks base method.
@@ -62,3 +79,45 @@ func (mr *MockTaxRepositoryMockRecorder) Save(tax interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockTaxRepository)(nil).Save), tax)
 }
+
+// MockqueryRower is a mock of queryRower interface.
+type MockqueryRower struct {
+	ctrl     *gomock.Controller
+	recorder *MockqueryRowerMockRecorder
+}
+
+// MockqueryRowerMockRecorder is the mock recorder for MockqueryRower.
+type MockqueryRowerMockRecorder struct {
+	mock *MockqueryRower
+}
+
+// NewMockqueryRower creates a new mock instance.
+func NewMockqueryRower(ctrl *gomock.Controller) *MockqueryRower {
+	mock := &MockqueryRower{ctrl: ctrl}
+	mock.recorder = &MockqueryRowerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockqueryRower) EXPECT() *MockqueryRowerMockRecorder {
+	return m.recorder
+}
+
+// QueryRow mocks base method.
+func (m *MockqueryRower) QueryRow(query string, args ...any) *sql.Row {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{query}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "QueryRow", varargs...)
+	ret0, _ := ret[0].(*sql.Row)
+	return ret0
+}
+
+// QueryRow indicates an expected call of QueryRow.
+func (mr *MockqueryRowerMockRecorder) QueryRow(query interface{}, args ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{query}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRow", reflect.TypeOf((*MockqueryRower)(nil).QueryRow), varargs...)
+}
@@ -0,0 +1,233 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../../repository/upload.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+	time "time"
+
+	model "github.com/LGROW101/assessment-tax/model"
+	repository "github.com/LGROW101/assessment-tax/repository"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockUploadRepository is a mock of UploadRepository interface.
+type MockUploadRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockUploadRepositoryMockRecorder
+}
+
+// MockUploadRepositoryMockRecorder is the mock recorder for MockUploadRepository.
+type MockUploadRepositoryMockRecorder struct {
+	mock *MockUploadRepository
+}
+
+// NewMockUploadRepository creates a new mock instance.
+func NewMockUploadRepository(ctrl *gomock.Controller) *MockUploadRepository {
+	mock := &MockUploadRepository{ctrl: ctrl}
+	mock.recorder = &MockUploadRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUploadRepository) EXPECT() *MockUploadRepositoryMockRecorder {
+	return m.recorder
+}
+
+// BeginResults mocks base method.
+func (m *MockUploadRepository) BeginResults(upload *model.TaxUpload) (repository.UploadResultWriter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginResults", upload)
+	ret0, _ := ret[0].(repository.UploadResultWriter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginResults indicates an expected call of BeginResults.
+func (mr *MockUploadRepositoryMockRecorder) BeginResults(upload interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginResults", reflect.TypeOf((*MockUploadRepository)(nil).BeginResults), upload)
+}
+
+// ClaimUpload mocks base method.
+func (m *MockUploadRepository) ClaimUpload() (*model.TaxUpload, []byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimUpload")
+	ret0, _ := ret[0].(*model.TaxUpload)
+	ret1, _ := ret[1].([]byte)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ClaimUpload indicates an expected call of ClaimUpload.
+func (mr *MockUploadRepositoryMockRecorder) ClaimUpload() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimUpload", reflect.TypeOf((*MockUploadRepository)(nil).ClaimUpload))
+}
+
+// CreateUpload mocks base method.
+func (m *MockUploadRepository) CreateUpload(upload *model.TaxUpload, content []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUpload", upload, content)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUpload indicates an expected call of CreateUpload.
+func (mr *MockUploadRepositoryMockRecorder) CreateUpload(upload, content interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUpload", reflect.TypeOf((*MockUploadRepository)(nil).CreateUpload), upload, content)
+}
+
+// EachResult mocks base method.
+func (m *MockUploadRepository) EachResult(id uint, fn func(*model.TaxCalculation) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EachResult", id, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EachResult indicates an expected call of EachResult.
+func (mr *MockUploadRepositoryMockRecorder) EachResult(id, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EachResult", reflect.TypeOf((*MockUploadRepository)(nil).EachResult), id, fn)
+}
+
+// FailUpload mocks base method.
+func (m *MockUploadRepository) FailUpload(upload *model.TaxUpload, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailUpload", upload, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailUpload indicates an expected call of FailUpload.
+func (mr *MockUploadRepositoryMockRecorder) FailUpload(upload, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailUpload", reflect.TypeOf((*MockUploadRepository)(nil).FailUpload), upload, reason)
+}
+
+// GetUpload mocks base method.
+func (m *MockUploadRepository) GetUpload(id uint) (*model.TaxUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUpload", id)
+	ret0, _ := ret[0].(*model.TaxUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUpload indicates an expected call of GetUpload.
+func (mr *MockUploadRepositoryMockRecorder) GetUpload(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpload", reflect.TypeOf((*MockUploadRepository)(nil).GetUpload), id)
+}
+
+// ReleaseUpload mocks base method.
+func (m *MockUploadRepository) ReleaseUpload(upload *model.TaxUpload) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseUpload", upload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReleaseUpload indicates an expected call of ReleaseUpload.
+func (mr *MockUploadRepositoryMockRecorder) ReleaseUpload(upload interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseUpload", reflect.TypeOf((*MockUploadRepository)(nil).ReleaseUpload), upload)
+}
+
+// RequeueStaleUploads mocks base method.
+func (m *MockUploadRepository) RequeueStaleUploads(timeout time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequeueStaleUploads", timeout)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RequeueStaleUploads indicates an expected call of RequeueStaleUploads.
+func (mr *MockUploadRepositoryMockRecorder) RequeueStaleUploads(timeout interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequeueStaleUploads", reflect.TypeOf((*MockUploadRepository)(nil).RequeueStaleUploads), timeout)
+}
+
+// UpdateProgress mocks base method.
+func (m *MockUploadRepository) UpdateProgress(upload *model.TaxUpload) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProgress", upload)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateProgress indicates an expected call of UpdateProgress.
+func (mr *MockUploadRepositoryMockRecorder) UpdateProgress(upload interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProgress", reflect.TypeOf((*MockUploadRepository)(nil).UpdateProgress), upload)
+}
+
+// MockUploadResultWriter is a mock of UploadResultWriter interface.
+type MockUploadResultWriter struct {
+	ctrl     *gomock.Controller
+	recorder *MockUploadResultWriterMockRecorder
+}
+
+// MockUploadResultWriterMockRecorder is the mock recorder for MockUploadResultWriter.
+type MockUploadResultWriterMockRecorder struct {
+	mock *MockUploadResultWriter
+}
+
+// NewMockUploadResultWriter creates a new mock instance.
+func NewMockUploadResultWriter(ctrl *gomock.Controller) *MockUploadResultWriter {
+	mock := &MockUploadResultWriter{ctrl: ctrl}
+	mock.recorder = &MockUploadResultWriterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUploadResultWriter) EXPECT() *MockUploadResultWriterMockRecorder {
+	return m.recorder
+}
+
+// Complete mocks base method.
+func (m *MockUploadResultWriter) Complete() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockUploadResultWriterMockRecorder) Complete() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockUploadResultWriter)(nil).Complete))
+}
+
+// Insert mocks base method.
+func (m *MockUploadResultWriter) Insert(tax *model.TaxCalculation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Insert", tax)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Insert indicates an expected call of Insert.
+func (mr *MockUploadResultWriterMockRecorder) Insert(tax interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockUploadResultWriter)(nil).Insert), tax)
+}
+
+// Rollback mocks base method.
+func (m *MockUploadResultWriter) Rollback() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rollback")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rollback indicates an expected call of Rollback.
+func (mr *MockUploadResultWriterMockRecorder) Rollback() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockUploadResultWriter)(nil).Rollback))
+}
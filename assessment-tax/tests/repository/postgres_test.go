@@ -0,0 +1,29 @@
+package repository_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/LGROW101/assessment-tax/databases/migration"
+	_ "github.com/lib/pq"
+)
+
+// testDatabase returns the migrated database of TEST_DATABASE_URL, skipping
+// the test when it is not set. Tests leave it as they found it.
+func testDatabase(t *testing.T) *sql.DB {
+	t.Helper()
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	if err := migration.Up(databaseURL); err != nil {
+		t.Fatalf("migrating the test database: %v", err)
+	}
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		t.Fatalf("opening the test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
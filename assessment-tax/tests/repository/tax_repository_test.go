@@ -1,6 +1,7 @@
 package repository_test
 
 import (
+	"database/sql/driver"
 	"errors"
 	"testing"
 	"time"
@@ -11,6 +12,11 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+var taxCalculationColumns = []string{
+	"id", "tax_year", "admin_config_id", "version", "upload_id", "upload_row", "totalIncome", "wht", "expenses", "personal_allowance", "donation", "k_receipt",
+	"taxable_income", "tax", "tax_payable", "tax_refund", "allowances", "tax_level", "request", "deductions", "created_at",
+}
+
 func TestTaxRepository_Save(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -20,28 +26,51 @@ func TestTaxRepository_Save(t *testing.T) {
 
 	repo := repository.NewTaxRepository(db)
 
+	allowances := []model.Allowance{{AllowanceType: "donation", Amount: model.Baht(10000)}}
 	taxCalculation := &model.TaxCalculation{
-		TotalIncome:       1000000,
-		WHT:               100000,
-		PersonalAllowance: 60000,
-		Donation:          10000,
-		KReceipt:          30000,
-		Tax:               200000,
+		TaxYear:           2024,
+		RuleSetID:         3,
+		TotalIncome:       model.Baht(1000000),
+		WHT:               model.Baht(100000),
+		PersonalAllowance: model.Baht(60000),
+		Donation:          model.Baht(10000),
+		KReceipt:          model.Baht(30000),
+		TaxableIncome:     model.Baht(900000),
+		Tax:               model.Baht(95000),
+		TaxRefund:         model.Baht(5000),
+		TaxLevel:          []model.TaxRate{{Level: "0-150,000", Tax: model.Baht(0)}},
+		Allowances:        allowances,
+		Deductions:        map[string]model.Money{"donation": model.Baht(10000)},
+		Request:           model.TaxCalculationRequest{TaxYear: 2024, TotalIncome: model.Baht(1000000), WHT: model.Baht(100000), Allowances: allowances},
+	}
+
+	args := []driver.Value{
+		2024, int64(3), nil, nil,
+		taxCalculation.TotalIncome, taxCalculation.WHT, taxCalculation.Expenses, taxCalculation.PersonalAllowance, taxCalculation.Donation, taxCalculation.KReceipt,
+		taxCalculation.TaxableIncome, taxCalculation.Tax, taxCalculation.TaxPayable, taxCalculation.TaxRefund,
+		`[{"allowanceType":"donation","amount":10000.00}]`,
+		`[{"level":"0-150,000","tax":0.00}]`,
+		`{"taxYear":2024,"totalIncome":1000000.00,"wht":100000.00,"allowances":[{"allowanceType":"donation","amount":10000.00}]}`,
+		`{"donation":10000.00}`,
 	}
 
-	mock.ExpectExec("^INSERT INTO tax_calculations").
-		WithArgs(taxCalculation.TotalIncome, taxCalculation.WHT, taxCalculation.PersonalAllowance, taxCalculation.Donation, taxCalculation.KReceipt, taxCalculation.Tax).
-		WillReturnResult(sqlmock.NewResult(1, 1))
+	createdAt := time.Now()
+	mock.ExpectQuery("^INSERT INTO tax_calculations").
+		WithArgs(args...).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(5, createdAt))
 
 	err = repo.Save(taxCalculation)
 	assert.NoError(t, err)
+	assert.Equal(t, uint(5), taxCalculation.ID)
+	assert.Equal(t, createdAt, taxCalculation.CreatedAt)
 
-	mock.ExpectExec("^INSERT INTO tax_calculations").
-		WithArgs(taxCalculation.TotalIncome, taxCalculation.WHT, taxCalculation.PersonalAllowance, taxCalculation.Donation, taxCalculation.KReceipt, taxCalculation.Tax).
+	mock.ExpectQuery("^INSERT INTO tax_calculations").
+		WithArgs(args...).
 		WillReturnError(errors.New("database error"))
 
 	err = repo.Save(taxCalculation)
 	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestTaxRepository_GetAllCalculations(t *testing.T) {
@@ -54,54 +83,204 @@ func TestTaxRepository_GetAllCalculations(t *testing.T) {
 	repo := repository.NewTaxRepository(db)
 
 	createdAt := time.Now()
-	rows := sqlmock.NewRows([]string{"id", "totalIncome", "wht", "personal_allowance", "donation", "k_receipt", "tax", "created_at"}).
-		AddRow(1, 1000000, 100000, 60000, 10000, 30000, 200000, createdAt).
-		AddRow(2, 800000, 80000, 60000, 5000, 20000, 150000, createdAt)
+	rows := sqlmock.NewRows(taxCalculationColumns).
+		AddRow(1, 2024, 3, 2, 0, 0, "1000000.00", "100000.00", "0.00", "60000.00", "10000.00", "30000.00", "900000.00", "95000.00", "0.00", "5000.00",
+			[]byte(`[{"allowanceType":"donation","amount":10000}]`), []byte(`[{"level":"0-150,000","tax":0}]`),
+			[]byte(`{"taxYear":2024,"totalIncome":1000000,"wht":100000}`), []byte(`{"donation":10000,"k-receipt":30000}`), createdAt).
+		AddRow(2, 0, 0, 0, 0, 0, "800000.00", "80000.00", "0.00", "60000.00", "5000.00", "20000.00", "0.00", "150000.00", "0.00", "0.00",
+			[]byte(`[]`), []byte(`[]`), []byte(`{}`), []byte(`{}`), createdAt)
 
-	mock.ExpectQuery("^SELECT id, totalIncome, wht, personal_allowance, donation, k_receipt, tax, created_at FROM tax_calculations$").
+	mock.ExpectQuery("^SELECT (.+) FROM tax_calculations tc LEFT JOIN admin_configs ac ON ac.id = tc.admin_config_id ORDER BY tc.created_at DESC, tc.id DESC LIMIT \\$1$").
+		WithArgs(model.DefaultCalculationPageSize + 1).
 		WillReturnRows(rows)
 
 	expectedCalculations := []*model.TaxCalculation{
 		{
 			ID:                1,
-			TotalIncome:       1000000,
-			WHT:               100000,
-			PersonalAllowance: 60000,
-			Donation:          10000,
-			KReceipt:          30000,
-			Tax:               200000,
+			TaxYear:           2024,
+			RuleSetID:         3,
+			RuleSetVersion:    2,
+			TotalIncome:       model.Baht(1000000),
+			WHT:               model.Baht(100000),
+			PersonalAllowance: model.Baht(60000),
+			Donation:          model.Baht(10000),
+			KReceipt:          model.Baht(30000),
+			TaxableIncome:     model.Baht(900000),
+			Tax:               model.Baht(95000),
+			TaxRefund:         model.Baht(5000),
+			TaxLevel:          []model.TaxRate{{Level: "0-150,000", Tax: model.Baht(0)}},
+			Allowances:        []model.Allowance{{AllowanceType: "donation", Amount: model.Baht(10000)}},
+			Deductions:        map[string]model.Money{model.AllowanceDonation: model.Baht(10000), model.AllowanceKReceipt: model.Baht(30000)},
+			Request:           model.TaxCalculationRequest{TaxYear: 2024, TotalIncome: model.Baht(1000000), WHT: model.Baht(100000)},
 			CreatedAt:         createdAt,
 		},
 		{
 			ID:                2,
-			TotalIncome:       800000,
-			WHT:               80000,
-			PersonalAllowance: 60000,
-			Donation:          5000,
-			KReceipt:          20000,
-			Tax:               150000,
+			TotalIncome:       model.Baht(800000),
+			WHT:               model.Baht(80000),
+			PersonalAllowance: model.Baht(60000),
+			Donation:          model.Baht(5000),
+			KReceipt:          model.Baht(20000),
+			Tax:               model.Baht(150000),
+			TaxLevel:          []model.TaxRate{},
+			Allowances:        []model.Allowance{},
+			Deductions:        map[string]model.Money{},
 			CreatedAt:         createdAt,
 		},
 	}
 
-	calculations, err := repo.GetAllCalculations()
+	calculations, nextCursor, err := repo.GetAllCalculations(model.TaxCalculationFilter{})
 	assert.NoError(t, err)
 	assert.Equal(t, expectedCalculations, calculations)
+	assert.Empty(t, nextCursor)
 
-	mock.ExpectQuery("^SELECT id, totalIncome, wht, personal_allowance, donation, k_receipt, tax, created_at FROM tax_calculations$").
+	mock.ExpectQuery("^SELECT (.+) FROM tax_calculations").
 		WillReturnError(errors.New("database error"))
 
-	calculations, err = repo.GetAllCalculations()
+	calculations, _, err = repo.GetAllCalculations(model.TaxCalculationFilter{})
 	assert.Error(t, err)
 	assert.Nil(t, calculations)
 
-	rows = sqlmock.NewRows([]string{"id", "totalIncome", "wht", "personal_allowance", "donation", "k_receipt", "tax", "created_at"}).
-		AddRow(1, "invalid", 100000, 60000, 10000, 30000, 200000, createdAt)
+	rows = sqlmock.NewRows(taxCalculationColumns).
+		AddRow(1, 0, 0, 0, 0, 0, "invalid", "0", "0.00", "0", "0", "0", "0", "0", "0", "0", []byte(`[]`), []byte(`[]`), []byte(`{}`), []byte(`{}`), createdAt)
 
-	mock.ExpectQuery("^SELECT id, totalIncome, wht, personal_allowance, donation, k_receipt, tax, created_at FROM tax_calculations$").
+	mock.ExpectQuery("^SELECT (.+) FROM tax_calculations").
 		WillReturnRows(rows)
 
-	calculations, err = repo.GetAllCalculations()
+	calculations, _, err = repo.GetAllCalculations(model.TaxCalculationFilter{})
 	assert.Error(t, err)
 	assert.Nil(t, calculations)
 }
+
+func TestTaxRepository_GetAllCalculationsWithFilter(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewTaxRepository(db)
+
+	from := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	minIncome := model.Baht(100000)
+	filter := model.TaxCalculationFilter{
+		CreatedFrom: &from,
+		MinIncome:   &minIncome,
+		Type:        "refund",
+		TaxYear:     2024,
+		Sort:        "totalIncome",
+		Order:       "asc",
+		Limit:       1,
+	}
+
+	row := func(id int, income string) []driver.Value {
+		return []driver.Value{id, 2024, 3, 1, 0, 0, income, "0.00", "0.00", "60000.00", "0.00", "0.00", "0.00", "0.00", "0.00", "100.00",
+			[]byte(`[]`), []byte(`[]`), []byte(`{}`), []byte(`{}`), from}
+	}
+
+	mock.ExpectQuery("^SELECT (.+) WHERE tc.created_at >= \\$1 AND tc.totalIncome >= \\$2 AND tc.tax_refund > 0 AND tc.tax_year = \\$3 ORDER BY tc.totalIncome ASC, tc.id ASC LIMIT \\$4$").
+		WithArgs(from, minIncome, 2024, 2).
+		WillReturnRows(sqlmock.NewRows(taxCalculationColumns).AddRow(row(4, "150000.00")...).AddRow(row(9, "200000.00")...))
+
+	calculations, nextCursor, err := repo.GetAllCalculations(filter)
+	assert.NoError(t, err)
+	assert.Len(t, calculations, 1)
+	assert.Equal(t, uint(4), calculations[0].ID)
+	assert.NotEmpty(t, nextCursor)
+
+	firstCursor := nextCursor
+	filter.Cursor = nextCursor
+	mock.ExpectQuery("^SELECT (.+) AND \\(tc.totalIncome, tc.id\\) > \\(\\$4::numeric, \\$5\\) ORDER BY tc.totalIncome ASC, tc.id ASC LIMIT \\$6$").
+		WithArgs(from, minIncome, 2024, "150000.00", 4, 2).
+		WillReturnRows(sqlmock.NewRows(taxCalculationColumns).AddRow(row(9, "200000.00")...))
+
+	calculations, nextCursor, err = repo.GetAllCalculations(filter)
+	assert.NoError(t, err)
+	assert.Len(t, calculations, 1)
+	assert.Equal(t, uint(9), calculations[0].ID)
+	assert.Empty(t, nextCursor)
+
+	// a cursor only continues the ordering it was issued for
+	filter.Order = "desc"
+	filter.Cursor = firstCursor
+	_, _, err = repo.GetAllCalculations(filter)
+	assert.ErrorIs(t, err, model.ErrInvalidCursor)
+
+	filter.Cursor = "bogus"
+	_, _, err = repo.GetAllCalculations(filter)
+	assert.ErrorIs(t, err, model.ErrInvalidCursor)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTaxRepository_GetAllCalculationsByCreatedAt(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewTaxRepository(db)
+
+	// TIMESTAMP columns are read as their wall clock in UTC
+	createdAt := time.Date(2024, time.March, 1, 10, 30, 0, 123456000, time.UTC)
+	row := func(id int) []driver.Value {
+		return []driver.Value{id, 2024, 3, 1, 0, 0, "500000.00", "0.00", "0.00", "60000.00", "0.00", "0.00", "0.00", "0.00", "0.00", "0.00",
+			[]byte(`[]`), []byte(`[]`), []byte(`{}`), []byte(`{}`), createdAt}
+	}
+	filter := model.TaxCalculationFilter{Limit: 1}
+
+	mock.ExpectQuery("^SELECT (.+) ORDER BY tc.created_at DESC, tc.id DESC LIMIT \\$1$").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(taxCalculationColumns).AddRow(row(9)...).AddRow(row(8)...))
+
+	_, nextCursor, err := repo.GetAllCalculations(filter)
+	assert.NoError(t, err)
+
+	// the cursor is compared as a TIMESTAMP so no session time zone applies
+	filter.Cursor = nextCursor
+	mock.ExpectQuery("^SELECT (.+) WHERE \\(tc.created_at, tc.id\\) < \\(\\$1::timestamp, \\$2\\)").
+		WithArgs("2024-03-01T10:30:00.123456", 9, 2).
+		WillReturnRows(sqlmock.NewRows(taxCalculationColumns).AddRow(row(8)...))
+
+	calculations, _, err := repo.GetAllCalculations(filter)
+	assert.NoError(t, err)
+	assert.Len(t, calculations, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTaxRepository_GetCalculation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewTaxRepository(db)
+
+	createdAt := time.Now()
+	mock.ExpectQuery("^SELECT (.+) FROM tax_calculations tc (.+) WHERE tc.id = \\$1$").
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows(taxCalculationColumns).
+			AddRow(7, 2024, 3, 1, 12, 3, "500000.00", "0.00", "0.00", "60000.00", "0.00", "0.00", "440000.00", "29000.00", "29000.00", "0.00",
+				[]byte(`[]`), []byte(`[{"level":"150,001-500,000","tax":29000}]`), []byte(`{"totalIncome":500000}`), []byte(`{"provident-fund":50000.00}`), createdAt))
+
+	calculation, err := repo.GetCalculation(7)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), calculation.ID)
+	assert.Equal(t, 1, calculation.RuleSetVersion)
+	assert.Equal(t, uint(12), calculation.UploadID)
+	assert.Equal(t, 3, calculation.UploadRow)
+	assert.Equal(t, model.Baht(29000), calculation.TaxPayable)
+	assert.Equal(t, []model.TaxRate{{Level: "150,001-500,000", Tax: model.Baht(29000)}}, calculation.TaxLevel)
+	assert.Equal(t, model.Baht(500000), calculation.Request.TotalIncome)
+	assert.Equal(t, map[string]model.Money{"provident-fund": model.Baht(50000)}, calculation.Deductions)
+
+	mock.ExpectQuery("^SELECT (.+) FROM tax_calculations").
+		WithArgs(8).
+		WillReturnRows(sqlmock.NewRows(taxCalculationColumns))
+
+	calculation, err = repo.GetCalculation(8)
+	assert.NoError(t, err)
+	assert.Nil(t, calculation)
+}
@@ -0,0 +1,200 @@
+package repository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+var uploadColumns = []string{
+	"id", "filename", "status", "row_count", "processed_count", "error_count", "errors", "error", "created_at", "updated_at", "finished_at", "attempt",
+}
+
+func TestUploadRepository_CreateUpload(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewUploadRepository(db)
+
+	now := time.Now()
+	content := []byte("totalIncome,wht\n500000,0\n")
+	mock.ExpectQuery("^INSERT INTO tax_uploads").
+		WithArgs("payroll.csv", model.UploadQueued, content).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(12, now, now))
+
+	upload := &model.TaxUpload{Filename: "payroll.csv"}
+	err = repo.CreateUpload(upload, content)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(12), upload.ID)
+	assert.Equal(t, model.UploadQueued, upload.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUploadRepository_GetUpload(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewUploadRepository(db)
+
+	now := time.Now()
+	mock.ExpectQuery("^SELECT (.+) FROM tax_uploads WHERE id = \\$1$").
+		WithArgs(12).
+		WillReturnRows(sqlmock.NewRows(uploadColumns).
+			AddRow(12, "payroll.csv", "completed", 3, 3, 1, []byte(`[{"row":3,"column":"wht","reason":"must not be negative"}]`), "", now, now, now, 1))
+
+	upload, err := repo.GetUpload(12)
+	assert.NoError(t, err)
+	assert.Equal(t, model.UploadCompleted, upload.Status)
+	assert.Equal(t, 3, upload.RowCount)
+	assert.Equal(t, []model.CSVRowError{{Row: 3, Column: "wht", Reason: "must not be negative"}}, upload.Errors)
+	assert.Equal(t, now, *upload.FinishedAt)
+
+	mock.ExpectQuery("^SELECT (.+) FROM tax_uploads").
+		WithArgs(13).
+		WillReturnRows(sqlmock.NewRows(uploadColumns))
+
+	upload, err = repo.GetUpload(13)
+	assert.NoError(t, err)
+	assert.Nil(t, upload)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUploadRepository_ClaimUpload(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewUploadRepository(db)
+
+	now := time.Now()
+	mock.ExpectQuery("^UPDATE tax_uploads SET status = 'running'(.+)attempt = attempt \\+ 1(.+)FOR UPDATE SKIP LOCKED").
+		WillReturnRows(sqlmock.NewRows(append(uploadColumns, "content")).
+			AddRow(4, "payroll.csv", "running", 0, 0, 0, []byte(`[]`), "", now, now, nil, 2, []byte("totalIncome,wht\n")))
+
+	upload, content, err := repo.ClaimUpload()
+	assert.NoError(t, err)
+	assert.Equal(t, uint(4), upload.ID)
+	assert.Equal(t, model.UploadRunning, upload.Status)
+	assert.Equal(t, 2, upload.Attempt)
+	assert.Nil(t, upload.FinishedAt)
+	assert.Equal(t, []byte("totalIncome,wht\n"), content)
+
+	mock.ExpectQuery("^UPDATE tax_uploads").
+		WillReturnRows(sqlmock.NewRows(append(uploadColumns, "content")))
+
+	upload, content, err = repo.ClaimUpload()
+	assert.NoError(t, err)
+	assert.Nil(t, upload)
+	assert.Nil(t, content)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUploadRepository_RequeueStaleUploads(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewUploadRepository(db)
+
+	mock.ExpectExec("^UPDATE tax_uploads SET status = 'queued'(.+)WHERE status = 'running' AND updated_at <").
+		WithArgs(int64(120)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	n, err := repo.RequeueStaleUploads(2 * time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUploadRepository_UpdateHeldUpload(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewUploadRepository(db)
+	upload := &model.TaxUpload{ID: 12, Attempt: 2, ProcessedCount: 1000, ErrorCount: 3}
+
+	mock.ExpectExec("^UPDATE tax_uploads SET processed_count(.+)WHERE id = \\$1 AND status = 'running' AND attempt = \\$2").
+		WithArgs(uint(12), 2, 1000, 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.UpdateProgress(upload))
+
+	// the upload was requeued as stale and claimed by another worker
+	mock.ExpectExec("^UPDATE tax_uploads SET processed_count").
+		WithArgs(uint(12), 2, 1000, 3).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, repo.UpdateProgress(upload), repository.ErrUploadNotRunning)
+
+	mock.ExpectExec("^UPDATE tax_uploads SET status = 'failed'(.+)AND attempt = \\$2").
+		WithArgs(uint(12), 2, "database error").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.ErrorIs(t, repo.FailUpload(upload, "database error"), repository.ErrUploadNotRunning)
+
+	mock.ExpectExec("^UPDATE tax_uploads SET status = 'queued'(.+)AND attempt = \\$2").
+		WithArgs(uint(12), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	assert.NoError(t, repo.ReleaseUpload(upload))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUploadRepository_Results(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewUploadRepository(db)
+
+	now := time.Now()
+	upload := &model.TaxUpload{ID: 12, Attempt: 1, RowCount: 2, ErrorCount: 1, Errors: []model.CSVRowError{{Row: 3, Reason: "wrong number of fields"}}}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^INSERT INTO tax_calculations").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, now))
+	mock.ExpectQuery("^UPDATE tax_uploads SET status = 'completed'").
+		WithArgs(uint(12), 2, 1, `[{"row":3,"reason":"wrong number of fields"}]`, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "updated_at", "finished_at"}).AddRow("completed", now, now))
+	mock.ExpectCommit()
+
+	writer, err := repo.BeginResults(upload)
+	assert.NoError(t, err)
+	taxCalculation := &model.TaxCalculation{UploadRow: 2, TotalIncome: model.Baht(500000)}
+	assert.NoError(t, writer.Insert(taxCalculation))
+	assert.Equal(t, uint(12), taxCalculation.UploadID)
+	assert.NoError(t, writer.Complete())
+	assert.Equal(t, model.UploadCompleted, upload.Status)
+	assert.Equal(t, 2, upload.ProcessedCount)
+
+	// an upload requeued while it was processed is left to its new worker
+	mock.ExpectBegin()
+	mock.ExpectQuery("^UPDATE tax_uploads").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "updated_at", "finished_at"}))
+	mock.ExpectRollback()
+
+	writer, err = repo.BeginResults(&model.TaxUpload{ID: 13})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, writer.Complete(), repository.ErrUploadNotRunning)
+
+	mock.ExpectBegin().WillReturnError(errors.New("database error"))
+	_, err = repo.BeginResults(upload)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
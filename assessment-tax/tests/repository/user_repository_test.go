@@ -0,0 +1,98 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/repository"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var adminUserColumns = []string{"id", "username", "password_hash", "role", "created_at", "updated_at"}
+
+func TestUserRepository_GetUserByUsername(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewUserRepository(db)
+
+	now := time.Now()
+	mock.ExpectQuery("^SELECT (.+) FROM admin_users WHERE username = \\$1$").
+		WithArgs("somchai").
+		WillReturnRows(sqlmock.NewRows(adminUserColumns).AddRow(2, "somchai", "hash", "tax-admin", now, now))
+
+	user, err := repo.GetUserByUsername("somchai")
+	assert.NoError(t, err)
+	assert.Equal(t, &model.AdminUser{ID: 2, Username: "somchai", PasswordHash: "hash", Role: model.RoleTaxAdmin, CreatedAt: now, UpdatedAt: now}, user)
+
+	mock.ExpectQuery("^SELECT (.+) FROM admin_users").
+		WithArgs("nobody").
+		WillReturnRows(sqlmock.NewRows(adminUserColumns))
+
+	user, err = repo.GetUserByUsername("nobody")
+	assert.NoError(t, err)
+	assert.Nil(t, user)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_CreateUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewUserRepository(db)
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("^INSERT INTO admin_users").
+		WithArgs("malee", "hash", "viewer").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(3, now, now))
+	mock.ExpectCommit()
+
+	user := &model.AdminUser{Username: "malee", PasswordHash: "hash", Role: model.RoleViewer}
+	err = repo.CreateUser(user, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), user.ID)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^INSERT INTO admin_users").
+		WithArgs("malee", "hash", "viewer").
+		WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectRollback()
+
+	err = repo.CreateUser(&model.AdminUser{Username: "malee", PasswordHash: "hash", Role: model.RoleViewer}, nil)
+	assert.ErrorIs(t, err, repository.ErrUsernameTaken)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepository_RevokeToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := repository.NewUserRepository(db)
+
+	expiresAt := time.Now().Add(time.Hour)
+	mock.ExpectExec("^INSERT INTO revoked_tokens (.+) ON CONFLICT \\(jti\\) DO NOTHING$").
+		WithArgs("t1", expiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("^SELECT EXISTS").
+		WithArgs("t1").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	assert.NoError(t, repo.RevokeToken("t1", expiresAt))
+	revoked, err := repo.IsTokenRevoked("t1")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,6 +2,7 @@ package service_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/LGROW101/assessment-tax/model"
 	"github.com/LGROW101/assessment-tax/service"
@@ -17,31 +18,77 @@ func TestAdminService_GetConfig(t *testing.T) {
 	mockRepo := mocks.NewMockAdminRepository(ctrl)
 	expectedConfig := &model.AdminConfig{
 		ID:                1,
-		PersonalDeduction: 60000,
-		KReceipt:          30000,
+		PersonalDeduction: model.Baht(60000),
+		KReceipt:          model.Baht(30000),
 	}
-	mockRepo.EXPECT().GetConfig().Return(expectedConfig, nil)
+	mockRepo.EXPECT().GetConfig(0).Return(expectedConfig, nil)
 
 	adminSvc := service.NewAdminService(mockRepo)
-	config, err := adminSvc.GetConfig()
+	config, err := adminSvc.GetConfig(0)
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedConfig, config)
 }
 
-func TestAdminService_UpdateConfig(t *testing.T) {
+func TestAdminService_GetRuleSet(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockAdminRepository(ctrl)
-	config := &model.AdminConfig{
-		PersonalDeduction: 70000,
-		KReceipt:          40000,
+	expectedConfig := &model.AdminConfig{
+		ID:                1,
+		TaxYear:           2023,
+		PersonalDeduction: model.Baht(60000),
+		Brackets:          taxBrackets,
 	}
-	mockRepo.EXPECT().UpdateConfig(config).Return(nil)
+	mockRepo.EXPECT().GetConfig(2023).Return(expectedConfig, nil)
 
 	adminSvc := service.NewAdminService(mockRepo)
-	err := adminSvc.UpdateConfig(config)
+	config, schedule, err := adminSvc.GetRuleSet(2023)
 
 	assert.NoError(t, err)
+	assert.Equal(t, expectedConfig, config)
+	assert.Equal(t, taxBrackets, schedule.Brackets())
+}
+
+func TestAdminService_GetRuleSetNotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAdminRepository(ctrl)
+	mockRepo.EXPECT().GetConfig(1999).Return(nil, nil)
+
+	adminSvc := service.NewAdminService(mockRepo)
+	_, _, err := adminSvc.GetRuleSet(1999)
+
+	assert.ErrorIs(t, err, service.ErrRuleSetNotFound)
+}
+
+func TestAdminService_GetRuleSetDefault(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAdminRepository(ctrl)
+	mockRepo.EXPECT().GetConfig(0).Return(nil, nil)
+
+	adminSvc := service.NewAdminService(mockRepo)
+	config, schedule, err := adminSvc.GetRuleSet(0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.DefaultRuleSet(time.Now().Year()), config)
+	assert.Equal(t, taxBrackets, schedule.Brackets())
+}
+
+func TestAdminService_GetRuleSetWithoutBrackets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockAdminRepository(ctrl)
+	mockRepo.EXPECT().GetConfig(0).Return(&model.AdminConfig{TaxYear: 2024}, nil)
+
+	adminSvc := service.NewAdminService(mockRepo)
+	_, _, err := adminSvc.GetRuleSet(0)
+
+	assert.ErrorIs(t, err, service.ErrRuleSetUnavailable)
+	assert.ErrorIs(t, err, service.ErrTaxBracketsNotConfigured)
 }
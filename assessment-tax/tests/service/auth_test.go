@@ -0,0 +1,125 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/service"
+	"github.com/LGROW101/assessment-tax/tests/repository/mocks"
+	"github.com/golang-jwt/jwt"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func taxAdmin(t *testing.T) *model.AdminUser {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret-pass"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	return &model.AdminUser{ID: 2, Username: "somchai", PasswordHash: string(hash), Role: model.RoleTaxAdmin}
+}
+
+func TestAuthService_Login(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	authSvc := service.NewAuthService(userRepo, "secret", time.Hour)
+
+	userRepo.EXPECT().GetUserByUsername("somchai").Return(taxAdmin(t), nil).Times(2)
+	userRepo.EXPECT().GetUserByUsername("nobody").Return(nil, nil)
+	userRepo.EXPECT().IsTokenRevoked(gomock.Any()).Return(false, nil)
+
+	resp, err := authSvc.Login(model.LoginRequest{Username: "somchai", Password: "s3cret-pass"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer", resp.TokenType)
+	assert.Equal(t, model.RoleTaxAdmin, resp.Role)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), resp.ExpiresAt, 2*time.Second)
+
+	claims, err := authSvc.Authenticate(resp.Token)
+	assert.NoError(t, err)
+	assert.Equal(t, "somchai", claims.Subject)
+	assert.Equal(t, model.RoleTaxAdmin, claims.Role)
+	assert.NotEmpty(t, claims.Id)
+
+	_, err = authSvc.Login(model.LoginRequest{Username: "somchai", Password: "wrong"})
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+
+	_, err = authSvc.Login(model.LoginRequest{Username: "nobody", Password: "s3cret-pass"})
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+}
+
+func TestAuthService_Authenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	authSvc := service.NewAuthService(userRepo, "secret", time.Hour)
+
+	sign := func(secret string, claims service.AuthClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+		assert.NoError(t, err)
+		return token
+	}
+	valid := service.AuthClaims{
+		Role:           model.RoleViewer,
+		StandardClaims: jwt.StandardClaims{Id: "t1", Subject: "somchai", ExpiresAt: time.Now().Add(time.Minute).Unix()},
+	}
+
+	userRepo.EXPECT().IsTokenRevoked("t1").Return(true, nil)
+	_, err := authSvc.Authenticate(sign("secret", valid))
+	assert.ErrorIs(t, err, service.ErrInvalidToken)
+
+	_, err = authSvc.Authenticate(sign("other", valid))
+	assert.ErrorIs(t, err, service.ErrInvalidToken)
+
+	expired := valid
+	expired.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+	_, err = authSvc.Authenticate(sign("secret", expired))
+	assert.ErrorIs(t, err, service.ErrInvalidToken)
+
+	unknownRole := valid
+	unknownRole.Role = "root"
+	_, err = authSvc.Authenticate(sign("secret", unknownRole))
+	assert.ErrorIs(t, err, service.ErrInvalidToken)
+
+	_, err = authSvc.Authenticate("not a token")
+	assert.ErrorIs(t, err, service.ErrInvalidToken)
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	authSvc := service.NewAuthService(userRepo, "secret", time.Hour)
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	userRepo.EXPECT().RevokeToken("t1", expiresAt).Return(nil)
+
+	err := authSvc.Logout(&service.AuthClaims{StandardClaims: jwt.StandardClaims{Id: "t1", ExpiresAt: expiresAt.Unix()}})
+	assert.NoError(t, err)
+}
+
+func TestAuthService_EnsureSuperAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	authSvc := service.NewAuthService(userRepo, "secret", time.Hour)
+
+	userRepo.EXPECT().GetUserByUsername("adminTax").Return(nil, nil)
+	userRepo.EXPECT().CreateUser(gomock.Any(), gomock.Any()).DoAndReturn(func(user *model.AdminUser, audit *model.AuditEntry) error {
+		assert.Equal(t, "system", audit.Actor)
+		assert.Equal(t, model.AuditUserCreate, audit.Action)
+		assert.Equal(t, "adminTax", user.Username)
+		assert.Equal(t, model.RoleSuperAdmin, user.Role)
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte("admin!")))
+		return nil
+	})
+	assert.NoError(t, authSvc.EnsureSuperAdmin("adminTax", "admin!"))
+
+	// an existing user keeps its password
+	userRepo.EXPECT().GetUserByUsername("adminTax").Return(&model.AdminUser{Username: "adminTax"}, nil)
+	assert.NoError(t, authSvc.EnsureSuperAdmin("adminTax", "changed"))
+}
@@ -0,0 +1,161 @@
+package service_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/service"
+	"github.com/stretchr/testify/assert"
+)
+
+var exportedCalculations = []*model.TaxCalculation{
+	{
+		ID:                7,
+		TaxYear:           2024,
+		TotalIncome:       model.Baht(500000),
+		PersonalAllowance: model.Baht(60000),
+		TaxableIncome:     model.Baht(440000),
+		TaxLevel: []model.TaxRate{
+			{Level: "0-150,000", Tax: model.Baht(0)},
+			{Level: "150,001-500,000", Tax: model.Baht(29000)},
+		},
+		Tax:        model.Baht(29000),
+		TaxPayable: model.Baht(29000),
+		CreatedAt:  time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC),
+	},
+}
+
+func TestWriteCalculationsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := service.WriteCalculations(&buf, service.ExportCSV, exportedCalculations)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "id,createdAt,taxYear,totalIncome,wht,expenses,personalAllowance,donation,kReceipt,taxableIncome,"+
+		"\"tax 0-150,000\",\"tax 150,001-500,000\",tax,taxPayable,taxRefund\n"+
+		"7,2024-05-01T10:00:00Z,2024,500000.00,0.00,0.00,60000.00,0.00,0.00,440000.00,0.00,29000.00,29000.00,29000.00,0.00\n", buf.String())
+}
+
+func TestWriteCalculationsDeductions(t *testing.T) {
+	var buf bytes.Buffer
+	err := service.WriteCalculations(&buf, service.ExportCSV, []*model.TaxCalculation{
+		{ID: 1, TotalIncome: model.Baht(500000), Deductions: map[string]model.Money{"rmf": model.Baht(50000), "child": model.Baht(30000)}},
+		{ID: 2, TotalIncome: model.Baht(600000), Deductions: map[string]model.Money{"ssf": model.Baht(20000), "rmf": model.Baht(0)}},
+	})
+
+	// each allowance type claimed in any calculation gets a column; one not
+	// claimed in a calculation is an empty cell
+	assert.NoError(t, err)
+	assert.Equal(t, "id,createdAt,taxYear,totalIncome,wht,expenses,personalAllowance,donation,kReceipt,"+
+		"deduction child,deduction rmf,deduction ssf,taxableIncome,tax,taxPayable,taxRefund\n"+
+		"1,0001-01-01T00:00:00Z,0,500000.00,0.00,0.00,0.00,0.00,0.00,30000.00,50000.00,,0.00,0.00,0.00,0.00\n"+
+		"2,0001-01-01T00:00:00Z,0,600000.00,0.00,0.00,0.00,0.00,0.00,,0.00,20000.00,0.00,0.00,0.00,0.00\n", buf.String())
+}
+
+func TestWriteCalculationsXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	err := service.WriteCalculations(&buf, service.ExportXLSX, exportedCalculations)
+	assert.NoError(t, err)
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+
+	parts := map[string][]byte{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		assert.NoError(t, err)
+		parts[f.Name], err = io.ReadAll(r)
+		assert.NoError(t, err)
+		r.Close()
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		assert.Contains(t, parts, name)
+	}
+
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	err = xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet)
+	assert.NoError(t, err)
+	assert.Len(t, sheet.Rows, 2)
+	assert.Equal(t, "tax 150,001-500,000", sheet.Rows[0].Cells[11].Inline)
+	assert.Equal(t, "", sheet.Rows[1].Cells[3].Type)
+	assert.Equal(t, "500000.00", sheet.Rows[1].Cells[3].Value)
+	assert.Equal(t, "29000.00", sheet.Rows[1].Cells[11].Value)
+}
+
+func TestWriteUploadResult(t *testing.T) {
+	var buf bytes.Buffer
+	calculations := []*model.TaxCalculation{{UploadRow: 3, TotalIncome: model.Baht(500000), TaxPayable: model.Baht(29000)}}
+	rowErrors := []model.CSVRowError{{Row: 2, Column: "totalIncome", Reason: "is required"}}
+
+	err := service.WriteUploadResult(&buf, service.ExportCSV, calculations, rowErrors)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "row,taxYear,totalIncome,wht,expenses,personalAllowance,donation,kReceipt,taxableIncome,tax,taxPayable,taxRefund,error\n"+
+		"2,,,,,,,,,,,,totalIncome: is required\n"+
+		"3,0,500000.00,0.00,0.00,0.00,0.00,0.00,0.00,0.00,29000.00,0.00,\n", buf.String())
+
+	err = service.WriteUploadResult(&buf, "application/pdf", calculations, rowErrors)
+	assert.ErrorIs(t, err, service.ErrUnknownExportFormat)
+}
+
+func TestWriteWithholdingRun(t *testing.T) {
+	var buf bytes.Buffer
+	run := &model.WithholdingRun{
+		Results: []model.WithholdingResult{{Row: 2, EmployeeID: "E1", Month: 1, Salary: model.Baht(50000), AnnualIncome: model.Baht(600000),
+			TaxableIncome: model.Baht(440000), AnnualTax: model.Baht(29000), Withholding: model.Money(241667)}},
+		Errors: []model.CSVRowError{{Row: 3, Column: "month", Reason: "must be a month from 1 to 12"}},
+	}
+
+	err := service.WriteWithholdingRun(&buf, service.ExportCSV, run)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "row,employeeId,month,salary,annualIncome,taxableIncome,annualTax,ytdWithheld,withholding,trueUp,error\n"+
+		"2,E1,1,50000.00,600000.00,440000.00,29000.00,0.00,2416.67,0.00,\n"+
+		"3,,,,,,,,,,month: must be a month from 1 to 12\n", buf.String())
+}
+
+func TestWriteWithholdingRunEscapesFormulas(t *testing.T) {
+	run := &model.WithholdingRun{
+		Results: []model.WithholdingResult{
+			{Row: 2, EmployeeID: "=HYPERLINK(\"http://example.com\")", Month: 1, TrueUp: model.Baht(-100)},
+			{Row: 3, EmployeeID: "@SUM(A1)", Month: 1},
+			{Row: 4, EmployeeID: "-2+3", Month: 1},
+		},
+		Errors: []model.CSVRowError{{Row: 5, Column: "+cmd", Reason: "unknown column"}},
+	}
+
+	// text is quoted; a negative amount is still a number
+	var buf bytes.Buffer
+	err := service.WriteWithholdingRun(&buf, service.ExportCSV, run)
+	assert.NoError(t, err)
+	assert.Equal(t, "row,employeeId,month,salary,annualIncome,taxableIncome,annualTax,ytdWithheld,withholding,trueUp,error\n"+
+		"2,\"'=HYPERLINK(\"\"http://example.com\"\")\",1,0.00,0.00,0.00,0.00,0.00,0.00,-100.00,\n"+
+		"3,'@SUM(A1),1,0.00,0.00,0.00,0.00,0.00,0.00,0.00,\n"+
+		"4,'-2+3,1,0.00,0.00,0.00,0.00,0.00,0.00,0.00,\n"+
+		"5,,,,,,,,,,'+cmd: unknown column\n", buf.String())
+
+	buf.Reset()
+	err = service.WriteWithholdingRun(&buf, service.ExportXLSX, run)
+	assert.NoError(t, err)
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	f, err := archive.Open("xl/worksheets/sheet1.xml")
+	assert.NoError(t, err)
+	sheet, err := io.ReadAll(f)
+	assert.NoError(t, err)
+	assert.Contains(t, string(sheet), `<t xml:space="preserve">&#39;=HYPERLINK(&#34;http://example.com&#34;)</t>`)
+	assert.Contains(t, string(sheet), `<c><v>-100.00</v></c>`)
+	assert.NotContains(t, string(sheet), `<f>`)
+}
@@ -8,6 +8,7 @@ import (
 	reflect "reflect"
 
 	model "github.com/LGROW101/assessment-tax/model"
+	service "github.com/LGROW101/assessment-tax/service"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -35,30 +36,48 @@ func (m *MockAdminServiceInterface) EXPECT() *MockAdminServiceInterfaceMockRecor
 }
 
 // GetConfig mocks base method.
-func (m *MockAdminServiceInterface) GetConfig() (*model.AdminConfig, error) {
+func (m *MockAdminServiceInterface) GetConfig(taxYear int) (*model.AdminConfig, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetConfig")
+	ret := m.ctrl.Call(m, "GetConfig", taxYear)
 	ret0, _ := ret[0].(*model.AdminConfig)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetConfig indicates an expected call of GetConfig.
-func (mr *MockAdminServiceInterfaceMockRecorder) GetConfig() *gomock.Call {
+func (mr *MockAdminServiceInterfaceMockRecorder) GetConfig(taxYear interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfig", reflect.TypeOf((*MockAdminServiceInterface)(nil).GetConfig))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfig", reflect.TypeOf((*MockAdminServiceInterface)(nil).GetConfig), taxYear)
 }
 
-// UpdateConfig mocks base method.
-func (m *MockAdminServiceInterface) UpdateConfig(config *model.AdminConfig) error {
+// GetRuleSet mocks base method.
+func (m *MockAdminServiceInterface) GetRuleSet(taxYear int) (*model.AdminConfig, service.TaxBracketSchedule, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateConfig", config)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetRuleSet", taxYear)
+	ret0, _ := ret[0].(*model.AdminConfig)
+	ret1, _ := ret[1].(service.TaxBracketSchedule)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetRuleSet indicates an expected call of GetRuleSet.
+func (mr *MockAdminServiceInterfaceMockRecorder) GetRuleSet(taxYear interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRuleSet", reflect.TypeOf((*MockAdminServiceInterface)(nil).GetRuleSet), taxYear)
+}
+
+// GetRuleSetVersion mocks base method.
+func (m *MockAdminServiceInterface) GetRuleSetVersion(taxYear, version int) (*model.AdminConfig, service.TaxBracketSchedule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRuleSetVersion", taxYear, version)
+	ret0, _ := ret[0].(*model.AdminConfig)
+	ret1, _ := ret[1].(service.TaxBracketSchedule)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
-// UpdateConfig indicates an expected call of UpdateConfig.
-func (mr *MockAdminServiceInterfaceMockRecorder) UpdateConfig(config interface{}) *gomock.Call {
+// GetRuleSetVersion indicates an expected call of GetRuleSetVersion.
+func (mr *MockAdminServiceInterfaceMockRecorder) GetRuleSetVersion(taxYear, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateConfig", reflect.TypeOf((*MockAdminServiceInterface)(nil).UpdateConfig), config)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRuleSetVersion", reflect.TypeOf((*MockAdminServiceInterface)(nil).GetRuleSetVersion), taxYear, version)
 }
@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../../service/scenario.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	model "github.com/LGROW101/assessment-tax/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockScenarioService is a mock of ScenarioService interface.
+type MockScenarioService struct {
+	ctrl     *gomock.Controller
+	recorder *MockScenarioServiceMockRecorder
+}
+
+// MockScenarioServiceMockRecorder is the mock recorder for MockScenarioService.
+type MockScenarioServiceMockRecorder struct {
+	mock *MockScenarioService
+}
+
+// NewMockScenarioService creates a new mock instance.
+func NewMockScenarioService(ctrl *gomock.Controller) *MockScenarioService {
+	mock := &MockScenarioService{ctrl: ctrl}
+	mock.recorder = &MockScenarioServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockScenarioService) EXPECT() *MockScenarioServiceMockRecorder {
+	return m.recorder
+}
+
+// Compare mocks base method.
+func (m *MockScenarioService) Compare(req model.ScenarioRequest) (*model.ScenarioComparison, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Compare", req)
+	ret0, _ := ret[0].(*model.ScenarioComparison)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Compare indicates an expected call of Compare.
+func (mr *MockScenarioServiceMockRecorder) Compare(req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Compare", reflect.TypeOf((*MockScenarioService)(nil).Compare), req)
+}
@@ -8,6 +8,7 @@ import (
 	reflect "reflect"
 
 	model "github.com/LGROW101/assessment-tax/model"
+	service "github.com/LGROW101/assessment-tax/service"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -34,32 +35,123 @@ func (m *MockTaxCalculatorService) EXPECT() *MockTaxCalculatorServiceMockRecorde
 	return m.recorder
 }
 
+// AllowanceTypes mocks base method.
+func (m *MockTaxCalculatorService) AllowanceTypes() ([]*model.AllowanceType, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AllowanceTypes")
+	ret0, _ := ret[0].([]*model.AllowanceType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AllowanceTypes indicates an expected call of AllowanceTypes.
+func (mr *MockTaxCalculatorServiceMockRecorder) AllowanceTypes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllowanceTypes", reflect.TypeOf((*MockTaxCalculatorService)(nil).AllowanceTypes))
+}
+
+// CalculateHousehold mocks base method.
+func (m *MockTaxCalculatorService) CalculateHousehold(req model.HouseholdRequest) (*model.HouseholdResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CalculateHousehold", req)
+	ret0, _ := ret[0].(*model.HouseholdResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CalculateHousehold indicates an expected call of CalculateHousehold.
+func (mr *MockTaxCalculatorServiceMockRecorder) CalculateHousehold(req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CalculateHousehold", reflect.TypeOf((*MockTaxCalculatorService)(nil).CalculateHousehold), req)
+}
+
 // CalculateTax mocks base method.
-func (m *MockTaxCalculatorService) CalculateTax(totalIncome, wht float64, allowances []model.Allowance) (*model.TaxCalculationResponse, error) {
+func (m *MockTaxCalculatorService) CalculateTax(req model.TaxCalculationRequest) (*model.TaxCalculationResponse, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CalculateTax", totalIncome, wht, allowances)
+	ret := m.ctrl.Call(m, "CalculateTax", req)
 	ret0, _ := ret[0].(*model.TaxCalculationResponse)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CalculateTax indicates an expected call of CalculateTax.
-func (mr *MockTaxCalculatorServiceMockRecorder) CalculateTax(totalIncome, wht, allowances interface{}) *gomock.Call {
+func (mr *MockTaxCalculatorServiceMockRecorder) CalculateTax(req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CalculateTax", reflect.TypeOf((*MockTaxCalculatorService)(nil).CalculateTax), req)
+}
+
+// Compute mocks base method.
+func (m *MockTaxCalculatorService) Compute(req model.TaxCalculationRequest) (*model.TaxCalculation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Compute", req)
+	ret0, _ := ret[0].(*model.TaxCalculation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Compute indicates an expected call of Compute.
+func (mr *MockTaxCalculatorServiceMockRecorder) Compute(req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CalculateTax", reflect.TypeOf((*MockTaxCalculatorService)(nil).CalculateTax), totalIncome, wht, allowances)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Compute", reflect.TypeOf((*MockTaxCalculatorService)(nil).Compute), req)
 }
 
 // GetAllCalculations mocks base method.
-func (m *MockTaxCalculatorService) GetAllCalculations() ([]*model.TaxCalculation, error) {
+func (m *MockTaxCalculatorService) GetAllCalculations(filter model.TaxCalculationFilter) ([]*model.TaxCalculation, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllCalculations")
+	ret := m.ctrl.Call(m, "GetAllCalculations", filter)
 	ret0, _ := ret[0].([]*model.TaxCalculation)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetAllCalculations indicates an expected call of GetAllCalculations.
+func (mr *MockTaxCalculatorServiceMockRecorder) GetAllCalculations(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllCalculations", reflect.TypeOf((*MockTaxCalculatorService)(nil).GetAllCalculations), filter)
+}
+
+// GetCalculation mocks base method.
+func (m *MockTaxCalculatorService) GetCalculation(id uint) (*model.TaxCalculation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCalculation", id)
+	ret0, _ := ret[0].(*model.TaxCalculation)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAllCalculations indicates an expected call of GetAllCalculations.
-func (mr *MockTaxCalculatorServiceMockRecorder) GetAllCalculations() *gomock.Call {
+// GetCalculation indicates an expected call of GetCalculation.
+func (mr *MockTaxCalculatorServiceMockRecorder) GetCalculation(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCalculation", reflect.TypeOf((*MockTaxCalculatorService)(nil).GetCalculation), id)
+}
+
+// GrossUp mocks base method.
+func (m *MockTaxCalculatorService) GrossUp(req model.GrossUpRequest) (*model.GrossUpResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrossUp", req)
+	ret0, _ := ret[0].(*model.GrossUpResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GrossUp indicates an expected call of GrossUp.
+func (mr *MockTaxCalculatorServiceMockRecorder) GrossUp(req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrossUp", reflect.TypeOf((*MockTaxCalculatorService)(nil).GrossUp), req)
+}
+
+// LoadRuleSet mocks base method.
+func (m *MockTaxCalculatorService) LoadRuleSet(req model.TaxCalculationRequest) (*service.RuleSet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadRuleSet", req)
+	ret0, _ := ret[0].(*service.RuleSet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadRuleSet indicates an expected call of LoadRuleSet.
+func (mr *MockTaxCalculatorServiceMockRecorder) LoadRuleSet(req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllCalculations", reflect.TypeOf((*MockTaxCalculatorService)(nil).GetAllCalculations))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadRuleSet", reflect.TypeOf((*MockTaxCalculatorService)(nil).LoadRuleSet), req)
 }
@@ -8,6 +8,7 @@ import (
 	io "io"
 	reflect "reflect"
 
+	model "github.com/LGROW101/assessment-tax/model"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -34,27 +35,25 @@ func (m *MockTaxCSVService) EXPECT() *MockTaxCSVServiceMockRecorder {
 	return m.recorder
 }
 
-// CalculateTax mocks base method.
-func (m *MockTaxCSVService) CalculateTax(totalIncome, wht, donation float64) (float64, float64, error) {
+// CheckHeader mocks base method.
+func (m *MockTaxCSVService) CheckHeader(reader io.Reader) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CalculateTax", totalIncome, wht, donation)
-	ret0, _ := ret[0].(float64)
-	ret1, _ := ret[1].(float64)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret := m.ctrl.Call(m, "CheckHeader", reader)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// CalculateTax indicates an expected call of CalculateTax.
-func (mr *MockTaxCSVServiceMockRecorder) CalculateTax(totalIncome, wht, donation interface{}) *gomock.Call {
+// CheckHeader indicates an expected call of CheckHeader.
+func (mr *MockTaxCSVServiceMockRecorder) CheckHeader(reader interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CalculateTax", reflect.TypeOf((*MockTaxCSVService)(nil).CalculateTax), totalIncome, wht, donation)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckHeader", reflect.TypeOf((*MockTaxCSVService)(nil).CheckHeader), reader)
 }
 
 // ImportCSV mocks base method.
-func (m *MockTaxCSVService) ImportCSV(reader io.Reader) ([]map[string]float64, error) {
+func (m *MockTaxCSVService) ImportCSV(reader io.Reader) (*model.CSVImportResult, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ImportCSV", reader)
-	ret0, _ := ret[0].([]map[string]float64)
+	ret0, _ := ret[0].(*model.CSVImportResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -0,0 +1,93 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../../service/taxjob.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	model "github.com/LGROW101/assessment-tax/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTaxJobService is a mock of TaxJobService interface.
+type MockTaxJobService struct {
+	ctrl     *gomock.Controller
+	recorder *MockTaxJobServiceMockRecorder
+}
+
+// MockTaxJobServiceMockRecorder is the mock recorder for MockTaxJobService.
+type MockTaxJobServiceMockRecorder struct {
+	mock *MockTaxJobService
+}
+
+// NewMockTaxJobService creates a new mock instance.
+func NewMockTaxJobService(ctrl *gomock.Controller) *MockTaxJobService {
+	mock := &MockTaxJobService{ctrl: ctrl}
+	mock.recorder = &MockTaxJobServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTaxJobService) EXPECT() *MockTaxJobServiceMockRecorder {
+	return m.recorder
+}
+
+// GetJob mocks base method.
+func (m *MockTaxJobService) GetJob(id uint) (*model.TaxUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJob", id)
+	ret0, _ := ret[0].(*model.TaxUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJob indicates an expected call of GetJob.
+func (mr *MockTaxJobServiceMockRecorder) GetJob(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJob", reflect.TypeOf((*MockTaxJobService)(nil).GetJob), id)
+}
+
+// Run mocks base method.
+func (m *MockTaxJobService) Run(ctx context.Context) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Run", ctx)
+}
+
+// Run indicates an expected call of Run.
+func (mr *MockTaxJobServiceMockRecorder) Run(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Run", reflect.TypeOf((*MockTaxJobService)(nil).Run), ctx)
+}
+
+// Submit mocks base method.
+func (m *MockTaxJobService) Submit(filename string, content []byte) (*model.TaxUpload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Submit", filename, content)
+	ret0, _ := ret[0].(*model.TaxUpload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Submit indicates an expected call of Submit.
+func (mr *MockTaxJobServiceMockRecorder) Submit(filename, content interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Submit", reflect.TypeOf((*MockTaxJobService)(nil).Submit), filename, content)
+}
+
+// WriteResult mocks base method.
+func (m *MockTaxJobService) WriteResult(id uint, format string, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteResult", id, format, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteResult indicates an expected call of WriteResult.
+func (mr *MockTaxJobServiceMockRecorder) WriteResult(id, format, w interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteResult", reflect.TypeOf((*MockTaxJobService)(nil).WriteResult), id, format, w)
+}
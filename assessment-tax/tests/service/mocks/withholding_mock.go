@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../../service/withholding.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	io "io"
+	reflect "reflect"
+
+	model "github.com/LGROW101/assessment-tax/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockWithholdingService is a mock of WithholdingService interface.
+type MockWithholdingService struct {
+	ctrl     *gomock.Controller
+	recorder *MockWithholdingServiceMockRecorder
+}
+
+// MockWithholdingServiceMockRecorder is the mock recorder for MockWithholdingService.
+type MockWithholdingServiceMockRecorder struct {
+	mock *MockWithholdingService
+}
+
+// NewMockWithholdingService creates a new mock instance.
+func NewMockWithholdingService(ctrl *gomock.Controller) *MockWithholdingService {
+	mock := &MockWithholdingService{ctrl: ctrl}
+	mock.recorder = &MockWithholdingServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWithholdingService) EXPECT() *MockWithholdingServiceMockRecorder {
+	return m.recorder
+}
+
+// Calculate mocks base method.
+func (m *MockWithholdingService) Calculate(req model.WithholdingRequest) (*model.WithholdingResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Calculate", req)
+	ret0, _ := ret[0].(*model.WithholdingResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Calculate indicates an expected call of Calculate.
+func (mr *MockWithholdingServiceMockRecorder) Calculate(req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Calculate", reflect.TypeOf((*MockWithholdingService)(nil).Calculate), req)
+}
+
+// ImportCSV mocks base method.
+func (m *MockWithholdingService) ImportCSV(reader io.Reader) (*model.WithholdingRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportCSV", reader)
+	ret0, _ := ret[0].(*model.WithholdingRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportCSV indicates an expected call of ImportCSV.
+func (mr *MockWithholdingServiceMockRecorder) ImportCSV(reader interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportCSV", reflect.TypeOf((*MockWithholdingService)(nil).ImportCSV), reader)
+}
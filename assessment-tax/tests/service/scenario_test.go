@@ -0,0 +1,94 @@
+package service_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/service"
+	"github.com/LGROW101/assessment-tax/tests/repository/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScenarioService_Compare(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	adminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminRepo.EXPECT().GetConfig(2024).Return(&model.AdminConfig{
+		TaxYear:           2024,
+		Version:           2,
+		PersonalDeduction: model.Baht(60000),
+		Brackets:          taxBrackets,
+	}, nil).Times(3)
+	adminRepo.EXPECT().GetConfigVersion(2024, 1).Return(&model.AdminConfig{
+		TaxYear:           2024,
+		Version:           1,
+		PersonalDeduction: model.Baht(100000),
+		Brackets:          taxBrackets,
+	}, nil)
+
+	rmfCap, rmfRate := model.Baht(500000), model.Percent(30)
+	taxSvc := service.NewTaxCalculatorService(nil, adminRepo, allowanceCatalogue(ctrl,
+		&model.AllowanceType{Key: "rmf", CapAmount: &rmfCap, CapRate: &rmfRate, Stage: model.AllowanceStageBefore},
+	))
+	scenarioSvc := service.NewScenarioService(taxSvc)
+
+	comparison, err := scenarioSvc.Compare(model.ScenarioRequest{
+		Base: model.TaxCalculationRequest{
+			TaxYear:     2024,
+			TotalIncome: model.Baht(700000),
+			Allowances:  []model.Allowance{{AllowanceType: "rmf", Amount: model.Baht(50000)}},
+		},
+		Variations: []model.ScenarioVariation{
+			{Name: "More RMF", Allowances: []model.Allowance{{AllowanceType: "rmf", Amount: model.Baht(100000)}}},
+			{ExtraIncome: model.Baht(100000)},
+			{Name: "First rule set", RuleSetVersion: 1},
+		},
+	})
+
+	assert.NoError(t, err)
+	// base: 700,000 - 60,000 - 50,000 = 590,000
+	assert.Equal(t, model.Baht(590000), comparison.Base.TaxableIncome)
+	assert.Equal(t, model.Baht(48500), comparison.Base.Tax)
+	assert.Equal(t, model.Percent(15), comparison.Base.MarginalRate)
+	assert.Nil(t, comparison.Base.Delta)
+	assert.Len(t, comparison.Variations, 3)
+
+	moreRMF := comparison.Variations[0]
+	assert.Equal(t, "More RMF", moreRMF.Name)
+	assert.Equal(t, model.Baht(-100000), moreRMF.Delta.TaxableIncome)
+	assert.Equal(t, model.Baht(-14500), moreRMF.Delta.Tax) // 90,000 at 15% and 10,000 at 10%
+
+	extraIncome := comparison.Variations[1]
+	assert.Equal(t, "variation 2", extraIncome.Name)
+	assert.Equal(t, model.Baht(100000), extraIncome.Delta.TotalIncome)
+	assert.Equal(t, model.Baht(15000), extraIncome.Delta.Tax)
+
+	firstRuleSet := comparison.Variations[2]
+	assert.Equal(t, 1, firstRuleSet.RuleSetVersion)
+	assert.Equal(t, model.Baht(-6000), firstRuleSet.Delta.Tax)
+}
+
+func TestScenarioService_CompareNamesFailingVariation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	adminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminRepo.EXPECT().GetConfig(0).Return(&model.AdminConfig{
+		PersonalDeduction: model.Baht(60000),
+		Brackets:          taxBrackets,
+	}, nil)
+	adminRepo.EXPECT().GetConfig(2030).Return(nil, nil)
+
+	scenarioSvc := service.NewScenarioService(service.NewTaxCalculatorService(nil, adminRepo, allowanceCatalogue(ctrl)))
+	_, err := scenarioSvc.Compare(model.ScenarioRequest{
+		Base:       model.TaxCalculationRequest{TotalIncome: model.Baht(500000)},
+		Variations: []model.ScenarioVariation{{Name: "Next year", TaxYear: 2030}},
+	})
+
+	assert.ErrorIs(t, err, service.ErrRuleSetNotFound)
+	assert.Contains(t, err.Error(), "Next year")
+	assert.False(t, errors.Is(err, service.ErrUnknownAllowanceType))
+}
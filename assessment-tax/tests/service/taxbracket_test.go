@@ -0,0 +1,98 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/service"
+	"github.com/stretchr/testify/assert"
+)
+
+var taxBrackets = []model.TaxBracket{
+	{Threshold: model.Baht(0), Rate: model.Percent(0)},
+	{Threshold: model.Baht(150000), Rate: model.Percent(10)},
+	{Threshold: model.Baht(500000), Rate: model.Percent(15)},
+	{Threshold: model.Baht(1000000), Rate: model.Percent(20)},
+	{Threshold: model.Baht(2000000), Rate: model.Percent(35)},
+}
+
+func TestTaxBracketSchedule_Tax(t *testing.T) {
+	schedule, err := service.NewTaxBracketSchedule(taxBrackets)
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		taxableIncome model.Money
+		expected      model.Money
+	}{
+		{"Negative income", model.Baht(-10000), 0},
+		{"Exempt bracket", model.Baht(150000), 0},
+		{"Second bracket", model.Baht(440000), model.Baht(29000)},
+		{"Third bracket", model.Baht(675000), model.Baht(61250)},
+		{"Fourth bracket", model.Baht(1500000), model.Baht(210000)},
+		{"Top bracket", model.Baht(3000000), model.Baht(660000)},
+		{"Satang rounding", model.Money(15000015), model.Money(2)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, schedule.Tax(tc.taxableIncome))
+		})
+	}
+}
+
+func TestTaxBracketSchedule_Levels(t *testing.T) {
+	schedule, err := service.NewTaxBracketSchedule(taxBrackets)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"0-150,000",
+		"150,001-500,000",
+		"500,001-1,000,000",
+		"1,000,001-2,000,000",
+		"2,000,001 ขึ้นไป",
+	}, schedule.Levels())
+}
+
+func TestTaxBracketSchedule_Breakdown(t *testing.T) {
+	schedule, err := service.NewTaxBracketSchedule(taxBrackets)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []model.TaxRate{
+		{Level: "0-150,000", Tax: model.Baht(0)},
+		{Level: "150,001-500,000", Tax: model.Baht(35000)},
+		{Level: "500,001-1,000,000", Tax: model.Baht(75000)},
+		{Level: "1,000,001-2,000,000", Tax: model.Baht(100000)},
+		{Level: "2,000,001 ขึ้นไป", Tax: model.Baht(0)},
+	}, schedule.Breakdown(model.Baht(1500000)))
+}
+
+func TestTaxBracketSchedule_MarginalRate(t *testing.T) {
+	schedule, err := service.NewTaxBracketSchedule(taxBrackets)
+	assert.NoError(t, err)
+
+	assert.Equal(t, model.Percent(0), schedule.MarginalRate(model.Baht(-10000)))
+	assert.Equal(t, model.Percent(0), schedule.MarginalRate(model.Baht(149999)))
+	assert.Equal(t, model.Percent(10), schedule.MarginalRate(model.Baht(150000)))
+	assert.Equal(t, model.Percent(15), schedule.MarginalRate(model.Baht(675000)))
+	assert.Equal(t, model.Percent(35), schedule.MarginalRate(model.Baht(3000000)))
+}
+
+func TestNewTaxBracketSchedule_Invalid(t *testing.T) {
+	testCases := []struct {
+		name     string
+		brackets []model.TaxBracket
+	}{
+		{"Empty", nil},
+		{"Not starting at zero", []model.TaxBracket{{Threshold: model.Baht(100), Rate: model.Percent(10)}}},
+		{"Unordered", []model.TaxBracket{{Threshold: model.Baht(0)}, {Threshold: model.Baht(500000), Rate: model.Percent(10)}, {Threshold: model.Baht(150000), Rate: model.Percent(5)}}},
+		{"Invalid rate", []model.TaxBracket{{Threshold: model.Baht(0), Rate: model.Percent(150)}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := service.NewTaxBracketSchedule(tc.brackets)
+			assert.Error(t, err)
+		})
+	}
+}
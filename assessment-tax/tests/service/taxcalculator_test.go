@@ -17,30 +17,32 @@ func TestTaxCalculatorService_GetAllCalculations(t *testing.T) {
 	mockRepo := mocks.NewMockTaxRepository(ctrl)
 	expectedCalculations := []*model.TaxCalculation{
 		{
-			TotalIncome:       1000000.0,
-			WHT:               100000.0,
-			PersonalAllowance: 60000.0,
-			Donation:          10000.0,
-			KReceipt:          20000.0,
-			Tax:               89500.0,
-			TaxPayable:        89500.0,
+			TotalIncome:       model.Baht(1000000),
+			WHT:               model.Baht(100000),
+			PersonalAllowance: model.Baht(60000),
+			Donation:          model.Baht(10000),
+			KReceipt:          model.Baht(20000),
+			Tax:               model.Baht(89500),
+			TaxPayable:        model.Baht(89500),
 			TaxLevel: []model.TaxRate{
-				{Level: "0-150,000", Tax: 0},
-				{Level: "150,001-500,000", Tax: 0},
-				{Level: "500,001-1,000,000", Tax: 89500.0},
-				{Level: "1,000,001-2,000,000", Tax: 0},
-				{Level: "2,000,001 ขึ้นไป", Tax: 0},
+				{Level: "0-150,000", Tax: model.Baht(0)},
+				{Level: "150,001-500,000", Tax: model.Baht(0)},
+				{Level: "500,001-1,000,000", Tax: model.Baht(89500)},
+				{Level: "1,000,001-2,000,000", Tax: model.Baht(0)},
+				{Level: "2,000,001 ขึ้นไป", Tax: model.Baht(0)},
 			},
 		},
 	}
-	mockRepo.EXPECT().GetAllCalculations().Return(expectedCalculations, nil)
+	filter := model.TaxCalculationFilter{TaxYear: 2024, Limit: 10}
+	mockRepo.EXPECT().GetAllCalculations(filter).Return(expectedCalculations, "cursor", nil)
 
 	mockAdminRepo := mocks.NewMockAdminRepository(ctrl)
-	taxSvc := service.NewTaxCalculatorService(mockRepo, mockAdminRepo)
-	calculations, err := taxSvc.GetAllCalculations()
+	taxSvc := service.NewTaxCalculatorService(mockRepo, mockAdminRepo, allowanceCatalogue(ctrl))
+	calculations, nextCursor, err := taxSvc.GetAllCalculations(filter)
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedCalculations, calculations)
+	assert.Equal(t, "cursor", nextCursor)
 }
 func TestTaxCalculatorService_CalculateTax(t *testing.T) {
 	ctrl := gomock.NewController(t)
@@ -52,85 +54,617 @@ func TestTaxCalculatorService_CalculateTax(t *testing.T) {
 	mockAdminRepo := mocks.NewMockAdminRepository(ctrl)
 
 	config := &model.AdminConfig{
-		PersonalDeduction: 60000,
-		KReceipt:          30000,
+		ID:                3,
+		TaxYear:           2024,
+		Version:           2,
+		PersonalDeduction: model.Baht(60000),
+		KReceipt:          model.Baht(30000),
+		DonationCap:       model.Baht(100000),
+		Brackets:          taxBrackets,
 	}
-	mockAdminRepo.EXPECT().GetConfig().Return(config, nil)
+	mockAdminRepo.EXPECT().GetConfig(0).Return(config, nil)
 
-	iotalIncome := 1000000.0
-	wht := 100000.0
+	iotalIncome := model.Baht(1000000)
+	wht := model.Baht(100000)
 	allowances := []model.Allowance{
-		{AllowanceType: "donation", Amount: 10000.0},
-		{AllowanceType: "k-receipt", Amount: 20000.0},
+		{AllowanceType: "donation", Amount: model.Baht(10000)},
+		{AllowanceType: "k-receipt", Amount: model.Baht(20000)},
 	}
 
-	taxableIncome := iotalIncome - config.PersonalDeduction - allowances[0].Amount - allowances[1].Amount
-	var tax float64
-	switch {
-	case taxableIncome <= 150000:
-		tax = taxableIncome * 0.05
-	case taxableIncome <= 500000:
-		tax = 7500 + (taxableIncome-150000)*0.10
-	case taxableIncome <= 1000000:
-		tax = 35000 + (taxableIncome-500000)*0.15
-	case taxableIncome <= 2000000:
-		tax = 110000 + (taxableIncome-1000000)*0.20
-	default:
-		tax = 310000 + (taxableIncome-2000000)*0.35
-	}
+	request := model.TaxCalculationRequest{TotalIncome: iotalIncome, WHT: wht, Allowances: allowances}
 
-	taxPayable := tax - wht
-	if taxPayable < 0 {
-		taxPayable = 0
-	}
+	// taxable income 910,000: 35,000 in the second bracket plus 15% of 410,000
+	tax := model.Baht(96500)
+	taxPayable := model.Money(0)
 	taxRefund := wht - tax
-	if taxRefund < 0 {
-		taxRefund = 0
-	}
 
 	expectedTaxLevel := []model.TaxRate{
-		{Level: "0-150,000", Tax: 0},
-		{Level: "150,001-500,000", Tax: 0},
-		{Level: "500,001-1,000,000", Tax: 0},
-		{Level: "1,000,001-2,000,000", Tax: 0},
-		{Level: "2,000,001 ขึ้นไป", Tax: 0},
-	}
-	switch {
-	case taxableIncome <= 150000:
-		expectedTaxLevel[0].Tax = taxPayable
-	case taxableIncome <= 500000:
-		expectedTaxLevel[1].Tax = taxPayable
-	case taxableIncome <= 1000000:
-		expectedTaxLevel[2].Tax = taxPayable
-	case taxableIncome <= 2000000:
-		expectedTaxLevel[3].Tax = taxPayable
-	default:
-		expectedTaxLevel[4].Tax = taxPayable
+		{Level: "0-150,000", Tax: model.Baht(0)},
+		{Level: "150,001-500,000", Tax: model.Baht(35000)},
+		{Level: "500,001-1,000,000", Tax: model.Baht(61500)},
+		{Level: "1,000,001-2,000,000", Tax: model.Baht(0)},
+		{Level: "2,000,001 ขึ้นไป", Tax: model.Baht(0)},
 	}
 
 	// Initialize expectedTaxCalculation with the expected values
 	expectedTaxCalculation = &model.TaxCalculation{
+		TaxYear:           2024,
+		RuleSetID:         config.ID,
+		RuleSetVersion:    config.Version,
 		TotalIncome:       iotalIncome,
 		WHT:               wht,
 		PersonalAllowance: config.PersonalDeduction,
 		Donation:          allowances[0].Amount,
 		KReceipt:          allowances[1].Amount,
+		TaxableIncome:     model.Baht(910000),
 		Tax:               tax,
 		TaxPayable:        taxPayable,
 		TaxRefund:         taxRefund,
 		TaxLevel:          expectedTaxLevel,
+		MarginalRate:      model.Percent(15),
+		Steps: []model.CalculationStep{
+			{Step: model.StepIncome, Amount: iotalIncome},
+			{Step: model.StepPersonalAllowance, Amount: model.Baht(60000)},
+			{Step: model.StepAllowance, Key: "k-receipt", Claimed: &allowances[1].Amount, Amount: model.Baht(20000), Reason: "claimed in full"},
+			{Step: model.StepAllowance, Key: "donation", Claimed: &allowances[0].Amount, Amount: model.Baht(10000), Reason: "claimed in full"},
+			{Step: model.StepTaxableIncome, Amount: model.Baht(910000)},
+			{Step: model.StepBracket, Key: "0-150,000", Base: moneyOf(model.Baht(150000)), Rate: rateOf(0), Amount: 0},
+			{Step: model.StepBracket, Key: "150,001-500,000", Base: moneyOf(model.Baht(350000)), Rate: rateOf(model.Percent(10)), Amount: model.Baht(35000)},
+			{Step: model.StepBracket, Key: "500,001-1,000,000", Base: moneyOf(model.Baht(410000)), Rate: rateOf(model.Percent(15)), Amount: model.Baht(61500)},
+			{Step: model.StepTax, Amount: tax},
+			{Step: model.StepWHT, Amount: wht},
+			{Step: model.StepTaxRefund, Amount: taxRefund},
+		},
+		Allowances: allowances,
+		Deductions: map[string]model.Money{model.AllowanceDonation: model.Baht(10000), model.AllowanceKReceipt: model.Baht(20000)},
+		Request:    request,
 	}
 
 	mockRepo.EXPECT().Save(expectedTaxCalculation).Return(nil)
 	expectedTaxCalculationResponse := &model.TaxCalculationResponse{
 		Tax:       nil,
 		TaxRefund: &taxRefund,
+		WHT:       wht,
 		TaxLevel:  expectedTaxLevel,
 	}
 
-	taxSvc := service.NewTaxCalculatorService(mockRepo, mockAdminRepo)
-	taxCalculation, err := taxSvc.CalculateTax(iotalIncome, wht, allowances)
+	taxSvc := service.NewTaxCalculatorService(mockRepo, mockAdminRepo, allowanceCatalogue(ctrl))
+	taxCalculation, err := taxSvc.CalculateTax(request)
 
 	assert.NoError(t, err)
 	assert.Equal(t, expectedTaxCalculationResponse, taxCalculation)
 }
+
+func TestTaxCalculatorService_GetCalculation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockTaxRepository(ctrl)
+	expectedCalculation := &model.TaxCalculation{ID: 7, RuleSetVersion: 2, TaxPayable: model.Baht(29000)}
+	mockRepo.EXPECT().GetCalculation(uint(7)).Return(expectedCalculation, nil)
+
+	taxSvc := service.NewTaxCalculatorService(mockRepo, mocks.NewMockAdminRepository(ctrl), allowanceCatalogue(ctrl))
+	calculation, err := taxSvc.GetCalculation(7)
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedCalculation, calculation)
+}
+
+func moneyOf(m model.Money) *model.Money { return &m }
+
+func rateOf(r model.Rate) *model.Rate { return &r }
+
+var builtinAllowanceTypes = []*model.AllowanceType{
+	{Key: model.AllowanceDonation, DisplayName: "เงินบริจาค", Stage: model.AllowanceStageAfter},
+	{Key: model.AllowanceKReceipt, DisplayName: "k-receipt", Stage: model.AllowanceStageBefore},
+}
+
+// allowanceCatalogue returns a repository serving the built-in allowance
+// types followed by extra.
+func allowanceCatalogue(ctrl *gomock.Controller, extra ...*model.AllowanceType) *mocks.MockAllowanceRepository {
+	allowanceTypes := append(append([]*model.AllowanceType{}, builtinAllowanceTypes...), extra...)
+	allowanceRepo := mocks.NewMockAllowanceRepository(ctrl)
+	allowanceRepo.EXPECT().ListAllowanceTypes().Return(allowanceTypes, nil).AnyTimes()
+	return allowanceRepo
+}
+
+func TestTaxCalculatorService_ComputeWithCatalogue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	adminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminRepo.EXPECT().GetConfig(0).Return(&model.AdminConfig{
+		PersonalDeduction: model.Baht(60000),
+		KReceipt:          model.Baht(50000),
+		DonationCap:       model.Baht(100000),
+		Brackets:          []model.TaxBracket{{Threshold: model.Baht(0), Rate: model.Percent(10)}},
+	}, nil)
+
+	capAmount, capRate := model.Baht(100000), model.Percent(15)
+	tenPercent := model.Percent(10)
+	taxSvc := service.NewTaxCalculatorService(nil, adminRepo, allowanceCatalogue(ctrl,
+		&model.AllowanceType{Key: "provident-fund", CapAmount: &capAmount, CapRate: &capRate, Stage: model.AllowanceStageBefore},
+		&model.AllowanceType{Key: "sports", CapRate: &tenPercent, Stage: model.AllowanceStageAfter},
+	))
+
+	taxCalculation, err := taxSvc.Compute(model.TaxCalculationRequest{
+		TotalIncome: model.Baht(560000),
+		Allowances: []model.Allowance{
+			{AllowanceType: "provident-fund", Amount: model.Baht(60000)},
+			{AllowanceType: "provident-fund", Amount: model.Baht(20000)},
+			{AllowanceType: model.AllowanceKReceipt, Amount: model.Baht(70000)},
+			{AllowanceType: "sports", Amount: model.Baht(90000)},
+		},
+	})
+
+	// before: provident fund min(80,000, 100,000, 15% of 560,000) = 80,000,
+	// k-receipt 50,000; after: sports 10% of the remaining 370,000
+	assert.NoError(t, err)
+	assert.Equal(t, model.Baht(50000), taxCalculation.KReceipt)
+	assert.Equal(t, model.Baht(500000-80000-50000-37000), taxCalculation.TaxableIncome)
+}
+
+func TestTaxCalculatorService_ComputeWithUnknownAllowance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	adminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminRepo.EXPECT().GetConfig(0).Return(&model.AdminConfig{
+		PersonalDeduction: model.Baht(60000),
+		Brackets:          []model.TaxBracket{{Threshold: model.Baht(0), Rate: model.Percent(0)}},
+	}, nil)
+
+	taxSvc := service.NewTaxCalculatorService(nil, adminRepo, allowanceCatalogue(ctrl))
+	_, err := taxSvc.Compute(model.TaxCalculationRequest{
+		TotalIncome: model.Baht(500000),
+		Allowances:  []model.Allowance{{AllowanceType: "lottery", Amount: model.Baht(1000)}},
+	})
+
+	assert.ErrorIs(t, err, service.ErrUnknownAllowanceType)
+}
+
+func TestTaxCalculatorService_ComputeWithRuleSetCatalogue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// the rule set keeps the catalogue it was stored with, so the current
+	// catalogue is not read
+	capAmount := model.Baht(10000)
+	adminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminRepo.EXPECT().GetConfigVersion(2024, 1).Return(&model.AdminConfig{
+		TaxYear:           2024,
+		Version:           1,
+		PersonalDeduction: model.Baht(60000),
+		Brackets:          []model.TaxBracket{{Threshold: model.Baht(0), Rate: model.Percent(10)}},
+		AllowanceTypes: append(append([]*model.AllowanceType{}, builtinAllowanceTypes...),
+			&model.AllowanceType{Key: "sports", CapAmount: &capAmount, Stage: model.AllowanceStageBefore}),
+	}, nil)
+
+	taxSvc := service.NewTaxCalculatorService(nil, adminRepo, mocks.NewMockAllowanceRepository(ctrl))
+	taxCalculation, err := taxSvc.Compute(model.TaxCalculationRequest{
+		TaxYear:        2024,
+		RuleSetVersion: 1,
+		TotalIncome:    model.Baht(500000),
+		Allowances:     []model.Allowance{{AllowanceType: "sports", Amount: model.Baht(15000)}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.Baht(500000-60000-10000), taxCalculation.TaxableIncome)
+}
+
+func TestTaxCalculatorService_ComputeDonations(t *testing.T) {
+	tenPercent := model.Percent(10)
+	catalogue := []*model.AllowanceType{
+		{Key: model.AllowanceDonation, CapRate: &tenPercent, Stage: model.AllowanceStageAfter},
+		{Key: model.AllowanceEducationDonation, CapRate: &tenPercent, Stage: model.AllowanceStageAfter},
+		{Key: model.AllowanceKReceipt, Stage: model.AllowanceStageBefore},
+		{Key: "sports", Stage: model.AllowanceStageAfter},
+	}
+
+	testCases := []struct {
+		name       string
+		cap        model.Money
+		allowances []model.Allowance
+		donation   model.Money
+		taxable    model.Money
+	}{
+		{
+			"General donation capped at 10% after other allowances",
+			model.Baht(100000),
+			[]model.Allowance{
+				{AllowanceType: model.AllowanceDonation, Amount: model.Baht(200000)},
+				{AllowanceType: model.AllowanceKReceipt, Amount: model.Baht(40000)},
+				{AllowanceType: "sports", Amount: model.Baht(20000)},
+			},
+			model.Baht(38000), // 10% of 500,000 - 40,000 - 20,000 - 60,000
+			model.Baht(342000),
+		},
+		{
+			"Education donation counts twice",
+			model.Baht(100000),
+			[]model.Allowance{{AllowanceType: model.AllowanceEducationDonation, Amount: model.Baht(10000)}},
+			model.Baht(20000),
+			model.Baht(420000),
+		},
+		{
+			"General donation capped after the education donation",
+			model.Baht(100000),
+			[]model.Allowance{
+				{AllowanceType: model.AllowanceDonation, Amount: model.Baht(50000)},
+				{AllowanceType: model.AllowanceEducationDonation, Amount: model.Baht(50000)},
+			},
+			model.Baht(44000 + 39600), // 10% of 440,000, then 10% of 396,000
+			model.Baht(440000 - 44000 - 39600),
+		},
+		{
+			"Rule set cap limits both donations",
+			model.Baht(50000),
+			[]model.Allowance{
+				{AllowanceType: model.AllowanceDonation, Amount: model.Baht(30000)},
+				{AllowanceType: model.AllowanceEducationDonation, Amount: model.Baht(20000)},
+			},
+			model.Baht(50000),
+			model.Baht(390000),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			adminRepo := mocks.NewMockAdminRepository(ctrl)
+			adminRepo.EXPECT().GetConfig(0).Return(&model.AdminConfig{
+				PersonalDeduction: model.Baht(60000),
+				KReceipt:          model.Baht(50000),
+				DonationCap:       tc.cap,
+				Brackets:          []model.TaxBracket{{Threshold: model.Baht(0), Rate: model.Percent(0)}},
+			}, nil)
+			allowanceRepo := mocks.NewMockAllowanceRepository(ctrl)
+			allowanceRepo.EXPECT().ListAllowanceTypes().Return(catalogue, nil)
+
+			taxSvc := service.NewTaxCalculatorService(nil, adminRepo, allowanceRepo)
+			taxCalculation, err := taxSvc.Compute(model.TaxCalculationRequest{
+				TotalIncome: model.Baht(500000),
+				Allowances:  tc.allowances,
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.donation, taxCalculation.Donation)
+			assert.Equal(t, tc.taxable, taxCalculation.TaxableIncome)
+		})
+	}
+}
+
+func TestTaxCalculatorService_ComputeThaiAllowances(t *testing.T) {
+	childUnit, spouseUnit, oneSpouse := model.Baht(30000), model.Baht(60000), 1
+	insuranceCap, retirementCap := model.Baht(100000), model.Baht(500000)
+	lifeCap, healthCap := model.Baht(100000), model.Baht(25000)
+	fundCap, fundRate, rmfRate := model.Baht(500000), model.Percent(15), model.Percent(30)
+	catalogue := []*model.AllowanceType{
+		{Key: "child", UnitAmount: &childUnit, Stage: model.AllowanceStageBefore},
+		{Key: "spouse", UnitAmount: &spouseUnit, MaxUnits: &oneSpouse, Stage: model.AllowanceStageBefore},
+		{Key: "life-insurance", CapAmount: &lifeCap, Group: "insurance", GroupCap: &insuranceCap, Stage: model.AllowanceStageBefore},
+		{Key: "health-insurance", CapAmount: &healthCap, Group: "insurance", GroupCap: &insuranceCap, Stage: model.AllowanceStageBefore},
+		{Key: "provident-fund", CapAmount: &fundCap, CapRate: &fundRate, Group: "retirement", GroupCap: &retirementCap, Stage: model.AllowanceStageBefore},
+		{Key: "rmf", CapAmount: &fundCap, CapRate: &rmfRate, Group: "retirement", GroupCap: &retirementCap, Stage: model.AllowanceStageBefore},
+	}
+
+	testCases := []struct {
+		name       string
+		allowances []model.Allowance
+		taxable    model.Money
+		err        error
+	}{
+		{
+			"Children and spouse by count",
+			[]model.Allowance{{AllowanceType: "child", Count: 2}, {AllowanceType: "spouse", Count: 1}},
+			model.Baht(2000000 - 60000 - 60000 - 60000),
+			nil,
+		},
+		{
+			"Insurance shares the group cap",
+			[]model.Allowance{
+				{AllowanceType: "life-insurance", Amount: model.Baht(90000)},
+				{AllowanceType: "health-insurance", Amount: model.Baht(25000)},
+			},
+			model.Baht(2000000 - 60000 - 100000),
+			nil,
+		},
+		{
+			"Retirement savings share 500,000",
+			[]model.Allowance{
+				{AllowanceType: "provident-fund", Amount: model.Baht(400000)},
+				{AllowanceType: "rmf", Amount: model.Baht(400000)},
+			},
+			model.Baht(2000000 - 60000 - 500000), // 300,000 (15%) + 200,000 left of the group
+			nil,
+		},
+		{
+			"More units than allowed",
+			[]model.Allowance{{AllowanceType: "spouse", Count: 2}},
+			0,
+			service.ErrInvalidAllowance,
+		},
+		{
+			"Unit type claimed by amount",
+			[]model.Allowance{{AllowanceType: "child", Amount: model.Baht(30000)}},
+			0,
+			service.ErrInvalidAllowance,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			adminRepo := mocks.NewMockAdminRepository(ctrl)
+			adminRepo.EXPECT().GetConfig(0).Return(&model.AdminConfig{
+				PersonalDeduction: model.Baht(60000),
+				Brackets:          []model.TaxBracket{{Threshold: model.Baht(0), Rate: model.Percent(0)}},
+			}, nil)
+			allowanceRepo := mocks.NewMockAllowanceRepository(ctrl)
+			allowanceRepo.EXPECT().ListAllowanceTypes().Return(catalogue, nil)
+
+			taxSvc := service.NewTaxCalculatorService(nil, adminRepo, allowanceRepo)
+			taxCalculation, err := taxSvc.Compute(model.TaxCalculationRequest{
+				TotalIncome: model.Baht(2000000),
+				Allowances:  tc.allowances,
+			})
+
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.taxable, taxCalculation.TaxableIncome)
+		})
+	}
+}
+
+func TestTaxCalculatorService_ComputeWithIncomes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		incomes  []model.Income
+		expenses model.Money
+		taxable  model.Money
+	}{
+		{
+			"Salary expenses capped at 100,000",
+			[]model.Income{{Category: model.IncomeSalary, Amount: model.Baht(600000)}},
+			model.Baht(100000),
+			model.Baht(600000 - 100000 - 60000),
+		},
+		{
+			"Salary and service fees share the cap",
+			[]model.Income{
+				{Category: model.IncomeSalary, Amount: model.Baht(120000)},
+				{Category: model.IncomeService, Amount: model.Baht(100000)},
+			},
+			model.Baht(100000), // 60,000 + 40,000 left of the cap
+			model.Baht(220000 - 100000 - 60000),
+		},
+		{
+			"Rent, business and interest",
+			[]model.Income{
+				{Category: model.IncomeRent, Amount: model.Baht(200000)},
+				{Category: model.IncomeBusiness, Amount: model.Baht(100000)},
+				{Category: model.IncomeInvestment, Amount: model.Baht(50000)},
+			},
+			model.Baht(60000 + 60000),
+			model.Baht(350000 - 120000 - 60000),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			adminRepo := mocks.NewMockAdminRepository(ctrl)
+			adminRepo.EXPECT().GetConfig(0).Return(&model.AdminConfig{
+				PersonalDeduction: model.Baht(60000),
+				Brackets:          []model.TaxBracket{{Threshold: model.Baht(0), Rate: model.Percent(0)}},
+			}, nil)
+
+			taxSvc := service.NewTaxCalculatorService(nil, adminRepo, allowanceCatalogue(ctrl))
+			taxCalculation, err := taxSvc.Compute(model.TaxCalculationRequest{Incomes: tc.incomes})
+
+			assert.NoError(t, err)
+			assert.Equal(t, model.TotalIncome(tc.incomes), taxCalculation.TotalIncome)
+			assert.Equal(t, tc.expenses, taxCalculation.Expenses)
+			assert.Equal(t, tc.taxable, taxCalculation.TaxableIncome)
+		})
+	}
+}
+
+func TestTaxCalculatorService_GrossUp(t *testing.T) {
+	money := func(amount model.Money) *model.Money { return &amount }
+
+	testCases := []struct {
+		name   string
+		req    model.GrossUpRequest
+		income model.Money
+		tax    model.Money
+	}{
+		{"Target net", model.GrossUpRequest{TargetNet: money(model.Baht(471000))}, model.Baht(500000), model.Baht(29000)},
+		// 28,999.995 baht of tax rounds up to 29,000
+		{"Target tax", model.GrossUpRequest{TargetTax: money(model.Baht(29000))}, model.Baht(500000) - 5, model.Baht(29000)},
+		{"Target tax of a salary", model.GrossUpRequest{TargetTax: money(model.Baht(29000)), Category: model.IncomeSalary}, model.Baht(600000) - 5, model.Baht(29000)},
+		{"Target net under the exempt bracket", model.GrossUpRequest{TargetNet: money(model.Baht(100000))}, model.Baht(100000), 0},
+		{"Target net between whole baht", model.GrossUpRequest{TargetNet: money(model.Baht(471000) + 5)}, model.Money(50000006), model.Money(2900001)},
+		{
+			"Target net with allowances",
+			model.GrossUpRequest{TargetNet: money(model.Baht(471000)), Allowances: []model.Allowance{{AllowanceType: model.AllowanceKReceipt, Amount: model.Baht(50000)}}},
+			model.Baht(494444) + 44, // taxable 384,444.44 taxed 23,444.44
+			model.Money(2344444),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			adminRepo := mocks.NewMockAdminRepository(ctrl)
+			adminRepo.EXPECT().GetConfig(0).Return(&model.AdminConfig{
+				PersonalDeduction: model.Baht(60000),
+				KReceipt:          model.Baht(50000),
+				Brackets:          taxBrackets,
+			}, nil)
+
+			taxSvc := service.NewTaxCalculatorService(nil, adminRepo, allowanceCatalogue(ctrl))
+			result, err := taxSvc.GrossUp(tc.req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.income, result.TotalIncome)
+			assert.Equal(t, tc.tax, result.Tax)
+			assert.Equal(t, result.TotalIncome-result.Tax, result.NetIncome)
+		})
+	}
+}
+
+func TestTaxCalculatorService_GrossUpUnreachable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	adminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminRepo.EXPECT().GetConfig(0).Return(&model.AdminConfig{
+		PersonalDeduction: model.Baht(60000),
+		Brackets:          []model.TaxBracket{{Threshold: model.Baht(0), Rate: model.Percent(0)}},
+	}, nil)
+
+	taxSvc := service.NewTaxCalculatorService(nil, adminRepo, allowanceCatalogue(ctrl))
+	targetTax := model.Baht(1000)
+	_, err := taxSvc.GrossUp(model.GrossUpRequest{TargetTax: &targetTax})
+
+	assert.ErrorIs(t, err, service.ErrTargetUnreachable)
+}
+
+func TestTaxCalculatorService_CalculateHousehold(t *testing.T) {
+	testCases := []struct {
+		name        string
+		spouses     []model.TaxCalculationRequest
+		separate    model.Money
+		joint       model.Money
+		recommended string
+	}{
+		{
+			"Joint when one spouse has no income",
+			[]model.TaxCalculationRequest{{TotalIncome: model.Baht(1000000)}, {TotalIncome: 0}},
+			model.Baht(101000), // 940,000 taxable
+			model.Baht(92000),  // 880,000 taxable
+			model.FilingJoint,
+		},
+		{
+			"Separate when incomes are equal",
+			[]model.TaxCalculationRequest{{TotalIncome: model.Baht(500000)}, {TotalIncome: model.Baht(500000)}},
+			model.Baht(29000 * 2),
+			model.Baht(92000),
+			model.FilingSeparate,
+		},
+		{
+			"Expenses worked out per spouse",
+			[]model.TaxCalculationRequest{
+				{Incomes: []model.Income{{Category: model.IncomeSalary, Amount: model.Baht(400000)}}},
+				{Incomes: []model.Income{{Category: model.IncomeSalary, Amount: model.Baht(400000)}}},
+			},
+			model.Baht(9000 * 2), // 400,000 - 100,000 - 60,000 each
+			model.Baht(33000),    // 800,000 - 200,000 - 120,000
+			model.FilingSeparate,
+		},
+		{
+			"Spouse allowance only on the separate return",
+			[]model.TaxCalculationRequest{
+				{TotalIncome: model.Baht(1000000), Allowances: []model.Allowance{{AllowanceType: model.AllowanceSpouse, Count: 1}}},
+				{TotalIncome: 0},
+			},
+			model.Baht(92000), // 1,000,000 - 60,000 - 60,000
+			model.Baht(92000), // 1,000,000 - 120,000, not a third 60,000
+			model.FilingSeparate,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			adminRepo := mocks.NewMockAdminRepository(ctrl)
+			adminRepo.EXPECT().GetConfig(2024).Return(&model.AdminConfig{
+				TaxYear:           2024,
+				PersonalDeduction: model.Baht(60000),
+				Brackets:          taxBrackets,
+			}, nil)
+
+			spouseAllowance, maxUnits := model.Baht(60000), 1
+			taxSvc := service.NewTaxCalculatorService(nil, adminRepo, allowanceCatalogue(ctrl,
+				&model.AllowanceType{Key: model.AllowanceSpouse, UnitAmount: &spouseAllowance, MaxUnits: &maxUnits, Stage: model.AllowanceStageBefore},
+			))
+			response, err := taxSvc.CalculateHousehold(model.HouseholdRequest{TaxYear: 2024, Spouses: tc.spouses})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.separate, response.Separate.Tax)
+			assert.Len(t, response.Separate.Returns, 2)
+			assert.Equal(t, tc.joint, response.Joint.Tax)
+			assert.Len(t, response.Joint.Returns, 1)
+			assert.Equal(t, tc.recommended, response.Recommended)
+		})
+	}
+}
+
+func TestTaxCalculatorService_CalculateTaxExplain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	adminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminRepo.EXPECT().GetConfig(0).Return(&model.AdminConfig{
+		PersonalDeduction: model.Baht(60000),
+		KReceipt:          model.Baht(50000),
+		DonationCap:       model.Baht(100000),
+		Brackets:          taxBrackets,
+	}, nil)
+	taxRepo := mocks.NewMockTaxRepository(ctrl)
+	taxRepo.EXPECT().Save(gomock.Any()).Return(nil)
+
+	fundCap, fundRate, tenPercent := model.Baht(500000), model.Percent(15), model.Percent(10)
+	taxSvc := service.NewTaxCalculatorService(taxRepo, adminRepo, allowanceCatalogue(ctrl,
+		&model.AllowanceType{Key: "provident-fund", CapAmount: &fundCap, CapRate: &fundRate, Stage: model.AllowanceStageBefore},
+		&model.AllowanceType{Key: model.AllowanceEducationDonation, CapRate: &tenPercent, Stage: model.AllowanceStageAfter},
+	))
+
+	response, err := taxSvc.CalculateTax(model.TaxCalculationRequest{
+		TotalIncome: model.Baht(800000),
+		Incomes:     []model.Income{{Category: model.IncomeSalary, Amount: model.Baht(800000)}},
+		Allowances: []model.Allowance{
+			{AllowanceType: "provident-fund", Amount: model.Baht(200000)},
+			{AllowanceType: model.AllowanceKReceipt, Amount: model.Baht(70000)},
+			{AllowanceType: model.AllowanceEducationDonation, Amount: model.Baht(30000)},
+		},
+		Explain: true,
+	})
+
+	// 800,000 - 100,000 - 60,000 - 120,000 - 50,000 = 470,000 left before
+	// the donation, of which 10% is 47,000
+	assert.NoError(t, err)
+	assert.Equal(t, []model.CalculationStep{
+		{Step: model.StepIncome, Amount: model.Baht(800000)},
+		{Step: model.StepExpense, Key: model.IncomeSalary, Base: moneyOf(model.Baht(800000)), Rate: rateOf(model.Percent(50)),
+			Cap: moneyOf(model.Baht(100000)), Amount: model.Baht(100000), Reason: "capped at the 100000.00 left of the 100000.00 cap shared by employment"},
+		{Step: model.StepPersonalAllowance, Amount: model.Baht(60000)},
+		{Step: model.StepAllowance, Key: model.AllowanceKReceipt, Claimed: moneyOf(model.Baht(70000)),
+			Cap: moneyOf(model.Baht(50000)), Amount: model.Baht(50000), Reason: "capped at the rule set's 50000.00"},
+		{Step: model.StepAllowance, Key: "provident-fund", Claimed: moneyOf(model.Baht(200000)),
+			Cap: moneyOf(model.Baht(120000)), Amount: model.Baht(120000), Reason: "capped at 15% of total income, 800000.00"},
+		{Step: model.StepAllowance, Key: model.AllowanceEducationDonation, Claimed: moneyOf(model.Baht(30000)),
+			Cap: moneyOf(model.Baht(47000)), Amount: model.Baht(47000), Reason: "counted twice, capped at 10% of the income left, 470000.00"},
+		{Step: model.StepTaxableIncome, Amount: model.Baht(423000)},
+		{Step: model.StepBracket, Key: "0-150,000", Base: moneyOf(model.Baht(150000)), Rate: rateOf(0), Amount: 0},
+		{Step: model.StepBracket, Key: "150,001-500,000", Base: moneyOf(model.Baht(273000)), Rate: rateOf(model.Percent(10)), Amount: model.Baht(27300)},
+		{Step: model.StepTax, Amount: model.Baht(27300)},
+		{Step: model.StepWHT, Amount: 0},
+		{Step: model.StepTaxPayable, Amount: model.Baht(27300)},
+	}, response.Explanation)
+	assert.Equal(t, model.Baht(27300), *response.Tax)
+}
@@ -19,104 +19,290 @@ func TestTaxCSVService_ImportCSV(t *testing.T) {
 	adminRepo := mocks.NewMockAdminRepository(ctrl)
 
 	adminConfig := &model.AdminConfig{
-		PersonalDeduction: 60000,
+		PersonalDeduction: model.Baht(60000),
+		DonationCap:       model.Baht(100000),
+		Brackets:          taxBrackets,
 	}
-	adminRepo.EXPECT().GetConfig().Return(adminConfig, nil).Times(3)
+	adminRepo.EXPECT().GetConfig(0).Return(adminConfig, nil).Times(3)
 
 	csvData := `income,wht,donation
    500000,0,0
    600000,40000,20000
    750000,50000,15000`
 
-	expectedResult := []map[string]float64{
-		{"totalIncome": 500000, "tax": 29000},
-		{"totalIncome": 600000, "taxRefund": 2000},
-		{"totalIncome": 750000, "tax": 11250},
+	expectedResult := []map[string]model.Money{
+		{"totalIncome": model.Baht(500000), "tax": model.Baht(29000)},
+		{"totalIncome": model.Baht(600000), "taxRefund": model.Baht(2000)},
+		{"totalIncome": model.Baht(750000), "tax": model.Baht(11250)},
 	}
 
-	taxCSVService := service.NewTaxCSVService(taxRepo, adminRepo)
+	taxCSVService := service.NewTaxCSVService(service.NewTaxCalculatorService(taxRepo, adminRepo, allowanceCatalogue(ctrl)))
 	result, err := taxCSVService.ImportCSV(strings.NewReader(csvData))
 
 	assert.NoError(t, err)
-	assert.Equal(t, expectedResult, result)
+	assert.Equal(t, expectedResult, result.Taxes)
+	assert.Empty(t, result.Errors)
 }
 
-func TestTaxCSVService_CalculateTax(t *testing.T) {
+func TestTaxCSVService_ImportCSVWithTaxYear(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
+
 	taxRepo := mocks.NewMockTaxRepository(ctrl)
 	adminRepo := mocks.NewMockAdminRepository(ctrl)
-	adminConfig := &model.AdminConfig{
-		PersonalDeduction: 60000,
+
+	adminRepo.EXPECT().GetConfig(0).Return(&model.AdminConfig{PersonalDeduction: model.Baht(60000), DonationCap: model.Baht(100000), Brackets: taxBrackets}, nil)
+	adminRepo.EXPECT().GetConfig(2023).Return(&model.AdminConfig{PersonalDeduction: model.Baht(100000), Brackets: taxBrackets}, nil)
+
+	csvData := `totalIncome,wht,donation,taxYear
+500000,0,0,
+500000,0,0,2023`
+
+	expectedResult := []map[string]model.Money{
+		{"totalIncome": model.Baht(500000), "tax": model.Baht(29000)},
+		{"totalIncome": model.Baht(500000), "tax": model.Baht(25000)},
 	}
-	adminRepo.EXPECT().GetConfig().Return(adminConfig, nil).Times(3)
-	taxCSVService := service.NewTaxCSVService(taxRepo, adminRepo)
 
+	taxCSVService := service.NewTaxCSVService(service.NewTaxCalculatorService(taxRepo, adminRepo, allowanceCatalogue(ctrl)))
+	result, err := taxCSVService.ImportCSV(strings.NewReader(csvData))
+
+	assert.NoError(t, err)
+	assert.Equal(t, expectedResult, result.Taxes)
+	assert.Empty(t, result.Errors)
+}
+
+func TestTaxCSVService_ImportCSVWithRowErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	taxRepo := mocks.NewMockTaxRepository(ctrl)
+	adminRepo := mocks.NewMockAdminRepository(ctrl)
+
+	adminConfig := &model.AdminConfig{ID: 1, TaxYear: 2024, Version: 1, PersonalDeduction: model.Baht(60000), DonationCap: model.Baht(100000), Brackets: taxBrackets}
+	adminRepo.EXPECT().GetConfig(0).Return(adminConfig, nil).Times(2)
+	adminRepo.EXPECT().GetConfig(1999).Return(nil, nil)
+
+	csvData := `totalIncome,wht,donation,taxYear
+500000,0,0
+abc,0,0
+500000,-1,0
+500000,0,0,1999
+500000,0,x
+600000,40000,20000
+`
+
+	taxCSVService := service.NewTaxCSVService(service.NewTaxCalculatorService(taxRepo, adminRepo, allowanceCatalogue(ctrl)))
+	result, err := taxCSVService.ImportCSV(strings.NewReader(csvData))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]model.Money{
+		{"totalIncome": model.Baht(500000), "tax": model.Baht(29000)},
+		{"totalIncome": model.Baht(600000), "taxRefund": model.Baht(2000)},
+	}, result.Taxes)
+
+	assert.Len(t, result.Errors, 4)
+	assert.Equal(t, model.CSVRowError{Row: 3, Column: "totalIncome", Reason: `invalid amount "abc"`}, result.Errors[0])
+	assert.Equal(t, model.CSVRowError{Row: 4, Column: "wht", Reason: "must not be negative"}, result.Errors[1])
+	assert.Equal(t, 5, result.Errors[2].Row)
+	assert.Equal(t, "taxYear", result.Errors[2].Column)
+	assert.Equal(t, model.CSVRowError{Row: 6, Column: "donation", Reason: `invalid amount "x"`}, result.Errors[3])
+}
+
+func TestTaxCSVService_ImportCSVWithAllowanceUnknownToTaxYear(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	adminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminRepo.EXPECT().GetConfig(2023).Return(&model.AdminConfig{
+		PersonalDeduction: model.Baht(100000),
+		Brackets:          taxBrackets,
+		AllowanceTypes:    builtinAllowanceTypes,
+	}, nil).Times(2)
+
+	csvData := `totalIncome,wht,provident-fund,taxYear
+500000,0,,2023
+500000,0,30000,2023
+`
+
+	allowanceRepo := allowanceCatalogue(ctrl, &model.AllowanceType{Key: "provident-fund"})
+	taxCSVService := service.NewTaxCSVService(service.NewTaxCalculatorService(mocks.NewMockTaxRepository(ctrl), adminRepo, allowanceRepo))
+	result, err := taxCSVService.ImportCSV(strings.NewReader(csvData))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []map[string]model.Money{{"totalIncome": model.Baht(500000), "tax": model.Baht(25000)}}, result.Taxes)
+	assert.Equal(t, []model.CSVRowError{{Row: 3, Column: "provident-fund", Reason: "unknown allowance type"}}, result.Errors)
+}
+
+func TestTaxCSVService_ImportCSVWithHeaderSchema(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	adminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminRepo.EXPECT().GetConfig(0).Return(&model.AdminConfig{
+		PersonalDeduction: model.Baht(60000),
+		KReceipt:          model.Baht(50000),
+		Brackets:          taxBrackets,
+	}, nil).Times(2)
+
+	// k-receipt is capped at 50,000: taxable 500,000 - 60,000 - 50,000 = 390,000
+	csvData := `name,K-Receipt,WHT,Total Income,note
+somchai,80000,0,500000,x
+somsri,,10000,500000,
+`
+
+	taxCSVService := service.NewTaxCSVService(service.NewTaxCalculatorService(nil, adminRepo, allowanceCatalogue(ctrl)))
+	result, err := taxCSVService.ImportCSV(strings.NewReader(csvData))
+
+	assert.NoError(t, err)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, []map[string]model.Money{
+		{"totalIncome": model.Baht(500000), "tax": model.Baht(24000)},
+		{"totalIncome": model.Baht(500000), "tax": model.Baht(19000)},
+	}, result.Taxes)
+}
+
+func TestTaxCSVService_ImportCSVWithMissingColumn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	taxCSVService := service.NewTaxCSVService(service.NewTaxCalculatorService(nil, mocks.NewMockAdminRepository(ctrl), allowanceCatalogue(ctrl)))
+	result, err := taxCSVService.ImportCSV(strings.NewReader("totalIncome,donation\n500000,0\n"))
+
+	assert.NoError(t, err)
+	assert.Empty(t, result.Taxes)
+	assert.Equal(t, []model.CSVRowError{{Row: 1, Reason: "missing required column(s): wht"}}, result.Errors)
+}
+
+func TestTaxCSVService_ImportCSVWithMalformedLine(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	adminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminRepo.EXPECT().GetConfig(0).Return(&model.AdminConfig{PersonalDeduction: model.Baht(60000), DonationCap: model.Baht(100000), Brackets: taxBrackets}, nil)
+
+	csvData := "totalIncome,wht,donation\n500000,0,0\n5000\"00,0,0\n"
+
+	// dry run: nothing is saved
+	taxCSVService := service.NewTaxCSVService(service.NewTaxCalculatorService(nil, adminRepo, allowanceCatalogue(ctrl)))
+	result, err := taxCSVService.ImportCSV(strings.NewReader(csvData))
+
+	assert.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.Len(t, result.Taxes, 1)
+	assert.Len(t, result.Errors, 1)
+	assert.Equal(t, 3, result.Errors[0].Row)
+	assert.Empty(t, result.Errors[0].Column)
+}
+
+func TestParseCSVHeader(t *testing.T) {
 	testCases := []struct {
-		name     string
-		income   float64
-		wht      float64
-		donation float64
-		expected float64
+		name   string
+		header []string
+		err    string
 	}{
-		{"Case 1", 500000, 0, 0, 29000},
-		{"Case 2", 600000, 40000, 20000, 0},
-		{"Case 3", 750000, 50000, 15000, 11250},
+		{"Positional order", []string{"totalIncome", "wht", "donation"}, ""},
+		{"Reordered with extra columns", []string{"employeeId", "WHT", "K-Receipt", "Total Income", "department"}, ""},
+		{"Income alias", []string{"\ufeffincome", "wht"}, ""},
+		{"Missing wht", []string{"totalIncome", "donation"}, "missing required column(s): wht"},
+		{"Missing both", []string{"name"}, "missing required column(s): totalIncome, wht"},
+		{"Duplicate column", []string{"totalIncome", "wht", "total_income"}, "duplicate column totalIncome"},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result, _, err := taxCSVService.CalculateTax(tc.income, tc.wht, tc.donation)
-			assert.NoError(t, err)
-			assert.Equal(t, tc.expected, result)
+			schema, err := service.ParseCSVHeader(tc.header, builtinAllowanceTypes)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				assert.Nil(t, schema)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, schema)
+			}
 		})
 	}
 }
 
-func TestParseFields(t *testing.T) {
+func TestCSVSchema_ParseRow(t *testing.T) {
+	schema, err := service.ParseCSVHeader([]string{"employeeId", "k-receipt", "wht", "totalIncome", "donation", "taxYear"}, builtinAllowanceTypes)
+	assert.NoError(t, err)
+
 	testCases := []struct {
 		name     string
 		input    []string
-		expected []float64
-		hasError bool
+		expected model.TaxCalculationRequest
+		err      *model.CSVRowError
 	}{
-		{"Valid input", []string{"500000", "50000", "0"}, []float64{500000, 50000, 0}, false},
-		{"Missing fields", []string{"600000", "40000", "20000"}, []float64{600000, 40000, 20000}, false},
-		{"Invalid income", []string{"invalid", "50000", "0"}, nil, true},
-		{"Invalid wht", []string{"500000", "invalid", "0"}, nil, true},
-		{"Invalid donation", []string{"500000", "50000", "invalid"}, nil, true},
+		{
+			"Valid input",
+			[]string{"E1", "20000", "50000", "500000", "1000", "2023"},
+			model.TaxCalculationRequest{TaxYear: 2023, TotalIncome: model.Baht(500000), WHT: model.Baht(50000), Allowances: []model.Allowance{
+				{AllowanceType: "donation", Amount: model.Baht(1000)},
+				{AllowanceType: "k-receipt", Amount: model.Baht(20000)},
+			}},
+			nil,
+		},
+		{
+			"Blank and missing optional fields",
+			[]string{"E2", "", " ", "600000.25"},
+			model.TaxCalculationRequest{TotalIncome: model.Money(60000025), Allowances: []model.Allowance{}},
+			nil,
+		},
+		{"Missing income", []string{"E3", "0", "0", ""}, model.TaxCalculationRequest{}, &model.CSVRowError{Column: "totalIncome", Reason: "is required"}},
+		{"Invalid wht", []string{"E4", "0", "invalid", "500000"}, model.TaxCalculationRequest{}, &model.CSVRowError{Column: "wht", Reason: `invalid amount "invalid"`}},
+		{"Negative k-receipt", []string{"E5", "-1", "0", "500000"}, model.TaxCalculationRequest{}, &model.CSVRowError{Column: "k-receipt", Reason: "must not be negative"}},
+		{"Invalid tax year", []string{"E6", "0", "0", "500000", "0", "last"}, model.TaxCalculationRequest{}, &model.CSVRowError{Column: "taxYear", Reason: "must be a year"}},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			income, wht, donation, err := service.ParseFields(tc.input)
-			if tc.hasError {
-				assert.Error(t, err)
+			req, err := schema.ParseRow(tc.input)
+			if tc.err != nil {
+				assert.Equal(t, tc.err, err)
 			} else {
 				assert.NoError(t, err)
-				assert.Equal(t, tc.expected[0], income)
-				assert.Equal(t, tc.expected[1], wht)
-				assert.Equal(t, tc.expected[2], donation)
+				assert.Equal(t, tc.expected, req)
 			}
 		})
 	}
 }
 
+func TestParseCSVHeaderWithCatalogueAllowance(t *testing.T) {
+	schema, err := service.ParseCSVHeader([]string{"totalIncome", "wht", "Provident Fund", "golf"}, append(builtinAllowanceTypes, &model.AllowanceType{Key: "provident-fund"}))
+	assert.NoError(t, err)
+
+	req, err := schema.ParseRow([]string{"500000", "0", "30000", "9000"})
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Allowance{{AllowanceType: "provident-fund", Amount: model.Baht(30000)}}, req.Allowances)
+}
+
+func TestCSVSchema_ParseRowSkipsBlankClaims(t *testing.T) {
+	unitAmount := model.Baht(30000)
+	allowanceTypes := append(builtinAllowanceTypes, &model.AllowanceType{Key: "provident-fund"}, &model.AllowanceType{Key: "child", UnitAmount: &unitAmount})
+	schema, err := service.ParseCSVHeader([]string{"totalIncome", "wht", "provident-fund", "child", "donation"}, allowanceTypes)
+	assert.NoError(t, err)
+
+	req, err := schema.ParseRow([]string{"500000", "0", "", "0", "1000"})
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Allowance{{AllowanceType: "donation", Amount: model.Baht(1000)}}, req.Allowances)
+}
+
 func TestParseDonation(t *testing.T) {
 	testCases := []struct {
 		name     string
 		input    string
-		expected float64
+		expected model.Money
 		hasError bool
 	}{
-		{"Valid amount", "10000", 10000, false},
-		{"Valid percentage", "5%", 0.05, false},
+		{"Valid amount", "10000", model.Baht(10000), false},
+		{"Valid percentage", "5%", model.Baht(25000), false},
+		{"Fractional percentage", "2.5%", model.Baht(12500), false},
+		{"Percentage above 100", "150%", 0, true},
 		{"Invalid input", "invalid", 0, true},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result, err := service.ParseDonation(tc.input)
+			result, err := service.ParseDonation(tc.input, model.Baht(500000))
 			if tc.hasError {
 				assert.Error(t, err)
 			} else {
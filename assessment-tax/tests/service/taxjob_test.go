@@ -0,0 +1,207 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/repository"
+	"github.com/LGROW101/assessment-tax/service"
+	"github.com/LGROW101/assessment-tax/tests/repository/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaxJobService_Submit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	uploadRepo := mocks.NewMockUploadRepository(ctrl)
+	uploadRepo.EXPECT().CreateUpload(gomock.Any(), []byte("totalIncome,wht\n")).
+		DoAndReturn(func(upload *model.TaxUpload, content []byte) error {
+			upload.ID = 3
+			upload.Status = model.UploadQueued
+			return nil
+		})
+
+	jobSvc := service.NewTaxJobService(uploadRepo, nil, 2)
+	upload, err := jobSvc.Submit("payroll.csv", []byte("totalIncome,wht\n"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, uint(3), upload.ID)
+	assert.Equal(t, "payroll.csv", upload.Filename)
+	assert.Equal(t, model.UploadQueued, upload.Status)
+}
+
+func TestTaxJobService_Run(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	adminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminRepo.EXPECT().GetConfig(0).
+		Return(&model.AdminConfig{PersonalDeduction: model.Baht(60000), DonationCap: model.Baht(100000), Brackets: taxBrackets}, nil).
+		Times(1)
+
+	uploadRepo := mocks.NewMockUploadRepository(ctrl)
+	writer := mocks.NewMockUploadResultWriter(ctrl)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	upload := &model.TaxUpload{ID: 8, Filename: "payroll.csv", Status: model.UploadRunning}
+	content := "totalIncome,wht\n500000,0\nabc,0\n600000,40000\n"
+
+	var inserted []*model.TaxCalculation
+	uploadRepo.EXPECT().RequeueStaleUploads(gomock.Any()).Return(int64(0), nil)
+	uploadRepo.EXPECT().ClaimUpload().Return(upload, []byte(content), nil)
+	uploadRepo.EXPECT().BeginResults(upload).Return(writer, nil)
+	uploadRepo.EXPECT().UpdateProgress(gomock.Any()).Return(nil).AnyTimes()
+	writer.EXPECT().Insert(gomock.Any()).
+		DoAndReturn(func(taxCalculation *model.TaxCalculation) error {
+			inserted = append(inserted, taxCalculation)
+			return nil
+		}).
+		Times(2)
+	writer.EXPECT().Complete().DoAndReturn(func() error {
+		cancel()
+		return nil
+	})
+
+	taxSvc := service.NewTaxCalculatorService(mocks.NewMockTaxRepository(ctrl), adminRepo, allowanceCatalogue(ctrl))
+	service.NewTaxJobService(uploadRepo, taxSvc, 2).Run(ctx)
+
+	assert.Len(t, inserted, 2)
+	rows := map[int]model.Money{}
+	for _, taxCalculation := range inserted {
+		rows[taxCalculation.UploadRow] = taxCalculation.TotalIncome
+	}
+	assert.Equal(t, map[int]model.Money{2: model.Baht(500000), 4: model.Baht(600000)}, rows)
+	assert.Equal(t, 3, upload.RowCount)
+	assert.Equal(t, 1, upload.ErrorCount)
+	assert.Equal(t, []model.CSVRowError{{Row: 3, Column: "totalIncome", Reason: "invalid amount \"abc\""}}, upload.Errors)
+}
+
+func TestTaxJobService_RunFailsJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	adminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminRepo.EXPECT().GetConfig(0).
+		Return(&model.AdminConfig{PersonalDeduction: model.Baht(60000), Brackets: taxBrackets}, nil).
+		AnyTimes()
+
+	uploadRepo := mocks.NewMockUploadRepository(ctrl)
+	writer := mocks.NewMockUploadResultWriter(ctrl)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	upload := &model.TaxUpload{ID: 9, Status: model.UploadRunning}
+	uploadRepo.EXPECT().RequeueStaleUploads(gomock.Any()).Return(int64(0), nil)
+	uploadRepo.EXPECT().ClaimUpload().Return(upload, []byte("totalIncome,wht\n500000,0\n600000,0\n"), nil)
+	uploadRepo.EXPECT().BeginResults(upload).Return(writer, nil)
+	uploadRepo.EXPECT().UpdateProgress(gomock.Any()).Return(nil).AnyTimes()
+	writer.EXPECT().Insert(gomock.Any()).Return(errors.New("database error"))
+	writer.EXPECT().Rollback().Return(nil)
+	uploadRepo.EXPECT().FailUpload(upload, "database error").DoAndReturn(func(upload *model.TaxUpload, reason string) error {
+		cancel()
+		return nil
+	})
+
+	taxSvc := service.NewTaxCalculatorService(mocks.NewMockTaxRepository(ctrl), adminRepo, allowanceCatalogue(ctrl))
+	service.NewTaxJobService(uploadRepo, taxSvc, 1).Run(ctx)
+}
+
+func TestTaxJobService_RunLeavesTakenOverJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	uploadRepo := mocks.NewMockUploadRepository(ctrl)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the job was requeued as stale and claimed again before this worker got
+	// to it: it is neither processed nor failed
+	upload := &model.TaxUpload{ID: 10, Attempt: 1, Status: model.UploadRunning}
+	uploadRepo.EXPECT().RequeueStaleUploads(gomock.Any()).Return(int64(0), nil)
+	uploadRepo.EXPECT().ClaimUpload().Return(upload, []byte("totalIncome,wht\n500000,0\n"), nil)
+	uploadRepo.EXPECT().UpdateProgress(gomock.Any()).DoAndReturn(func(progress *model.TaxUpload) error {
+		assert.Equal(t, uint(10), progress.ID)
+		assert.Equal(t, 1, progress.Attempt)
+		return repository.ErrUploadNotRunning
+	})
+	uploadRepo.EXPECT().ClaimUpload().DoAndReturn(func() (*model.TaxUpload, []byte, error) {
+		cancel()
+		return nil, nil, nil
+	})
+
+	service.NewTaxJobService(uploadRepo, nil, 1).Run(ctx)
+}
+
+func TestTaxJobService_WriteResult(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	uploadRepo := mocks.NewMockUploadRepository(ctrl)
+	jobSvc := service.NewTaxJobService(uploadRepo, nil, 1)
+
+	finishedAt := time.Now()
+	uploadRepo.EXPECT().GetUpload(uint(4)).Return(&model.TaxUpload{
+		ID:         4,
+		Status:     model.UploadCompleted,
+		RowCount:   4,
+		ErrorCount: 2,
+		Errors: []model.CSVRowError{
+			{Row: 3, Column: "wht", Reason: "must not be negative"},
+			{Row: 5, Reason: "wrong number of fields"},
+		},
+		FinishedAt: &finishedAt,
+	}, nil)
+	uploadRepo.EXPECT().EachResult(uint(4), gomock.Any()).
+		Times(2).
+		DoAndReturn(func(id uint, fn func(*model.TaxCalculation) error) error {
+			fn(&model.TaxCalculation{
+				UploadRow:         2,
+				TaxYear:           2024,
+				TotalIncome:       model.Baht(500000),
+				PersonalAllowance: model.Baht(60000),
+				TaxableIncome:     model.Baht(440000),
+				TaxLevel: []model.TaxRate{
+					{Level: "0-150,000", Tax: model.Baht(0)},
+					{Level: "150,001-500,000", Tax: model.Baht(29000)},
+				},
+				Tax:        model.Baht(29000),
+				TaxPayable: model.Baht(29000),
+			})
+			return fn(&model.TaxCalculation{
+				UploadRow:         4,
+				TaxYear:           2024,
+				TotalIncome:       model.Baht(150000),
+				WHT:               model.Baht(2000),
+				PersonalAllowance: model.Baht(60000),
+				TaxableIncome:     model.Baht(90000),
+				TaxLevel:          []model.TaxRate{{Level: "0-150,000", Tax: model.Baht(0)}},
+				TaxRefund:         model.Baht(2000),
+			})
+		})
+
+	var result strings.Builder
+	err := jobSvc.WriteResult(4, service.ExportCSV, &result)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "row,taxYear,totalIncome,wht,expenses,personalAllowance,donation,kReceipt,taxableIncome,"+
+		"\"tax 0-150,000\",\"tax 150,001-500,000\",tax,taxPayable,taxRefund,error\n"+
+		"2,2024,500000.00,0.00,0.00,60000.00,0.00,0.00,440000.00,0.00,29000.00,29000.00,29000.00,0.00,\n"+
+		"3,,,,,,,,,,,,,,wht: must not be negative\n"+
+		"4,2024,150000.00,2000.00,0.00,60000.00,0.00,0.00,90000.00,0.00,,0.00,0.00,2000.00,\n"+
+		"5,,,,,,,,,,,,,,wrong number of fields\n", result.String())
+
+	uploadRepo.EXPECT().GetUpload(uint(5)).Return(&model.TaxUpload{ID: 5, Status: model.UploadRunning}, nil)
+	err = jobSvc.WriteResult(5, service.ExportCSV, &result)
+	assert.ErrorIs(t, err, service.ErrJobNotFinished)
+
+	uploadRepo.EXPECT().GetUpload(uint(6)).Return(nil, nil)
+	err = jobSvc.WriteResult(6, service.ExportCSV, &result)
+	assert.ErrorIs(t, err, service.ErrJobNotFound)
+}
@@ -0,0 +1,109 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/LGROW101/assessment-tax/model"
+	"github.com/LGROW101/assessment-tax/service"
+	"github.com/LGROW101/assessment-tax/tests/repository/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func withholdingService(ctrl *gomock.Controller) service.WithholdingService {
+	adminRepo := mocks.NewMockAdminRepository(ctrl)
+	adminRepo.EXPECT().GetConfig(0).Return(&model.AdminConfig{
+		TaxYear:           2024,
+		PersonalDeduction: model.Baht(60000),
+		Brackets:          taxBrackets,
+	}, nil).AnyTimes()
+
+	return service.NewWithholdingService(service.NewTaxCalculatorService(nil, adminRepo, allowanceCatalogue(ctrl)))
+}
+
+func TestWithholdingService_Calculate(t *testing.T) {
+	// 50,000 a month: 600,000 - 100,000 expenses - 60,000 = 440,000 taxable,
+	// 29,000 tax, 2,416.67 a month
+	testCases := []struct {
+		name         string
+		req          model.WithholdingRequest
+		annualIncome model.Money
+		annualTax    model.Money
+		withholding  model.Money
+		trueUp       model.Money
+	}{
+		{
+			"First month",
+			model.WithholdingRequest{Month: 1, Salary: model.Baht(50000)},
+			model.Baht(600000), model.Baht(29000), model.Money(241667), 0,
+		},
+		{
+			"Catches up after withholding too little",
+			model.WithholdingRequest{Month: 7, Salary: model.Baht(50000), YTDIncome: model.Baht(300000), YTDWithheld: model.Baht(12000)},
+			model.Baht(600000), model.Baht(29000), model.Money(283333), model.Money(283333 - 241667),
+		},
+		{
+			"Raise from July",
+			model.WithholdingRequest{Month: 7, Salary: model.Baht(60000), YTDIncome: model.Baht(300000), YTDWithheld: model.Money(1450002)},
+			model.Baht(660000), model.Baht(35000), model.Money(341666), model.Money(341666 - 291667),
+		},
+		{
+			"Nothing left to withhold in December",
+			model.WithholdingRequest{Month: 12, Salary: model.Baht(50000), YTDIncome: model.Baht(550000), YTDWithheld: model.Baht(30000)},
+			model.Baht(600000), model.Baht(29000), 0, model.Money(-241667),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			result, err := withholdingService(ctrl).Calculate(tc.req)
+
+			assert.NoError(t, err)
+			assert.Equal(t, 2024, result.TaxYear)
+			assert.Equal(t, tc.annualIncome, result.AnnualIncome)
+			assert.Equal(t, tc.annualTax, result.AnnualTax)
+			assert.Equal(t, tc.withholding, result.Withholding)
+			assert.Equal(t, tc.trueUp, result.TrueUp)
+		})
+	}
+}
+
+func TestWithholdingService_ImportCSV(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	file := "Employee ID,Month,Salary,YTD Income,YTD Withheld,donation\n" +
+		"E1,1,50000,,,\n" +
+		"E2,13,50000,,,\n" +
+		"E3,7,50000,300000,12000,\n" +
+		"E4,1,abc,,,\n"
+	run, err := withholdingService(ctrl).ImportCSV(strings.NewReader(file))
+
+	assert.NoError(t, err)
+	if assert.Len(t, run.Results, 2) {
+		assert.Equal(t, 2, run.Results[0].Row)
+		assert.Equal(t, "E1", run.Results[0].EmployeeID)
+		assert.Equal(t, model.Money(241667), run.Results[0].Withholding)
+		assert.Equal(t, 4, run.Results[1].Row)
+		assert.Equal(t, model.Money(283333), run.Results[1].Withholding)
+	}
+	assert.Equal(t, []model.CSVRowError{
+		{Row: 3, Column: "month", Reason: "must be a month from 1 to 12"},
+		{Row: 5, Column: "salary", Reason: `invalid amount "abc"`},
+	}, run.Errors)
+}
+
+func TestWithholdingService_ImportCSVWithoutSalary(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	run, err := withholdingService(ctrl).ImportCSV(strings.NewReader("employeeId,month\nE1,1\n"))
+
+	assert.NoError(t, err)
+	assert.Empty(t, run.Results)
+	assert.Equal(t, []model.CSVRowError{{Row: 1, Reason: "missing required column(s): salary"}}, run.Errors)
+}
@@ -0,0 +1,16 @@
+// lib/adminToken.ts
+import axios from 'axios';
+
+// Logs in to the API as the user and returns the bearer token for /admin routes.
+// Rejects with a 401 axios error when the credentials are wrong.
+export async function adminToken(username: string, password: string): Promise<string> {
+  const response = await axios.post<{ token: string }>('http://api:8080/admin/login', { username, password });
+  return response.data.token;
+}
+
+// Reads the username and password of a Basic Authorization header.
+export function basicCredentials(auth: string): [string, string] {
+  const decoded = Buffer.from(auth.split(' ')[1] || '', 'base64').toString();
+  const separator = decoded.indexOf(':');
+  return [decoded.slice(0, separator), decoded.slice(separator + 1)];
+}
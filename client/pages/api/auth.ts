@@ -1,5 +1,6 @@
 // pages/api/auth.ts
 import type { NextApiRequest, NextApiResponse } from 'next';
+import { adminToken, basicCredentials } from '../../lib/adminToken';
 
 export default async function handler(req: NextApiRequest, res: NextApiResponse) {
   if (req.method === 'GET') {
@@ -9,11 +10,12 @@ export default async function handler(req: NextApiRequest, res: NextApiResponse)
       return res.status(401).json({ message: 'Authorization header is missing' });
     }
 
-    const [username, password] = Buffer.from(auth.split(' ')[1], 'base64').toString().split(':');
+    const [username, password] = basicCredentials(auth);
 
-    if (username === process.env.ADMIN_USERNAME && password === process.env.ADMIN_PASSWORD) {
+    try {
+      await adminToken(username, password);
       res.status(200).end();
-    } else {
+    } catch (error) {
       res.status(401).json({ message: 'Invalid username or password' });
     }
   } else {
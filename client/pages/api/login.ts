@@ -1,5 +1,6 @@
 import type { NextApiRequest, NextApiResponse } from 'next';
 import axios from 'axios';
+import { adminToken, basicCredentials } from '../../lib/adminToken';
 
 export default async function handler(req: NextApiRequest, res: NextApiResponse) {
   if (req.method === 'POST') {
@@ -10,9 +11,12 @@ export default async function handler(req: NextApiRequest, res: NextApiResponse)
       return res.status(401).json({ success: false, message: 'Authorization header is missing' });
     }
 
-    const [username, password] = Buffer.from(auth.split(' ')[1], 'base64').toString().split(':');
+    const [username, password] = basicCredentials(auth);
 
-    if (username !== process.env.ADMIN_USERNAME || password !== process.env.ADMIN_PASSWORD) {
+    let token: string;
+    try {
+      token = await adminToken(username, password);
+    } catch (error) {
       return res.status(401).json({ success: false, message: 'Invalid username or password' });
     }
 
@@ -20,7 +24,7 @@ export default async function handler(req: NextApiRequest, res: NextApiResponse)
       const response = await axios.post(
         'http://api:8080/admin/deductions',
         { personalDeduction: personalDeduction || undefined, k_receipt: kReceipt || undefined },
-        { headers: { Authorization: auth } }
+        { headers: { Authorization: `Bearer ${token}` } }
       );
 
       const { data } = response;
@@ -1,6 +1,7 @@
 // pages/api/settings.ts
 import { NextApiRequest, NextApiResponse } from 'next';
 import axios, { AxiosError } from 'axios';
+import { adminToken, basicCredentials } from '../../lib/adminToken';
 
 interface Setting {
   personalDeduction: number;
@@ -10,7 +11,10 @@ interface Setting {
 export default async function handler(req: NextApiRequest, res: NextApiResponse) {
   if (req.method === 'GET') {
     try {
-      const response = await axios.get<Setting>('http://api:8080/admin/deductions');
+      const token = await adminToken(process.env.ADMIN_USERNAME || '', process.env.ADMIN_PASSWORD || '');
+      const response = await axios.get<Setting>('http://api:8080/admin/deductions', {
+        headers: { Authorization: `Bearer ${token}` },
+      });
       const { personalDeduction, kReceipt } = response.data;
       res.status(200).json({ personalDeduction, kReceipt });
     } catch (error) {
@@ -24,27 +28,26 @@ export default async function handler(req: NextApiRequest, res: NextApiResponse)
       return res.status(401).json({ message: 'Authorization header is missing' });
     }
 
-    const [username, password] = Buffer.from(auth.split(' ')[1], 'base64').toString().split(':');
-
-    if (username !== process.env.ADMIN_USERNAME || password !== process.env.ADMIN_PASSWORD) {
-      return res.status(401).json({ message: 'Invalid username or password' });
-    }
+    const [username, password] = basicCredentials(auth);
 
     const { personalDeduction, kReceipt } = req.body;
     try {
+      const token = await adminToken(username, password);
       await axios.post<Setting>('http://api:8080/admin/deductions', {
         personalDeduction,
         k_receipt: kReceipt,
       }, {
-        headers: { Authorization: auth },
+        headers: { Authorization: `Bearer ${token}` },
       });
       res.status(200).json({ message: 'Settings updated successfully' });
     } catch (error) {
       console.error('Error updating settings:', error);
       if (axios.isAxiosError(error)) {
         const axiosError = error as AxiosError;
-        if (axiosError.response && axiosError.response.status === 401) {
+        if (axiosError.response && (axiosError.response.status === 401 || axiosError.response.status === 403)) {
           res.status(401).json({ message: 'Unauthorized' });
+        } else if (axiosError.response && axiosError.response.status === 409) {
+          res.status(409).json({ message: 'Settings were changed by another admin, reload and try again' });
         } else {
           res.status(500).json({ message: 'Internal server error' });
         }